@@ -0,0 +1,92 @@
+// Команда ratesimport загружает курсы валют из CSV или JSON файлов без доступа к интернету.
+//
+//	go run ./cmd/ratesimport [-format csv|json] [-v] rates.csv more-rates.json
+//
+// Формат по умолчанию определяется по расширению файла.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"personal-finance-tracker/internal/config"
+	"personal-finance-tracker/internal/repository"
+	"personal-finance-tracker/internal/service"
+	"strings"
+)
+
+func main() {
+	format := flag.String("format", "", "file format: csv or json (default: by file extension)")
+	verbose := flag.Bool("v", false, "print every imported row")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: ratesimport [-format csv|json] [-v] FILE...")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	repo, err := repository.NewPostgresRepository(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer repo.Close()
+
+	// Провайдеры не нужны: курсы берутся только из файлов
+	exchangeService := service.NewExchangeService(repo, service.NewAuditService(repo))
+
+	failed := false
+	for _, path := range flag.Args() {
+		if err := importFile(exchangeService, path, *format, *verbose); err != nil {
+			log.Printf("%s: %v", path, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func importFile(exchangeService service.ExchangeService, path, format string, verbose bool) error {
+	if format == "" {
+		format = service.RateImportCSV
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			format = service.RateImportJSON
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	result, err := exchangeService.ImportExchangeRates(context.Background(), file, format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: inserted %d, replaced %d, skipped %d\n", path, result.Inserted, result.Replaced, result.Skipped)
+	if verbose {
+		for _, row := range result.Rows {
+			line := fmt.Sprintf("  line %d: %s %s/%s = %s %s", row.Line, row.Date, row.Base, row.Target, row.Rate, row.Status)
+			if row.PreviousRate != nil {
+				line += fmt.Sprintf(" (was %s)", *row.PreviousRate)
+			}
+			fmt.Println(line)
+		}
+	}
+	for _, message := range result.Errors {
+		fmt.Printf("  %s\n", message)
+	}
+
+	return nil
+}
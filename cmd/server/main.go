@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"personal-finance-tracker/internal/config"
 	"personal-finance-tracker/internal/handler"
+	"personal-finance-tracker/internal/middleware"
 	"personal-finance-tracker/internal/repository"
 	"personal-finance-tracker/internal/service"
 	"personal-finance-tracker/internal/utils"
@@ -34,12 +36,81 @@ func main() {
 	defer repo.Close()
 
 	// Инициализация сервисов (бизнес-логика)
-	userService := service.NewUserService(repo)
-	transactionService := service.NewTransactionService(repo)
-	categoryService := service.NewCategoryService(repo)
-	currencyService := service.NewCurrencyService(repo)
-	accountService := service.NewAccountService(repo)
-	exchangeService := service.NewExchangeService(repo, cfg.ExchangeAPIEndpoint)
+	auditService := service.NewAuditService(repo)
+	twoFactorService := service.NewTwoFactorService(repo, cfg.TOTPEncryptionKey, cfg.TOTPIssuer)
+	limiterStore, err := service.NewLimiterStore(cfg.LoginLimiterStore, repo)
+	if err != nil {
+		log.Fatal("Failed to configure login limiter:", err)
+	}
+	loginLimiter := service.NewLoginLimiter(limiterStore, service.LoginLimiterConfig{
+		Policies: map[string]service.LimitPolicy{
+			service.LimitLoginIP: {FreeAttempts: cfg.LoginIPFreeAttempts},
+			service.LimitLoginAccount: {
+				FreeAttempts:     cfg.LoginAccountFreeAttempts,
+				LockoutThreshold: cfg.LoginLockoutThreshold,
+				LockoutDuration:  cfg.LoginLockoutDuration,
+			},
+			service.LimitRegisterIP:  {FreeAttempts: cfg.RegisterIPFreeAttempts},
+			service.LimitOIDCStartIP: {FreeAttempts: cfg.OIDCStartIPFreeAttempts},
+			service.LimitResetIP:     {FreeAttempts: cfg.PasswordResetIPFreeAttempts},
+			service.LimitResetEmail:  {FreeAttempts: cfg.PasswordResetEmailFreeAttempts},
+		},
+		BackoffMax: cfg.LoginBackoffMax,
+	})
+	// Вход через OpenID Connect включается адресом провайдера OIDC_ISSUER_URL
+	var oidcProvider service.OIDCProvider
+	if cfg.OIDC.IssuerURL != "" {
+		oidcProvider, err = service.NewOIDCProvider(service.OIDCConfig{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		})
+		if err != nil {
+			log.Fatal("Failed to configure OIDC login:", err)
+		}
+	}
+	userService := service.NewUserService(repo, twoFactorService, loginLimiter, oidcProvider, auditService, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	// Пользователи из ADMIN_EMAILS получают роль admin при старте
+	if err := userService.EnsureAdmins(context.Background(), cfg.AdminEmails); err != nil {
+		log.Fatal("Failed to assign admin roles:", err)
+	}
+	// Провайдеры курсов внутри цепочки опрашиваются по порядку до первого успешного ответа;
+	// фиатная и криптовалютная цепочки обновляются независимо
+	rateSources := []service.RateProvider{newRateChain(cfg.ExchangeProviders, cfg.ExchangeEndpoints)}
+	if len(cfg.CryptoProviders) > 0 {
+		rateSources = append(rateSources, newRateChain(cfg.CryptoProviders, cfg.ExchangeEndpoints))
+	}
+	exchangeService := service.NewExchangeService(repo, auditService, rateSources...)
+	transactionService := service.NewTransactionService(repo, exchangeService, auditService)
+	categoryService := service.NewCategoryService(repo, auditService)
+	currencyService := service.NewCurrencyService(repo, auditService)
+	accountService := service.NewAccountService(repo, auditService)
+	goalService := service.NewGoalService(repo, exchangeService, auditService)
+	investmentService := service.NewInvestmentService(repo, auditService)
+	reportService := service.NewReportService(repo, exchangeService, investmentService)
+	notificationService := service.NewNotificationService(repo)
+	alertService := service.NewAlertService(repo, exchangeService, notificationService, auditService)
+	transferService := service.NewTransferService(repo, exchangeService, auditService)
+	mailer, err := service.NewMailer(cfg.Mailer, service.MailerConfig{
+		From:         cfg.MailerConfig.From,
+		SMTPHost:     cfg.MailerConfig.SMTPHost,
+		SMTPPort:     cfg.MailerConfig.SMTPPort,
+		SMTPUsername: cfg.MailerConfig.SMTPUsername,
+		SMTPPassword: cfg.MailerConfig.SMTPPassword,
+		LogFile:      cfg.MailerConfig.LogFile,
+	})
+	if err != nil {
+		log.Fatal("Failed to configure mailer:", err)
+	}
+	passwordService := service.NewPasswordService(repo, mailer, loginLimiter, cfg.PasswordResetTTL, cfg.PasswordResetURL)
+	verificationService := service.NewVerificationService(repo, mailer,
+		cfg.EmailVerificationTTL, cfg.EmailVerificationResendInterval, cfg.EmailVerificationURL)
+	apiKeyService := service.NewAPIKeyService(repo)
+	privacyService := service.NewPrivacyService(repo, twoFactorService, mailer, cfg.AccountDeletionGrace)
+	// Правила оповещения проверяются после каждого обновления курсов
+	exchangeService.OnRatesUpdated(alertService.EvaluateAlerts)
 
 	// Инициализация обработчиков
 	handlers := handler.NewHandler(
@@ -49,6 +120,22 @@ func main() {
 		currencyService,
 		accountService,
 		exchangeService,
+		goalService,
+		investmentService,
+		reportService,
+		alertService,
+		notificationService,
+		transferService,
+		passwordService,
+		verificationService,
+		twoFactorService,
+		apiKeyService,
+		privacyService,
+		auditService,
+		middleware.EmailVerificationPolicy{
+			Access:  cfg.UnverifiedAccess,
+			Allowed: cfg.UnverifiedAllowedEndpoints,
+		},
 	)
 
 	// Настройка роутера
@@ -60,6 +147,8 @@ func main() {
 
 	// Middleware: CORS для доступа фронтенда
 	router.Use(handler.CORSMiddleware())
+	// Middleware: идентификатор запроса для логов и журнала аудита
+	router.Use(middleware.RequestID())
 
 	// Routes
 	handlers.InitRoutes(router)
@@ -67,7 +156,7 @@ func main() {
 	// Запускаем фоновое обновление курсов валют при старте
 	go func() {
 		log.Println("Starting initial exchange rates update...")
-		if err := exchangeService.UpdateExchangeRates(); err != nil {
+		if err := exchangeService.UpdateExchangeRates(context.Background()); err != nil {
 			log.Printf("Failed to update exchange rates: %v", err)
 		} else {
 			log.Println("Exchange rates updated successfully")
@@ -78,7 +167,7 @@ func main() {
 		defer ticker.Stop()
 		for range ticker.C {
 			log.Println("Scheduled exchange rates update...")
-			if err := exchangeService.UpdateExchangeRates(); err != nil {
+			if err := exchangeService.UpdateExchangeRates(context.Background()); err != nil {
 				log.Printf("Failed to update exchange rates on schedule: %v", err)
 			} else {
 				log.Println("Scheduled exchange rates updated successfully")
@@ -86,7 +175,44 @@ func main() {
 		}
 	}()
 
+	// Периодическая очистка истекших сессий, устаревших счетчиков попыток входа
+	// и удаление учетных записей, срок удаления которых наступил
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			purged, err := userService.PurgeExpiredSessions(context.Background())
+			if err != nil {
+				log.Printf("Failed to purge expired sessions: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d expired sessions", purged)
+			}
+			if _, err := loginLimiter.Purge(context.Background()); err != nil {
+				log.Printf("Failed to purge login throttles: %v", err)
+			}
+			deleted, err := privacyService.PurgeScheduledDeletions(context.Background())
+			if err != nil {
+				log.Printf("Failed to delete accounts scheduled for deletion: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Deleted %d accounts scheduled for deletion", deleted)
+			}
+		}
+	}()
+
 	// Запуск сервера
 	log.Printf("Server starting on port %s", cfg.Port)
 	log.Fatal(router.Run(":" + cfg.Port))
 }
+
+// newRateChain собирает цепочку провайдеров курсов с запасными источниками
+func newRateChain(names []string, endpoints map[string]string) service.RateProvider {
+	var providers []service.RateProvider
+	for _, name := range names {
+		provider, err := service.NewRateProvider(name, endpoints[name])
+		if err != nil {
+			log.Fatal("Failed to configure exchange rate provider:", err)
+		}
+		providers = append(providers, provider)
+	}
+	return service.NewFallbackRateProvider(providers...)
+}
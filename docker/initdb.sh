@@ -0,0 +1,10 @@
+#!/bin/sh
+# Применяет миграции при первом запуске контейнера Postgres. Каталог migrations не
+# монтируется в docker-entrypoint-initdb.d напрямую: initdb выполняет файлы по алфавиту,
+# и откаты (*.down.sql) запускались бы раньше своих миграций.
+set -e
+
+for file in /migrations/001_init.sql /migrations/*.up.sql; do
+	echo "Applying $file"
+	psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" -f "$file"
+done
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -10,14 +14,155 @@ type Config struct {
 	JWTSecret           string
 	Env                 string
 	ExchangeAPIEndpoint string
+	// Провайдеры курсов в порядке опроса и их адреса (пустой адрес — по умолчанию)
+	ExchangeProviders []string
+	ExchangeEndpoints map[string]string
+	// Провайдеры курсов криптовалют — отдельная цепочка, опрашиваемая вместе с фиатной
+	CryptoProviders []string
+	// Email пользователей, получающих роль admin при старте сервера
+	AdminEmails []string
+	// Срок действия access-токена и refresh-токена (обновляется при каждой ротации)
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Доставка писем: smtp или log (файл MAIL_LOG_FILE либо лог сервера)
+	Mailer       string
+	MailerConfig MailerConfig
+	// Срок действия токена сброса пароля и адрес страницы сброса ({token} — токен)
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	// Подтверждение email: срок токена, интервал повторной отправки и адрес из письма
+	EmailVerificationTTL            time.Duration
+	EmailVerificationResendInterval time.Duration
+	EmailVerificationURL            string
+	// Доступ до подтверждения email: full, read-only или none, плюс разрешенные эндпоинты
+	UnverifiedAccess           string
+	UnverifiedAllowedEndpoints []string
+	// Ключ шифрования секретов TOTP и название сервиса в приложении-аутентификаторе
+	TOTPEncryptionKey string
+	TOTPIssuer        string
+	// Защита от перебора: хранилище счетчиков (memory или postgres), число неудач без
+	// задержки, предельная задержка и блокировка учетной записи
+	LoginLimiterStore        string
+	LoginIPFreeAttempts      int
+	LoginAccountFreeAttempts int
+	LoginBackoffMax          time.Duration
+	LoginLockoutThreshold    int
+	LoginLockoutDuration     time.Duration
+	RegisterIPFreeAttempts   int
+	OIDCStartIPFreeAttempts  int
+	// Запросы сброса пароля с одного IP и для одного email без задержки
+	PasswordResetIPFreeAttempts    int
+	PasswordResetEmailFreeAttempts int
+	// Через сколько после запроса учетная запись удаляется; до этого вход отменяет удаление
+	AccountDeletionGrace time.Duration
+	// Вход через OpenID Connect; пустой IssuerURL — вход отключен
+	OIDC OIDCConfig
 }
 
+// MailerConfig — параметры отправки писем
+type MailerConfig struct {
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	LogFile      string
+}
+
+// OIDCConfig — параметры клиента OpenID Connect
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Ключ JWT по умолчанию известен всем и годится только для локальной разработки
+const defaultJWTSecret = "your-super-secret-jwt-key-change-in-production"
+
 func Load() (*Config, error) {
 	port := getEnv("PORT", "8080")
 	databaseURL := getEnv("DATABASE_URL", "host=localhost port=5432 user=postgres password=fakha dbname=transactions sslmode=disable")
-	jwtSecret := getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production")
+	jwtSecret := getEnv("JWT_SECRET", defaultJWTSecret)
 	env := getEnv("GIN_MODE", "debug")
+	totpEncryptionKey, err := getTOTPEncryptionKey(env, jwtSecret)
+	if err != nil {
+		return nil, err
+	}
 	exchangeAPIEndpoint := getEnv("EXCHANGE_API_ENDPOINT", "https://api.exchangerate-api.com/v4/latest/USD")
+	exchangeProviders := splitList(getEnv("EXCHANGE_PROVIDERS", "exchangerate-api,ecb,cbr,nbt"))
+	cryptoProviders := splitList(getEnv("CRYPTO_PROVIDERS", "coingecko"))
+	if len(cryptoProviders) == 1 && cryptoProviders[0] == "none" {
+		cryptoProviders = nil
+	}
+	adminEmails := splitList(os.Getenv("ADMIN_EMAILS"))
+	accessTokenTTL, err := getDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokenTTL, err := getDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	passwordResetTTL, err := getDuration("PASSWORD_RESET_TTL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	emailVerificationTTL, err := getDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	emailVerificationResendInterval, err := getDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	loginIPFreeAttempts, err := getInt("LOGIN_IP_FREE_ATTEMPTS", 20)
+	if err != nil {
+		return nil, err
+	}
+	loginAccountFreeAttempts, err := getInt("LOGIN_ACCOUNT_FREE_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+	loginBackoffMax, err := getDuration("LOGIN_BACKOFF_MAX", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	loginLockoutThreshold, err := getInt("LOGIN_LOCKOUT_THRESHOLD", 10)
+	if err != nil {
+		return nil, err
+	}
+	loginLockoutDuration, err := getDuration("LOGIN_LOCKOUT_DURATION", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	registerIPFreeAttempts, err := getInt("REGISTER_IP_FREE_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+	oidcStartIPFreeAttempts, err := getInt("OIDC_START_IP_FREE_ATTEMPTS", 20)
+	if err != nil {
+		return nil, err
+	}
+	passwordResetIPFreeAttempts, err := getInt("PASSWORD_RESET_IP_FREE_ATTEMPTS", 10)
+	if err != nil {
+		return nil, err
+	}
+	passwordResetEmailFreeAttempts, err := getInt("PASSWORD_RESET_EMAIL_FREE_ATTEMPTS", 3)
+	if err != nil {
+		return nil, err
+	}
+	accountDeletionGrace, err := getDuration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	unverifiedAccess := getEnv("UNVERIFIED_ACCESS", "read-only")
+	switch unverifiedAccess {
+	case "full", "read-only", "none":
+	default:
+		return nil, fmt.Errorf("invalid UNVERIFIED_ACCESS: %q", unverifiedAccess)
+	}
 
 	return &Config{
 		Port:                port,
@@ -25,9 +170,90 @@ func Load() (*Config, error) {
 		JWTSecret:           jwtSecret,
 		Env:                 env,
 		ExchangeAPIEndpoint: exchangeAPIEndpoint,
+		ExchangeProviders:   exchangeProviders,
+		CryptoProviders:     cryptoProviders,
+		ExchangeEndpoints: map[string]string{
+			"exchangerate-api": exchangeAPIEndpoint,
+			"ecb":              os.Getenv("ECB_API_ENDPOINT"),
+			"cbr":              os.Getenv("CBR_API_ENDPOINT"),
+			"nbt":              os.Getenv("NBT_API_ENDPOINT"),
+			"coingecko":        os.Getenv("COINGECKO_API_ENDPOINT"),
+		},
+		AdminEmails:     adminEmails,
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+		Mailer:          getEnv("MAILER", "log"),
+		MailerConfig: MailerConfig{
+			From:         getEnv("MAIL_FROM", "Personal Finance Tracker <no-reply@localhost>"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			LogFile:      os.Getenv("MAIL_LOG_FILE"),
+		},
+		PasswordResetTTL: passwordResetTTL,
+		PasswordResetURL: os.Getenv("PASSWORD_RESET_URL"),
+
+		EmailVerificationTTL:            emailVerificationTTL,
+		EmailVerificationResendInterval: emailVerificationResendInterval,
+		EmailVerificationURL:            getEnv("EMAIL_VERIFICATION_URL", "http://localhost:8080/api/v1/email/verify?token={token}"),
+		UnverifiedAccess:                unverifiedAccess,
+		UnverifiedAllowedEndpoints:      splitList(getEnv("UNVERIFIED_ALLOWED_ENDPOINTS", defaultUnverifiedAllowedEndpoints)),
+
+		TOTPEncryptionKey: totpEncryptionKey,
+		TOTPIssuer:        getEnv("TOTP_ISSUER", "Personal Finance Tracker"),
+
+		LoginLimiterStore:        getEnv("LOGIN_LIMITER_STORE", "memory"),
+		LoginIPFreeAttempts:      loginIPFreeAttempts,
+		LoginAccountFreeAttempts: loginAccountFreeAttempts,
+		LoginBackoffMax:          loginBackoffMax,
+		LoginLockoutThreshold:    loginLockoutThreshold,
+		LoginLockoutDuration:     loginLockoutDuration,
+		RegisterIPFreeAttempts:   registerIPFreeAttempts,
+		OIDCStartIPFreeAttempts:  oidcStartIPFreeAttempts,
+
+		PasswordResetIPFreeAttempts:    passwordResetIPFreeAttempts,
+		PasswordResetEmailFreeAttempts: passwordResetEmailFreeAttempts,
+
+		AccountDeletionGrace: accountDeletionGrace,
+
+		OIDC: OIDCConfig{
+			IssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+		},
 	}, nil
 }
 
+// Эндпоинты, доступные до подтверждения email по умолчанию: профиль, выбор валюты,
+// повторная отправка письма, пароль, сессии и удаление учетной записи
+const defaultUnverifiedAllowedEndpoints = "GET /api/v1/user/*," +
+	"PUT /api/v1/user/default-currency,PUT /api/v1/user/currencies,PUT /api/v1/user/password," +
+	"POST /api/v1/email/resend,POST /api/v1/logout,DELETE /api/v1/user," +
+	"GET /api/v1/sessions,DELETE /api/v1/sessions/:id,POST /api/v1/sessions/revoke-others"
+
+// getTOTPEncryptionKey читает ключ шифрования секретов 2FA. Вне режима debug он обязателен и
+// должен отличаться от JWT_SECRET: один ключ не должен и подписывать токены, и шифровать секреты
+func getTOTPEncryptionKey(env, jwtSecret string) (string, error) {
+	key := os.Getenv("TOTP_ENCRYPTION_KEY")
+	if env == "debug" {
+		if key == "" {
+			return jwtSecret, nil
+		}
+		return key, nil
+	}
+
+	switch key {
+	case "":
+		return "", fmt.Errorf("TOTP_ENCRYPTION_KEY is required when GIN_MODE is %q", env)
+	case jwtSecret, defaultJWTSecret:
+		return "", fmt.Errorf("TOTP_ENCRYPTION_KEY must differ from JWT_SECRET and its default value")
+	}
+	return key, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -35,3 +261,40 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getDuration читает длительность в формате time.ParseDuration ("15m", "168h")
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+	return duration, nil
+}
+
+// getInt читает неотрицательное целое число
+func getInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+	return number, nil
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
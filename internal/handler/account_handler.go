@@ -38,9 +38,14 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 		UserID:     user.ID,
 		CurrencyID: req.CurrencyID,
 		Balance:    req.InitialBalance,
+		Kind:       "cash",
 		IsDefault:  false,
 	}
 
+	if req.Kind != "" {
+		account.Kind = req.Kind
+	}
+
 	if req.IsDefault != nil {
 		account.IsDefault = *req.IsDefault
 	}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+	"personal-finance-tracker/internal/middleware"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AlertHandler struct {
+	alertService        service.AlertService
+	notificationService service.NotificationService
+}
+
+func NewAlertHandler(alertService service.AlertService, notificationService service.NotificationService) *AlertHandler {
+	return &AlertHandler{
+		alertService:        alertService,
+		notificationService: notificationService,
+	}
+}
+
+// GetAlerts возвращает правила оповещения о курсах пользователя
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	alerts, err := h.alertService.GetUserAlerts(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// CreateAlert создает правило оповещения о курсе
+func (h *AlertHandler) CreateAlert(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	var req models.RateAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, err := h.alertService.CreateAlert(c.Request.Context(), user.ID, &req)
+	if err != nil {
+		c.JSON(alertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, alert)
+}
+
+// UpdateAlert заменяет условия правила оповещения
+func (h *AlertHandler) UpdateAlert(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	var req models.RateAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert, err := h.alertService.UpdateAlert(c.Request.Context(), user.ID, id, &req)
+	if err != nil {
+		c.JSON(alertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// DeleteAlert удаляет правило оповещения
+func (h *AlertHandler) DeleteAlert(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	if err := h.alertService.DeleteAlert(c.Request.Context(), user.ID, id); err != nil {
+		c.JSON(alertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert deleted successfully"})
+}
+
+// GetNotifications возвращает ленту уведомлений; ?unread=true — только непрочитанные
+func (h *AlertHandler) GetNotifications(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	notifications, err := h.notificationService.GetNotifications(c.Request.Context(), user.ID, unreadOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// MarkNotificationRead отмечает уведомление прочитанным
+func (h *AlertHandler) MarkNotificationRead(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), user.ID, id); err != nil {
+		c.JSON(alertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllNotificationsRead отмечает прочитанными все уведомления пользователя
+func (h *AlertHandler) MarkAllNotificationsRead(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	if err := h.notificationService.MarkAllRead(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read"})
+}
+
+func alertErrorStatus(err error) int {
+	switch err.Error() {
+	case "alert not found", "notification not found":
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
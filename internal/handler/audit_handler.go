@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"personal-finance-tracker/internal/middleware"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// GetEntries возвращает журнал изменений данных текущего пользователя
+func (h *AuditHandler) GetEntries(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+	filter.UserID = &user.ID
+
+	entries, err := h.auditService.GetEntries(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetAdminEntries возвращает журнал по всем пользователям; ?user_id= — по одному пользователю
+func (h *AuditHandler) GetAdminEntries(c *gin.Context) {
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	entries, err := h.auditService.GetEntries(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// parseAuditFilter читает фильтры журнала из query-параметров; при ошибке отвечает 400.
+// Дата to включается целиком.
+func parseAuditFilter(c *gin.Context) (*models.AuditFilter, bool) {
+	filter := &models.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		Action:     c.Query("action"),
+		RequestID:  c.Query("request_id"),
+	}
+
+	if action := filter.Action; action != "" && action != models.AuditCreate && action != models.AuditUpdate && action != models.AuditDelete {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action. Use create, update or delete"})
+		return nil, false
+	}
+	if entityIDStr := c.Query("entity_id"); entityIDStr != "" {
+		entityID, err := strconv.Atoi(entityIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+			return nil, false
+		}
+		filter.EntityID = &entityID
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format. Use YYYY-MM-DD"})
+			return nil, false
+		}
+		filter.From = &from
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format. Use YYYY-MM-DD"})
+			return nil, false
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	if beforeIDStr := c.Query("before_id"); beforeIDStr != "" {
+		beforeID, err := strconv.ParseInt(beforeIDStr, 10, 64)
+		if err != nil || beforeID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before_id"})
+			return nil, false
+		}
+		filter.BeforeID = beforeID
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return nil, false
+		}
+		filter.Limit = limit
+	}
+
+	return filter, true
+}
@@ -5,6 +5,7 @@ import (
 	"personal-finance-tracker/internal/middleware"
 	"personal-finance-tracker/internal/models"
 	"personal-finance-tracker/internal/service"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -57,10 +58,89 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 		Type:        req.Type,
 	}
 
-	if err := h.categoryService.CreateCategory(category); err != nil {
+	if err := h.categoryService.CreateCategory(c.Request.Context(), category); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, category)
 }
+
+// GetGlobalCategories возвращает общие категории
+func (h *CategoryHandler) GetGlobalCategories(c *gin.Context) {
+	categories, err := h.categoryService.GetGlobalCategories(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// CreateGlobalCategory создает общую категорию, доступную всем пользователям
+func (h *CategoryHandler) CreateGlobalCategory(c *gin.Context) {
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category := &models.Category{
+		Name:        req.Name,
+		Description: req.Description,
+		Type:        req.Type,
+	}
+
+	if err := h.categoryService.CreateCategory(c.Request.Context(), category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateGlobalCategory изменяет общую категорию
+func (h *CategoryHandler) UpdateGlobalCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.UpdateGlobalCategory(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteGlobalCategory удаляет общую категорию
+func (h *CategoryHandler) DeleteGlobalCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	if err := h.categoryService.DeleteGlobalCategory(c.Request.Context(), id); err != nil {
+		c.JSON(categoryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
+
+func categoryErrorStatus(err error) int {
+	if err.Error() == "category not found" {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"net/http"
+	"personal-finance-tracker/internal/middleware"
+	"personal-finance-tracker/internal/models"
 	"personal-finance-tracker/internal/service"
 	"strconv"
 
@@ -18,9 +20,9 @@ func NewCurrencyHandler(currencyService service.CurrencyService) *CurrencyHandle
 	}
 }
 
-// GetAllCurrencies возвращает все доступные валюты
+// GetAllCurrencies возвращает все доступные (не отключенные) валюты
 func (h *CurrencyHandler) GetAllCurrencies(c *gin.Context) {
-	currencies, err := h.currencyService.GetAllCurrencies()
+	currencies, err := h.currencyService.GetActiveCurrencies()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -50,4 +52,128 @@ func (h *CurrencyHandler) GetCurrencyByID(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, currency)
-}
\ No newline at end of file
+}
+
+// GetAdminCurrencies возвращает все валюты, включая отключенные
+func (h *CurrencyHandler) GetAdminCurrencies(c *gin.Context) {
+	currencies, err := h.currencyService.GetAllCurrencies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, currencies)
+}
+
+// CreateCurrency добавляет валюту в справочник
+func (h *CurrencyHandler) CreateCurrency(c *gin.Context) {
+	var req models.CurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, err := h.currencyService.CreateCurrency(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, currency)
+}
+
+// UpdateCurrency изменяет название, символ, точность или активность валюты
+func (h *CurrencyHandler) UpdateCurrency(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency ID"})
+		return
+	}
+
+	var req models.UpdateCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, err := h.currencyService.UpdateCurrency(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, currency)
+}
+
+// DisableCurrency отключает валюту
+func (h *CurrencyHandler) DisableCurrency(c *gin.Context) {
+	h.setCurrencyActive(c, false)
+}
+
+// EnableCurrency снова включает отключенную валюту
+func (h *CurrencyHandler) EnableCurrency(c *gin.Context) {
+	h.setCurrencyActive(c, true)
+}
+
+func (h *CurrencyHandler) setCurrencyActive(c *gin.Context, active bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency ID"})
+		return
+	}
+
+	currency, err := h.currencyService.SetCurrencyActive(c.Request.Context(), id, active)
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, currency)
+}
+
+// GetUserCurrencies возвращает валюты, активные для пользователя
+func (h *CurrencyHandler) GetUserCurrencies(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	currencies, err := h.currencyService.GetUserCurrencies(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, currencies)
+}
+
+// SetUserCurrencies задает валюты, активные для пользователя
+func (h *CurrencyHandler) SetUserCurrencies(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	var req models.UserCurrenciesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currencies, err := h.currencyService.SetUserCurrencies(c.Request.Context(), user.ID, req.CurrencyIDs)
+	if err != nil {
+		c.JSON(currencyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, currencies)
+}
+
+func currencyErrorStatus(err error) int {
+	if err.Error() == "currency not found" {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
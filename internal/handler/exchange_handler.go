@@ -2,10 +2,14 @@ package handler
 
 import (
 	"net/http"
+	"path/filepath"
 	"personal-finance-tracker/internal/middleware"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
 	"personal-finance-tracker/internal/service"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,8 +27,24 @@ func (h *ExchangeHandler) ConvertSimpleCurrency(c *gin.Context) {
 		return
 	}
 
-	// Получаем курс обмена
-	rate, err := h.exchangeService.GetExchangeRate(req.FromCurrencyID, req.ToCurrencyID)
+	asOf := time.Now()
+	if req.Date != "" {
+		var err error
+		asOf, err = time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	mode, err := money.ParseRoundingMode(req.Rounding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Пересчитываем по курсу на дату с округлением до минимальной единицы целевой валюты
+	converted, rate, err := h.exchangeService.ConvertCurrencyAmount(c.Request.Context(), req.Amount, req.FromCurrencyID, req.ToCurrencyID, asOf, mode)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -34,13 +54,17 @@ func (h *ExchangeHandler) ConvertSimpleCurrency(c *gin.Context) {
 		return
 	}
 
-	converted := req.Amount * rate.Rate
 	c.JSON(http.StatusOK, gin.H{
 		"from_currency_id": req.FromCurrencyID,
 		"to_currency_id":   req.ToCurrencyID,
 		"amount":           req.Amount,
 		"exchange_rate":    rate.Rate,
+		"rate_date":        rate.RateDate.Format("2006-01-02"),
+		"path":             rate.Path,
+		"staleness_days":   rate.StalenessDays,
 		"converted_amount": converted,
+		"formatted_amount": service.FormatAmount(converted, rate.TargetCurrency),
+		"rounding":         mode.String(),
 	})
 }
 
@@ -51,9 +75,14 @@ func NewExchangeHandler(exchangeService service.ExchangeService, accountService
 	}
 }
 
-// GetExchangeRates возвращает все курсы валют
+// GetExchangeRates возвращает все курсы валют на дату (?date=YYYY-MM-DD, по умолчанию — текущие)
 func (h *ExchangeHandler) GetExchangeRates(c *gin.Context) {
-	rates, err := h.exchangeService.GetAllExchangeRates()
+	asOf, ok := parseRateDate(c)
+	if !ok {
+		return
+	}
+
+	rates, err := h.exchangeService.GetAllExchangeRates(asOf)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -64,7 +93,7 @@ func (h *ExchangeHandler) GetExchangeRates(c *gin.Context) {
 
 // UpdateExchangeRates обновляет курсы валют
 func (h *ExchangeHandler) UpdateExchangeRates(c *gin.Context) {
-	if err := h.exchangeService.UpdateExchangeRates(); err != nil {
+	if err := h.exchangeService.UpdateExchangeRates(c.Request.Context()); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -72,6 +101,37 @@ func (h *ExchangeHandler) UpdateExchangeRates(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Exchange rates updated successfully"})
 }
 
+// ImportExchangeRates загружает курсы из CSV или JSON (поле формы "file" или тело запроса).
+// Формат задается ?format=csv|json, иначе определяется по расширению файла или Content-Type.
+func (h *ExchangeHandler) ImportExchangeRates(c *gin.Context) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = service.RateImportCSV
+		if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			if fileHeader, err := c.FormFile("file"); err == nil && strings.EqualFold(filepath.Ext(fileHeader.Filename), ".json") {
+				format = service.RateImportJSON
+			}
+		} else if c.ContentType() == "application/json" {
+			format = service.RateImportJSON
+		}
+	}
+
+	body, err := openUpload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	result, err := h.exchangeService.ImportExchangeRates(c.Request.Context(), body, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // ConvertCurrency конвертирует сумму между счетами
 func (h *ExchangeHandler) ConvertCurrency(c *gin.Context) {
 	user, exists := middleware.GetUserFromContext(c)
@@ -86,6 +146,12 @@ func (h *ExchangeHandler) ConvertCurrency(c *gin.Context) {
 		return
 	}
 
+	mode, err := money.ParseRoundingMode(req.Rounding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Проверяем, что счета принадлежат пользователю
 	fromAccount, err := h.accountService.GetAccountByID(c.Request.Context(), req.FromAccountID)
 	if err != nil {
@@ -108,7 +174,7 @@ func (h *ExchangeHandler) ConvertCurrency(c *gin.Context) {
 	}
 
 	// Конвертируем сумму
-	convertedAmount, err := h.exchangeService.ConvertAmount(req.Amount, req.FromAccountID, req.ToAccountID)
+	convertedAmount, err := h.exchangeService.ConvertAmount(req.Amount, req.FromAccountID, req.ToAccountID, mode)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -121,6 +187,7 @@ func (h *ExchangeHandler) ConvertCurrency(c *gin.Context) {
 		"converted_amount": convertedAmount,
 		"from_currency":    fromAccount.Currency.Code,
 		"to_currency":      toAccount.Currency.Code,
+		"rounding":         mode.String(),
 	})
 }
 
@@ -141,7 +208,7 @@ func (h *ExchangeHandler) GetUserBalances(c *gin.Context) {
 	c.JSON(http.StatusOK, balances)
 }
 
-// GetExchangeRate возвращает курс между двумя валютами
+// GetExchangeRate возвращает курс между двумя валютами на дату (?date=YYYY-MM-DD)
 func (h *ExchangeHandler) GetExchangeRate(c *gin.Context) {
 	baseStr := c.Param("base")
 	targetStr := c.Param("target")
@@ -158,7 +225,12 @@ func (h *ExchangeHandler) GetExchangeRate(c *gin.Context) {
 		return
 	}
 
-	rate, err := h.exchangeService.GetExchangeRate(baseID, targetID)
+	asOf, ok := parseRateDate(c)
+	if !ok {
+		return
+	}
+
+	rate, err := h.exchangeService.GetExchangeRate(baseID, targetID, asOf)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -171,3 +243,126 @@ func (h *ExchangeHandler) GetExchangeRate(c *gin.Context) {
 
 	c.JSON(http.StatusOK, rate)
 }
+
+// GetExchangeRateHistory возвращает историю курса пары за период (?base=&target=&start=&end=)
+func (h *ExchangeHandler) GetExchangeRateHistory(c *gin.Context) {
+	baseID, err := strconv.Atoi(c.Query("base"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid base currency ID"})
+		return
+	}
+
+	targetID, err := strconv.Atoi(c.Query("target"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target currency ID"})
+		return
+	}
+
+	// По умолчанию — последний год
+	end := time.Now()
+	start := end.AddDate(-1, 0, 0)
+	if startStr := c.Query("start"); startStr != "" {
+		start, err = time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if endStr := c.Query("end"); endStr != "" {
+		end, err = time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	rates, err := h.exchangeService.GetExchangeRateHistory(baseID, targetID, start, end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// parseRateDate читает необязательный параметр ?date=; при ошибке отвечает 400
+func parseRateDate(c *gin.Context) (time.Time, bool) {
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		return time.Now(), true
+	}
+
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
+// GetUserRates возвращает личные курсы пользователя
+func (h *ExchangeHandler) GetUserRates(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	rates, err := h.exchangeService.GetUserExchangeRates(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetUserRate сохраняет личный курс пары на дату
+func (h *ExchangeHandler) SetUserRate(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	var req models.ExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.exchangeService.SetUserExchangeRate(c.Request.Context(), user.ID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteUserRate удаляет личный курс
+func (h *ExchangeHandler) DeleteUserRate(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exchange rate ID"})
+		return
+	}
+
+	if err := h.exchangeService.DeleteUserExchangeRate(c.Request.Context(), user.ID, id); err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "exchange rate not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+}
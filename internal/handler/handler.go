@@ -8,12 +8,25 @@ import (
 )
 
 type Handler struct {
-	userService        service.UserService
-	transactionService service.TransactionService
-	categoryService    service.CategoryService
-	currencyService    service.CurrencyService
-	accountService     service.AccountService
-	exchangeService    service.ExchangeService
+	userService         service.UserService
+	transactionService  service.TransactionService
+	categoryService     service.CategoryService
+	currencyService     service.CurrencyService
+	accountService      service.AccountService
+	exchangeService     service.ExchangeService
+	goalService         service.GoalService
+	investmentService   service.InvestmentService
+	reportService       service.ReportService
+	alertService        service.AlertService
+	notificationService service.NotificationService
+	transferService     service.TransferService
+	passwordService     service.PasswordService
+	verificationService service.VerificationService
+	twoFactorService    service.TwoFactorService
+	apiKeyService       service.APIKeyService
+	privacyService      service.PrivacyService
+	auditService        service.AuditService
+	verificationPolicy  middleware.EmailVerificationPolicy
 }
 
 func NewHandler(
@@ -23,14 +36,40 @@ func NewHandler(
 	currencyService service.CurrencyService,
 	accountService service.AccountService,
 	exchangeService service.ExchangeService,
+	goalService service.GoalService,
+	investmentService service.InvestmentService,
+	reportService service.ReportService,
+	alertService service.AlertService,
+	notificationService service.NotificationService,
+	transferService service.TransferService,
+	passwordService service.PasswordService,
+	verificationService service.VerificationService,
+	twoFactorService service.TwoFactorService,
+	apiKeyService service.APIKeyService,
+	privacyService service.PrivacyService,
+	auditService service.AuditService,
+	verificationPolicy middleware.EmailVerificationPolicy,
 ) *Handler {
 	return &Handler{
-		userService:        userService,
-		transactionService: transactionService,
-		categoryService:    categoryService,
-		currencyService:    currencyService,
-		accountService:     accountService,
-		exchangeService:    exchangeService,
+		userService:         userService,
+		transactionService:  transactionService,
+		categoryService:     categoryService,
+		currencyService:     currencyService,
+		accountService:      accountService,
+		exchangeService:     exchangeService,
+		goalService:         goalService,
+		investmentService:   investmentService,
+		reportService:       reportService,
+		alertService:        alertService,
+		notificationService: notificationService,
+		transferService:     transferService,
+		passwordService:     passwordService,
+		verificationService: verificationService,
+		twoFactorService:    twoFactorService,
+		apiKeyService:       apiKeyService,
+		privacyService:      privacyService,
+		auditService:        auditService,
+		verificationPolicy:  verificationPolicy,
 	}
 }
 
@@ -39,33 +78,71 @@ func (h *Handler) InitRoutes(router *gin.Engine) {
 	currencyHandler := NewCurrencyHandler(h.currencyService)
 	accountHandler := NewAccountHandler(h.accountService)
 	exchangeHandler := NewExchangeHandler(h.exchangeService, h.accountService)
+	goalHandler := NewGoalHandler(h.goalService)
+	investmentHandler := NewInvestmentHandler(h.investmentService)
+	reportHandler := NewReportHandler(h.reportService)
+	alertHandler := NewAlertHandler(h.alertService, h.notificationService)
+	categoryHandler := NewCategoryHandler(h.categoryService)
+	transferHandler := NewTransferHandler(h.transferService)
+	passwordHandler := NewPasswordHandler(h.passwordService)
+	verificationHandler := NewVerificationHandler(h.verificationService)
+	twoFactorHandler := NewTwoFactorHandler(h.twoFactorService)
+	apiKeyHandler := NewAPIKeyHandler(h.apiKeyService)
+	privacyHandler := NewPrivacyHandler(h.privacyService)
+	auditHandler := NewAuditHandler(h.auditService)
 
 	// Группа публичных маршрутов (не требует аутентификации)
 	public := router.Group("/api/v1")
 	{
 		public.POST("/register", h.Register)
 		public.POST("/login", h.Login)
+		public.POST("/login/2fa", h.LoginTwoFactor)
+		public.POST("/login/oidc", h.StartOIDCLogin)
+		public.POST("/login/oidc/callback", h.LoginOIDC)
+		public.POST("/token/refresh", h.RefreshToken)
+		public.POST("/password/forgot", passwordHandler.ForgotPassword)
+		public.POST("/password/reset", passwordHandler.ResetPassword)
+		public.GET("/email/verify", verificationHandler.VerifyEmail)
+		public.POST("/email/verify", verificationHandler.VerifyEmail)
 		public.GET("/health", h.HealthCheck)
 		public.GET("/currencies", currencyHandler.GetAllCurrencies)
 		public.GET("/currencies/:id", currencyHandler.GetCurrencyByID)
 		// Обмен валют (read-only): доступны без авторизации
 		public.GET("/exchange/rates", exchangeHandler.GetExchangeRates)
+		public.GET("/exchange/rates/history", exchangeHandler.GetExchangeRateHistory)
 		public.GET("/exchange/rate/:base/:target", exchangeHandler.GetExchangeRate)
 		public.POST("/exchange/convert-simple", exchangeHandler.ConvertSimpleCurrency)
 	}
 
-	// Группа защищенных маршрутов (требуется JWT + активная сессия)
+	// Группа защищенных маршрутов (требуется JWT + активная сессия; до подтверждения
+	// email — только эндпоинты, разрешенные политикой)
 	protected := router.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware(h.userService))
+	protected.Use(middleware.AuthMiddleware(h.userService, h.apiKeyService), middleware.RequireVerifiedEmail(h.verificationPolicy))
 	{
 		// Пользователь
 		protected.GET("/user/profile", h.GetUserProfile)
+		protected.DELETE("/user", privacyHandler.DeleteAccount)
+		protected.GET("/user/export", privacyHandler.ExportData)
 		protected.POST("/logout", h.Logout)
+		protected.POST("/email/resend", verificationHandler.ResendVerification)
+		protected.GET("/sessions", h.GetSessions)
+		protected.DELETE("/sessions/:id", h.RevokeSession)
+		protected.POST("/sessions/revoke-others", h.RevokeOtherSessions)
 		protected.PUT("/user/default-currency", h.SetDefaultCurrency)
+		protected.PUT("/user/password", passwordHandler.ChangePassword)
+		protected.GET("/user/2fa", twoFactorHandler.GetStatus)
+		protected.POST("/user/2fa/setup", twoFactorHandler.Setup)
+		protected.POST("/user/2fa/enable", twoFactorHandler.Enable)
+		protected.POST("/user/2fa/disable", twoFactorHandler.Disable)
+		protected.POST("/user/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+		protected.GET("/api-keys", apiKeyHandler.GetKeys)
+		protected.POST("/api-keys", apiKeyHandler.CreateKey)
+		protected.DELETE("/api-keys/:id", apiKeyHandler.RevokeKey)
+		protected.GET("/user/currencies", currencyHandler.GetUserCurrencies)
+		protected.PUT("/user/currencies", currencyHandler.SetUserCurrencies)
 		protected.GET("/user/profile-with-accounts", h.GetUserProfileWithAccounts)
 
 		// Категории
-		categoryHandler := NewCategoryHandler(h.categoryService)
 		protected.GET("/categories", categoryHandler.GetCategories)
 		protected.POST("/categories", categoryHandler.CreateCategory)
 
@@ -86,15 +163,89 @@ func (h *Handler) InitRoutes(router *gin.Engine) {
 		protected.GET("/accounts/:id", accountHandler.GetAccountByID)
 		protected.PUT("/accounts/:id/default", accountHandler.SetDefaultAccount)
 
+		// Инвестиционные счета
+		protected.GET("/accounts/:id/investment-transactions", investmentHandler.GetTransactions)
+		protected.POST("/accounts/:id/investment-transactions", investmentHandler.CreateTransaction)
+		protected.GET("/accounts/:id/holdings", investmentHandler.GetHoldings)
+		protected.GET("/accounts/:id/valuation", investmentHandler.GetValuation)
+		protected.GET("/accounts/:id/gains", investmentHandler.GetGains)
+		protected.GET("/accounts/:id/allocation", investmentHandler.GetAllocation)
+		protected.GET("/investments/securities", investmentHandler.GetSecurities)
+		protected.GET("/investments/prices/:ticker", investmentHandler.GetPrices)
+
 		// Обмен валют
-		protected.POST("/exchange/rates/update", exchangeHandler.UpdateExchangeRates)
 		protected.POST("/exchange/convert", exchangeHandler.ConvertCurrency)
 		protected.GET("/exchange/balances", exchangeHandler.GetUserBalances)
 
+		// Личные курсы валют
+		protected.GET("/exchange/user-rates", exchangeHandler.GetUserRates)
+		protected.POST("/exchange/user-rates", exchangeHandler.SetUserRate)
+		protected.DELETE("/exchange/user-rates/:id", exchangeHandler.DeleteUserRate)
+
+		// Переводы между счетами
+		protected.GET("/transfers", transferHandler.GetTransfers)
+		protected.POST("/transfers", transferHandler.CreateTransfer)
+
 		// Статистика транзакций
 		protected.GET("/transactions/summary", h.GetTransactionsSummary)
 		protected.GET("/transactions/by-category", h.GetTransactionsByCategory)
 		protected.GET("/transactions/monthly-summary", h.GetMonthlySummary)
+
+		// Отчеты
+		protected.GET("/reports/net-worth", reportHandler.GetNetWorth)
+
+		// Цели накопления
+		protected.GET("/goals", goalHandler.GetGoals)
+		protected.POST("/goals", goalHandler.CreateGoal)
+		protected.GET("/goals/:id", goalHandler.GetGoal)
+		protected.PUT("/goals/:id", goalHandler.UpdateGoal)
+		protected.DELETE("/goals/:id", goalHandler.DeleteGoal)
+		protected.GET("/goals/:id/contributions", goalHandler.GetContributions)
+		protected.POST("/goals/:id/contributions", goalHandler.AddContribution)
+
+		// Оповещения о курсах и лента уведомлений
+		protected.GET("/alerts", alertHandler.GetAlerts)
+		protected.POST("/alerts", alertHandler.CreateAlert)
+		protected.PUT("/alerts/:id", alertHandler.UpdateAlert)
+		protected.DELETE("/alerts/:id", alertHandler.DeleteAlert)
+		protected.GET("/notifications", alertHandler.GetNotifications)
+		protected.POST("/notifications/read-all", alertHandler.MarkAllNotificationsRead)
+		protected.POST("/notifications/:id/read", alertHandler.MarkNotificationRead)
+
+		// Журнал изменений
+		protected.GET("/audit", auditHandler.GetEntries)
+	}
+
+	// Группа маршрутов администратора (JWT + роль admin): изменение общих для всех данных
+	admin := router.Group("/api/v1/admin")
+	admin.Use(middleware.AuthMiddleware(h.userService, h.apiKeyService), middleware.RequireVerifiedEmail(h.verificationPolicy), middleware.AdminMiddleware())
+	{
+		// Пользователи и роли
+		admin.GET("/users", h.GetUsers)
+		admin.PUT("/users/:id/role", h.SetUserRole)
+
+		// Курсы валют: запрос к провайдерам и импорт пишут в общую таблицу exchange_rates
+		admin.POST("/exchange/rates/update", exchangeHandler.UpdateExchangeRates)
+		admin.POST("/exchange/rates/import", exchangeHandler.ImportExchangeRates)
+
+		// Котировки ценных бумаг: общая таблица security_prices
+		admin.POST("/investments/prices/import", investmentHandler.ImportPrices)
+
+		// Общие категории
+		admin.GET("/categories", categoryHandler.GetGlobalCategories)
+		admin.POST("/categories", categoryHandler.CreateGlobalCategory)
+		admin.PUT("/categories/:id", categoryHandler.UpdateGlobalCategory)
+		admin.DELETE("/categories/:id", categoryHandler.DeleteGlobalCategory)
+
+		// Справочник валют
+		admin.GET("/currencies", currencyHandler.GetAdminCurrencies)
+		admin.POST("/currencies", currencyHandler.CreateCurrency)
+		admin.PUT("/currencies/:id", currencyHandler.UpdateCurrency)
+		admin.POST("/currencies/:id/disable", currencyHandler.DisableCurrency)
+		admin.POST("/currencies/:id/enable", currencyHandler.EnableCurrency)
+
+		// Журнал изменений всех пользователей
+		admin.GET("/audit", auditHandler.GetAdminEntries)
 	}
 }
 
@@ -114,7 +265,7 @@ func CORSMiddleware() gin.HandlerFunc {
 		}
 
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, X-Request-ID, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
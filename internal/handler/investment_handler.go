@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"personal-finance-tracker/internal/middleware"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvestmentHandler struct {
+	investmentService service.InvestmentService
+}
+
+func NewInvestmentHandler(investmentService service.InvestmentService) *InvestmentHandler {
+	return &InvestmentHandler{
+		investmentService: investmentService,
+	}
+}
+
+// CreateTransaction добавляет покупку, продажу или дивиденд на инвестиционный счет
+func (h *InvestmentHandler) CreateTransaction(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	var req models.InvestmentTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	transaction := &models.InvestmentTransaction{
+		UserID:      user.ID,
+		AccountID:   accountID,
+		Type:        req.Type,
+		Quantity:    req.Quantity,
+		Price:       req.Price,
+		Fee:         req.Fee,
+		Amount:      req.Amount,
+		Date:        date,
+		Description: req.Description,
+		Security: &models.Security{
+			Ticker:     req.Ticker,
+			Name:       req.Name,
+			AssetClass: req.AssetClass,
+		},
+	}
+
+	if err := h.investmentService.CreateTransaction(c.Request.Context(), transaction); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
+
+// GetTransactions возвращает операции инвестиционного счета
+func (h *InvestmentHandler) GetTransactions(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	transactions, err := h.investmentService.GetTransactions(c.Request.Context(), user.ID, accountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactions)
+}
+
+// GetHoldings возвращает позиции с лотами и рыночной стоимостью на дату (?date=YYYY-MM-DD)
+func (h *InvestmentHandler) GetHoldings(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	asOf := time.Now()
+	if dateStr := c.Query("date"); dateStr != "" {
+		asOf, err = time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	holdings, err := h.investmentService.GetHoldings(c.Request.Context(), user.ID, accountID, asOf)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, holdings)
+}
+
+// GetValuation возвращает рыночную стоимость портфеля (деньги + позиции)
+func (h *InvestmentHandler) GetValuation(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	valuation, err := h.investmentService.GetValuation(c.Request.Context(), user.ID, accountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, valuation)
+}
+
+// GetGains возвращает реализованную (FIFO) и нереализованную прибыль
+func (h *InvestmentHandler) GetGains(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	// Период необязателен: без него считаются все продажи
+	var start, end time.Time
+	if startStr := c.Query("start"); startStr != "" {
+		start, err = time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if endStr := c.Query("end"); endStr != "" {
+		end, err = time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	report, err := h.investmentService.GetGains(c.Request.Context(), user.ID, accountID, start, end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetAllocation возвращает распределение портфеля по классам активов
+func (h *InvestmentHandler) GetAllocation(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return
+	}
+
+	allocation, err := h.investmentService.GetAllocation(c.Request.Context(), user.ID, accountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, allocation)
+}
+
+// GetSecurities возвращает известные ценные бумаги
+func (h *InvestmentHandler) GetSecurities(c *gin.Context) {
+	securities, err := h.investmentService.GetSecurities(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, securities)
+}
+
+// GetPrices возвращает котировки бумаги за период
+func (h *InvestmentHandler) GetPrices(c *gin.Context) {
+	end := time.Now()
+	start := end.AddDate(-1, 0, 0)
+
+	var err error
+	if startStr := c.Query("start"); startStr != "" {
+		start, err = time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if endStr := c.Query("end"); endStr != "" {
+		end, err = time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	prices, err := h.investmentService.GetPrices(c.Request.Context(), c.Param("ticker"), start, end)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// ImportPrices загружает котировки из CSV (поле формы "file" или тело запроса)
+func (h *InvestmentHandler) ImportPrices(c *gin.Context) {
+	body, err := openUpload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	result, err := h.investmentService.ImportPrices(c.Request.Context(), body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// openUpload возвращает загруженный файл из multipart-формы или тело запроса целиком
+func openUpload(c *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		return fileHeader.Open()
+	}
+
+	return c.Request.Body, nil
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"personal-finance-tracker/internal/middleware"
+	"personal-finance-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartOIDCLogin возвращает адрес страницы входа провайдера OpenID Connect
+func (h *Handler) StartOIDCLogin(c *gin.Context) {
+	authorization, err := h.userService.StartOIDCLogin(c.Request.Context(), middleware.GetSessionClient(c))
+	if err != nil {
+		if respondRateLimited(c, err) {
+			return
+		}
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, authorization)
+}
+
+// LoginOIDC завершает вход кодом, с которым провайдер вернул пользователя на OIDC_REDIRECT_URL
+func (h *Handler) LoginOIDC(c *gin.Context) {
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.userService.LoginOIDC(c.Request.Context(), &req, middleware.GetSessionClient(c))
+	if err != nil {
+		if respondRateLimited(c, err) {
+			return
+		}
+		c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	respondLoggedIn(c, result)
+}
+
+func oidcErrorStatus(err error) int {
+	switch err.Error() {
+	case "oidc login is not configured":
+		return http.StatusNotFound
+	case "oidc provider is unavailable":
+		return http.StatusBadGateway
+	case "invalid or expired oidc state", "oidc login failed":
+		return http.StatusUnauthorized
+	case "oidc provider did not return an email":
+		return http.StatusBadRequest
+	case "user with this email already exists":
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"personal-finance-tracker/internal/middleware"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler struct {
+	privacyService service.PrivacyService
+}
+
+func NewPrivacyHandler(privacyService service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{privacyService: privacyService}
+}
+
+// DeleteAccount назначает удаление учетной записи после подтверждения паролем
+func (h *PrivacyHandler) DeleteAccount(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deleteAt, err := h.privacyService.RequestDeletion(c.Request.Context(), user.ID, &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "password is incorrect", "invalid two-factor code":
+			status = http.StatusUnauthorized
+		case "cannot delete the last admin":
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":               "Account scheduled for deletion; sign in before the deadline to cancel",
+		"deletion_scheduled_at": deleteAt,
+	})
+}
+
+// ExportData отдает все персональные данные пользователя одним JSON-файлом
+func (h *PrivacyHandler) ExportData(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	export, err := h.privacyService.ExportData(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("personal-data-%d-%s.json", user.ID, export.ExportedAt.Format("2006-01-02"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.IndentedJSON(http.StatusOK, export)
+}
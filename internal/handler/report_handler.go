@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"personal-finance-tracker/internal/middleware"
+	"personal-finance-tracker/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	reportService service.ReportService
+}
+
+func NewReportHandler(reportService service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GetNetWorth возвращает историю чистого капитала (активы минус пассивы) по всем счетам
+func (h *ReportHandler) GetNetWorth(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	// По умолчанию — последние 12 месяцев
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(-1, 0, 0)
+
+	var err error
+	if startStr := c.Query("start"); startStr != "" {
+		start, err = time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if endStr := c.Query("end"); endStr != "" {
+		end, err = time.Parse("2006-01-02", endStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	currencyID, err := parseCurrencyQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency ID"})
+		return
+	}
+
+	report, err := h.reportService.GetNetWorth(c.Request.Context(), user.ID, currencyID, start, end, c.Query("interval"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
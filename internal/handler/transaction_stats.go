@@ -32,7 +32,13 @@ func (h *Handler) GetTransactionsSummary(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.transactionService.GetTransactionSummary(c.Request.Context(), user.ID, start, end)
+	currencyID, err := parseCurrencyQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency ID"})
+		return
+	}
+
+	summary, err := h.transactionService.GetTransactionSummary(c.Request.Context(), user.ID, currencyID, start, end)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -64,7 +70,13 @@ func (h *Handler) GetTransactionsByCategory(c *gin.Context) {
 		return
 	}
 
-	categorySummary, err := h.transactionService.GetTransactionsByCategory(c.Request.Context(), user.ID, start, end)
+	currencyID, err := parseCurrencyQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency ID"})
+		return
+	}
+
+	categorySummary, err := h.transactionService.GetTransactionsByCategory(c.Request.Context(), user.ID, currencyID, start, end)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -87,7 +99,13 @@ func (h *Handler) GetMonthlySummary(c *gin.Context) {
 		year = time.Now().Year()
 	}
 
-	monthlySummary, err := h.transactionService.GetMonthlySummary(c.Request.Context(), user.ID, year)
+	currencyID, err := parseCurrencyQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency ID"})
+		return
+	}
+
+	monthlySummary, err := h.transactionService.GetMonthlySummary(c.Request.Context(), user.ID, currencyID, year)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -95,3 +113,13 @@ func (h *Handler) GetMonthlySummary(c *gin.Context) {
 
 	c.JSON(http.StatusOK, monthlySummary)
 }
+
+// parseCurrencyQuery читает необязательный параметр currency_id; 0 — валюта пользователя по умолчанию
+func parseCurrencyQuery(c *gin.Context) (int, error) {
+	currencyStr := c.Query("currency_id")
+	if currencyStr == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(currencyStr)
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
 	"personal-finance-tracker/internal/middleware"
 	"personal-finance-tracker/internal/models"
-	"strings"
+	"personal-finance-tracker/internal/service"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,11 +27,19 @@ func (h *Handler) Register(c *gin.Context) {
 		Password: req.Password,
 	}
 
-	if err := h.userService.Register(c.Request.Context(), user); err != nil {
+	if err := h.userService.Register(c.Request.Context(), user, middleware.GetSessionClient(c)); err != nil {
+		if respondRateLimited(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Письмо можно запросить повторно через /email/resend, поэтому сбой отправки не отменяет регистрацию
+	if err := h.verificationService.SendVerification(c.Request.Context(), user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User registered successfully",
 		"user":    user,
@@ -42,36 +54,97 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.userService.Login(c.Request.Context(), &req)
+	result, err := h.userService.Login(c.Request.Context(), &req, middleware.GetSessionClient(c))
 	if err != nil {
+		if respondRateLimited(c, err) {
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
-		"user":    user,
-	})
+	respondLoggedIn(c, result)
 }
 
-// Logout обрабатывает выход пользователя
-func (h *Handler) Logout(c *gin.Context) {
-	authHeader := c.GetHeader("Authorization")
-	if authHeader == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization header required"})
+// LoginTwoFactor завершает вход кодом 2FA или кодом восстановления
+func (h *Handler) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Формат: "Bearer {token}"
-	parts := strings.Split(authHeader, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid authorization format"})
+	result, err := h.userService.LoginTwoFactor(c.Request.Context(), &req, middleware.GetSessionClient(c))
+	if err != nil {
+		if respondRateLimited(c, err) {
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
-	token := parts[1]
 
-	if err := h.userService.Logout(token); err != nil {
+	respondLoggedIn(c, result)
+}
+
+// respondRateLimited отвечает 429 с заголовком Retry-After, если попытки временно отклоняются
+func respondRateLimited(c *gin.Context, err error) bool {
+	var limitErr *service.RateLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retry_after": retryAfter})
+	return true
+}
+
+// respondLoggedIn отвечает токенами сессии или, если у пользователя включена 2FA, токеном второго шага
+func respondLoggedIn(c *gin.Context, result *models.LoginResult) {
+	if result.ChallengeToken != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "Two-factor code required",
+			"two_factor_required": true,
+			"challenge_token":     result.ChallengeToken,
+			"expires_in":          int(result.ChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	response := gin.H{
+		"message":       "Login successful",
+		"token":         result.Tokens.AccessToken,
+		"refresh_token": result.Tokens.RefreshToken,
+		"expires_in":    result.Tokens.ExpiresIn,
+		"user":          result.User,
+	}
+	if result.DeletionCanceled {
+		response["message"] = "Login successful, account deletion canceled"
+		response["deletion_canceled"] = true
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken выдает новую пару токенов в обмен на refresh-токен
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.userService.RefreshToken(c.Request.Context(), req.RefreshToken, middleware.GetSessionClient(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout завершает текущую сессию: отзывает ее refresh-токены и access-токены
+func (h *Handler) Logout(c *gin.Context) {
+	if err := h.userService.Logout(c.Request.Context(), c.GetInt("sessionID")); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -79,6 +152,65 @@ func (h *Handler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
 }
 
+// GetSessions возвращает устройства, на которых выполнен вход
+func (h *Handler) GetSessions(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	sessions, err := h.userService.GetSessions(c.Request.Context(), user.ID, c.GetInt("sessionID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession завершает сессию на выбранном устройстве
+func (h *Handler) RevokeSession(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := h.userService.RevokeSession(c.Request.Context(), user.ID, id); err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "session not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeOtherSessions завершает все сессии, кроме текущей
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	if err := h.userService.RevokeOtherSessions(c.Request.Context(), user.ID, c.GetInt("sessionID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked successfully"})
+}
+
 // GetUserProfile возвращает профиль пользователя
 func (h *Handler) GetUserProfile(c *gin.Context) {
 	user, exists := middleware.GetUserFromContext(c)
@@ -141,3 +273,47 @@ func (h *Handler) GetUserProfileWithAccounts(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// GetUsers возвращает всех пользователей с ролями (для администратора)
+func (h *Handler) GetUsers(c *gin.Context) {
+	users, err := h.userService.GetAllUsers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// SetUserRole назначает пользователю роль user или admin
+func (h *Handler) SetUserRole(c *gin.Context) {
+	admin, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.SetUserRole(c.Request.Context(), admin.ID, id, req.Role)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "user not found" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"personal-finance-tracker/internal/money"
+	"reflect"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Правила binding (required, gt=0, gte=0) для денежных полей проверяются по значению
+// числа: валидатор видит money.Decimal как float64
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			if d, ok := field.Interface().(money.Decimal); ok {
+				return d.Float64()
+			}
+			return nil
+		}, money.Decimal{})
+	}
+}
@@ -10,9 +10,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware проверяет JWT (подпись и срок) и валидность сессии в БД
-func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
+// AuthMiddleware проверяет access-токен (подпись и срок) и то, что его сессия в БД не отозвана.
+// Вместо токена можно передать API-ключ: в заголовке X-API-Key или как Bearer-токен.
+func AuthMiddleware(userService service.UserService, apiKeyService service.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			authenticateAPIKey(c, apiKeyService, apiKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
@@ -29,16 +35,20 @@ func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
 		}
 
 		token := parts[1]
+		if strings.HasPrefix(token, service.APIKeyPrefix) {
+			authenticateAPIKey(c, apiKeyService, token)
+			return
+		}
 
 		// Сначала валидируем подпись JWT, затем сверяем сессию в БД
-		_, jwtErr := utils.ValidateJWT(token)
+		claims, jwtErr := utils.ValidateJWT(token)
 		if jwtErr != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		user, err := userService.ValidateToken(c.Request.Context(), token)
+		user, err := userService.ValidateSession(c.Request.Context(), claims.UserID, claims.SessionID, GetSessionClient(c))
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
@@ -48,10 +58,180 @@ func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
 		// Сохраняем пользователя в контекст
 		c.Set("user", user)
 		c.Set("userID", user.ID)
+		c.Set("sessionID", claims.SessionID)
+		withAuditActor(c, user.ID, nil)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey проверяет API-ключ и то, что его области действия разрешают запрос
+func authenticateAPIKey(c *gin.Context, apiKeyService service.APIKeyService, rawKey string) {
+	user, key, err := apiKeyService.Authenticate(c.Request.Context(), rawKey)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired API key"})
+		c.Abort()
+		return
+	}
+
+	if !apiKeyAllows(key, c.Request.Method, c.FullPath()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key scope does not allow this request"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user", user)
+	c.Set("userID", user.ID)
+	c.Set("apiKey", key)
+	withAuditActor(c, user.ID, &key.ID)
+	c.Next()
+}
+
+// Управление входом и учетной записью доступно только после входа по паролю, не по API-ключу.
+// "*" в конце — префикс маршрута.
+var apiKeyForbiddenPaths = []string{
+	"/api/v1/api-keys*",
+	"/api/v1/sessions*",
+	"/api/v1/logout",
+	"/api/v1/user",
+	"/api/v1/user/export",
+	"/api/v1/user/password",
+	"/api/v1/user/2fa*",
+	"/api/v1/email/resend",
+}
+
+// Эндпоинты, изменяемые ключом с областью transactions:write
+var apiKeyTransactionWritePaths = []string{
+	"/api/v1/transactions",
+	"/api/v1/transfers",
+	"/api/v1/accounts/:id/investment-transactions",
+}
+
+// apiKeyAllows сопоставляет запрос (метод и маршрут Gin) с областями действия ключа
+func apiKeyAllows(key *models.APIKey, method, path string) bool {
+	for _, pattern := range apiKeyForbiddenPaths {
+		if prefix, isPrefix := strings.CutSuffix(pattern, "*"); isPrefix {
+			if strings.HasPrefix(path, prefix) {
+				return false
+			}
+		} else if pattern == path {
+			return false
+		}
+	}
+
+	if key.HasScope(models.APIKeyScopeAdmin) {
+		return true
+	}
+	if strings.HasPrefix(path, "/api/v1/admin/") {
+		return false
+	}
+
+	if method == http.MethodGet || method == http.MethodHead {
+		return key.HasScope(models.APIKeyScopeRead) || key.HasScope(models.APIKeyScopeWriteTransactions)
+	}
+
+	if key.HasScope(models.APIKeyScopeWriteTransactions) {
+		for _, prefix := range apiKeyTransactionWritePaths {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AdminMiddleware пропускает только пользователей с ролью admin. Подключается после
+// AuthMiddleware; роль берется из БД, поэтому снятие прав действует сразу, не дожидаясь
+// истечения выданных токенов.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := GetUserFromContext(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			c.Abort()
+			return
+		}
+
+		if user.Role != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
 
+// Доступ пользователей с неподтвержденным email (UNVERIFIED_ACCESS)
+const (
+	UnverifiedAccessFull     = "full"      // без ограничений
+	UnverifiedAccessReadOnly = "read-only" // только GET и разрешенные эндпоинты
+	UnverifiedAccessNone     = "none"      // только разрешенные эндпоинты
+)
+
+// EmailVerificationPolicy определяет, какие эндпоинты доступны до подтверждения email.
+// Allowed — шаблоны "METHOD /path" по маршрутам Gin ("PUT /api/v1/user/password",
+// "DELETE /api/v1/sessions/:id"); метод "*" — любой, "*" в конце пути — префикс.
+type EmailVerificationPolicy struct {
+	Access  string
+	Allowed []string
+}
+
+// RequireVerifiedEmail ограничивает пользователей с неподтвержденным email по политике.
+// Подключается после AuthMiddleware.
+func RequireVerifiedEmail(policy EmailVerificationPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := GetUserFromContext(c)
+		if !exists || user.VerifiedAt != nil || policy.allows(c.Request.Method, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email not verified"})
+		c.Abort()
+	}
+}
+
+func (p EmailVerificationPolicy) allows(method, path string) bool {
+	switch p.Access {
+	case UnverifiedAccessFull:
+		return true
+	case UnverifiedAccessReadOnly:
+		if method == http.MethodGet || method == http.MethodHead {
+			return true
+		}
+	}
+
+	for _, pattern := range p.Allowed {
+		patternMethod, patternPath, ok := strings.Cut(pattern, " ")
+		if !ok || (patternMethod != "*" && !strings.EqualFold(patternMethod, method)) {
+			continue
+		}
+		if prefix, isPrefix := strings.CutSuffix(patternPath, "*"); isPrefix {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if patternPath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Максимальная длина сохраняемого User-Agent
+const maxUserAgentLength = 512
+
+// GetSessionClient возвращает User-Agent и IP клиента для записи в сессию
+func GetSessionClient(c *gin.Context) models.SessionClient {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	return models.SessionClient{
+		UserAgent: userAgent,
+		IPAddress: c.ClientIP(),
+	}
+}
+
 func GetUserFromContext(c *gin.Context) (*models.User, bool) {
 	user, exists := c.Get("user")
 	if !exists {
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/service"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader — заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// Идентификатор от клиента принимается, только если он не длиннее 64 безопасных символов
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID берет идентификатор запроса из X-Request-ID или генерирует новый,
+// сохраняет его в контекст ("requestID") и возвращает в заголовке ответа
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err != nil {
+				c.Next()
+				return
+			}
+			requestID = hex.EncodeToString(buf)
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// withAuditActor передает исполнителя запроса в контекст для журнала аудита
+func withAuditActor(c *gin.Context, userID int, apiKeyID *int) {
+	actor := models.AuditActor{
+		UserID:    userID,
+		APIKeyID:  apiKeyID,
+		IPAddress: c.ClientIP(),
+		RequestID: c.GetString("requestID"),
+	}
+	c.Request = c.Request.WithContext(service.WithAuditActor(c.Request.Context(), actor))
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"personal-finance-tracker/internal/money"
+	"time"
+)
+
+// Правило оповещения о курсе пары валют
+type RateAlert struct {
+	ID               int            `json:"id"`
+	UserID           int            `json:"user_id"`
+	BaseCurrencyID   int            `json:"base_currency_id"`
+	TargetCurrencyID int            `json:"target_currency_id"`
+	Condition        string         `json:"condition"` // "above", "below" или "change"
+	Threshold        money.Decimal  `json:"threshold"` // курс для above/below, проценты для change
+	WebhookURL       string         `json:"webhook_url,omitempty"`
+	IsActive         bool           `json:"is_active"`
+	LastRate         *money.Decimal `json:"last_rate,omitempty"`
+	LastTriggeredAt  *time.Time     `json:"last_triggered_at,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// Уведомление в ленте пользователя
+type Notification struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Kind        string     `json:"kind"` // "rate_alert"
+	Title       string     `json:"title"`
+	Message     string     `json:"message"`
+	RateAlertID *int       `json:"rate_alert_id,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DTO для правил оповещения
+type RateAlertRequest struct {
+	BaseCurrencyID   int           `json:"base_currency_id" binding:"required"`
+	TargetCurrencyID int           `json:"target_currency_id" binding:"required"`
+	Condition        string        `json:"condition" binding:"required,oneof=above below change"`
+	Threshold        money.Decimal `json:"threshold" binding:"required,gt=0"`
+	WebhookURL       string        `json:"webhook_url" binding:"omitempty,url"`
+	IsActive         *bool         `json:"is_active,omitempty"` // по умолчанию true
+}
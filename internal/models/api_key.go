@@ -0,0 +1,43 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Области действия API-ключей
+const (
+	APIKeyScopeRead              = "read"               // только чтение
+	APIKeyScopeWriteTransactions = "transactions:write" // чтение и запись транзакций и переводов
+	APIKeyScopeAdmin             = "admin"              // полный доступ владельца, включая администрирование
+)
+
+// APIKey — персональный ключ для скриптов и интеграций; сам ключ не хранится
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope сообщает, выдана ли ключу область действия
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+type APIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=read transactions:write admin"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedAPIKey — новый ключ вместе с его значением, которое больше не будет показано
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Действия в журнале аудита
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditEntry — запись журнала аудита: кто, когда и как изменил объект
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	UserID     *int            `json:"user_id"`
+	ActorID    *int            `json:"actor_id"`
+	APIKeyID   *int            `json:"api_key_id,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   *int            `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IPAddress  string          `json:"ip_address"`
+	RequestID  string          `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter — условия выборки журнала; пустые поля не ограничивают выборку.
+// Записи идут от новых к старым, BeforeID — продолжить со следующей страницы.
+type AuditFilter struct {
+	UserID     *int
+	EntityType string
+	EntityID   *int
+	Action     string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	BeforeID   int64
+	Limit      int
+}
+
+// AuditActor — кто выполняет запрос; передается в контексте от middleware до сервисов
+type AuditActor struct {
+	UserID    int
+	APIKeyID  *int
+	IPAddress string
+	RequestID string
+}
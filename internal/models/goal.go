@@ -0,0 +1,56 @@
+package models
+
+import (
+	"personal-finance-tracker/internal/money"
+	"time"
+)
+
+// Цель накопления: либо привязана к счету, либо пополняется ручными взносами
+type SavingsGoal struct {
+	ID           int           `json:"id"`
+	UserID       int           `json:"user_id"`
+	Name         string        `json:"name"`
+	TargetAmount money.Decimal `json:"target_amount"`
+	CurrencyID   int           `json:"currency_id"`
+	Deadline     *time.Time    `json:"deadline,omitempty"`
+	AccountID    *int          `json:"account_id,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Progress     *GoalProgress `json:"progress,omitempty"`
+}
+
+type GoalContribution struct {
+	ID        int           `json:"id"`
+	GoalID    int           `json:"goal_id"`
+	Amount    money.Decimal `json:"amount"`
+	Date      time.Time     `json:"date"`
+	Note      string        `json:"note"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Прогресс цели в валюте цели
+type GoalProgress struct {
+	CurrentAmount       money.Decimal  `json:"current_amount"`
+	RemainingAmount     money.Decimal  `json:"remaining_amount"`
+	ProgressPercent     float64        `json:"progress_percent"`
+	Completed           bool           `json:"completed"`
+	RequiredMonthly     *money.Decimal `json:"required_monthly,omitempty"`
+	MonthlyVelocity     money.Decimal  `json:"monthly_velocity"`
+	ProjectedCompletion *string        `json:"projected_completion,omitempty"` // "2026-05-01"
+	OnTrack             *bool          `json:"on_track,omitempty"`
+}
+
+// DTO для целей накопления
+type SavingsGoalRequest struct {
+	Name         string        `json:"name" binding:"required"`
+	TargetAmount money.Decimal `json:"target_amount" binding:"required,gt=0"`
+	CurrencyID   int           `json:"currency_id" binding:"required"`
+	Deadline     string        `json:"deadline"` // "2006-01-02", необязательно
+	AccountID    *int          `json:"account_id,omitempty"`
+}
+
+type GoalContributionRequest struct {
+	Amount money.Decimal `json:"amount" binding:"required,gt=0"`
+	Date   string        `json:"date" binding:"required"`
+	Note   string        `json:"note"`
+}
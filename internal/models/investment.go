@@ -0,0 +1,123 @@
+package models
+
+import (
+	"personal-finance-tracker/internal/money"
+	"time"
+)
+
+// Ценная бумага (тикер) с классом актива для распределения портфеля
+type Security struct {
+	ID         int       `json:"id"`
+	Ticker     string    `json:"ticker"`
+	Name       string    `json:"name"`
+	AssetClass string    `json:"asset_class"` // "stock", "bond", "etf", "fund", "commodity", "real_estate", "crypto", "other"
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Операция на инвестиционном счете. Amount — влияние на денежный остаток счета
+type InvestmentTransaction struct {
+	ID          int           `json:"id"`
+	UserID      int           `json:"user_id"`
+	AccountID   int           `json:"account_id"`
+	SecurityID  int           `json:"security_id"`
+	Type        string        `json:"type"` // "buy", "sell" или "dividend"
+	Quantity    money.Decimal `json:"quantity"`
+	Price       money.Decimal `json:"price"`
+	Fee         money.Decimal `json:"fee"`
+	Amount      money.Decimal `json:"amount"`
+	Date        time.Time     `json:"date"`
+	Description string        `json:"description"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Security    *Security     `json:"security,omitempty"`
+}
+
+// Котировка бумаги на дату, в валюте инвестиционного счета
+type SecurityPrice struct {
+	SecurityID int           `json:"security_id"`
+	Ticker     string        `json:"ticker"`
+	Date       time.Time     `json:"date"`
+	Price      money.Decimal `json:"price"`
+}
+
+// Налоговый лот: часть позиции, купленная одной операцией
+type TaxLot struct {
+	TransactionID int           `json:"transaction_id"`
+	AcquiredDate  time.Time     `json:"acquired_date"`
+	Quantity      money.Decimal `json:"quantity"`
+	CostPerUnit   money.Decimal `json:"cost_per_unit"`
+	CostBasis     money.Decimal `json:"cost_basis"`
+}
+
+type Holding struct {
+	SecurityID            int           `json:"security_id"`
+	Ticker                string        `json:"ticker"`
+	Name                  string        `json:"name"`
+	AssetClass            string        `json:"asset_class"`
+	Quantity              money.Decimal `json:"quantity"`
+	CostBasis             money.Decimal `json:"cost_basis"`
+	Price                 money.Decimal `json:"price"`
+	PriceDate             string        `json:"price_date,omitempty"`
+	PriceSource           string        `json:"price_source"` // "price_table" или "last_trade"
+	MarketValue           money.Decimal `json:"market_value"`
+	UnrealizedGain        money.Decimal `json:"unrealized_gain"`
+	UnrealizedGainPercent float64       `json:"unrealized_gain_percent"`
+	Lots                  []TaxLot      `json:"lots"`
+}
+
+type PortfolioValuation struct {
+	AccountID      int           `json:"account_id"`
+	AsOf           string        `json:"as_of"`
+	Cash           money.Decimal `json:"cash"`
+	HoldingsValue  money.Decimal `json:"holdings_value"`
+	MarketValue    money.Decimal `json:"market_value"`
+	CostBasis      money.Decimal `json:"cost_basis"`
+	UnrealizedGain money.Decimal `json:"unrealized_gain"`
+	Holdings       []Holding     `json:"holdings"`
+}
+
+// Реализованная прибыль по продаже, рассчитанная по FIFO
+type RealizedGain struct {
+	TransactionID int           `json:"transaction_id"`
+	Ticker        string        `json:"ticker"`
+	SellDate      string        `json:"sell_date"`
+	Quantity      money.Decimal `json:"quantity"`
+	Proceeds      money.Decimal `json:"proceeds"`
+	CostBasis     money.Decimal `json:"cost_basis"`
+	Gain          money.Decimal `json:"gain"`
+}
+
+type GainsReport struct {
+	AccountID           int            `json:"account_id"`
+	PeriodStart         string         `json:"period_start,omitempty"`
+	PeriodEnd           string         `json:"period_end,omitempty"`
+	Realized            []RealizedGain `json:"realized"`
+	TotalRealizedGain   money.Decimal  `json:"total_realized_gain"`
+	TotalDividends      money.Decimal  `json:"total_dividends"`
+	TotalUnrealizedGain money.Decimal  `json:"total_unrealized_gain"`
+}
+
+type AllocationItem struct {
+	AssetClass  string        `json:"asset_class"`
+	MarketValue money.Decimal `json:"market_value"`
+	Percentage  float64       `json:"percentage"`
+}
+
+// DTO для инвестиций
+type InvestmentTransactionRequest struct {
+	Ticker      string        `json:"ticker" binding:"required"`
+	Name        string        `json:"name"`
+	AssetClass  string        `json:"asset_class" binding:"omitempty,oneof=stock bond etf fund commodity real_estate crypto other"`
+	Type        string        `json:"type" binding:"required,oneof=buy sell dividend"`
+	Quantity    money.Decimal `json:"quantity" binding:"gte=0"`
+	Price       money.Decimal `json:"price" binding:"gte=0"`
+	Fee         money.Decimal `json:"fee" binding:"gte=0"`
+	Amount      money.Decimal `json:"amount" binding:"gte=0"` // сумма дивиденда
+	Date        string        `json:"date" binding:"required"`
+	Description string        `json:"description"`
+}
+
+type PriceImportResult struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors"`
+}
@@ -1,46 +1,114 @@
 package models
 
 import (
+	"personal-finance-tracker/internal/money"
 	"time"
 )
 
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID                int       `json:"id"`
-	Username          string    `json:"username"`
-	Email             string    `json:"email"`
-	Password          string    `json:"-"`
-	DefaultCurrencyID *int      `json:"default_currency_id,omitempty"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                int    `json:"id"`
+	Username          string `json:"username"`
+	Email             string `json:"email"`
+	Password          string `json:"-"`
+	Role              string `json:"role"` // "user" или "admin"
+	DefaultCurrencyID *int   `json:"default_currency_id,omitempty"`
+	// VerifiedAt — когда подтвержден email; nil — не подтвержден
+	VerifiedAt         *time.Time `json:"verified_at"`
+	VerificationSentAt *time.Time `json:"-"`
+	TwoFactorEnabled   bool       `json:"two_factor_enabled"`
+	// DeletionScheduledAt — когда учетная запись будет удалена; nil — удаление не запрошено
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 type Currency struct {
-	ID        int       `json:"id"`
-	Code      string    `json:"code"`
-	Name      string    `json:"name"`
-	Symbol    string    `json:"symbol"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             int       `json:"id"`
+	Code           string    `json:"code"`
+	Name           string    `json:"name"`
+	Symbol         string    `json:"symbol"`
+	MinorUnits     int32     `json:"minor_units"`     // знаков после запятой: 2 для USD, 0 для JPY
+	SymbolPosition string    `json:"symbol_position"` // "before" ($10.00) или "after" (10.00 ₽)
+	IsActive       bool      `json:"is_active"`
+	Kind           string    `json:"kind"` // "fiat" или "crypto"
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type Account struct {
-	ID         int       `json:"id"`
-	UserID     int       `json:"user_id"`
-	CurrencyID int       `json:"currency_id"`
-	Balance    float64   `json:"balance"`
-	IsDefault  bool      `json:"is_default"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Currency   *Currency `json:"currency,omitempty"`
+	ID         int           `json:"id"`
+	UserID     int           `json:"user_id"`
+	CurrencyID int           `json:"currency_id"`
+	Balance    money.Decimal `json:"balance"`
+	Kind       string        `json:"kind"` // "cash", "investment", "credit_card" или "loan"
+	IsDefault  bool          `json:"is_default"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Currency   *Currency     `json:"currency,omitempty"`
 }
 
 type ExchangeRate struct {
-	ID               int       `json:"id"`
-	BaseCurrencyID   int       `json:"base_currency_id"`
-	TargetCurrencyID int       `json:"target_currency_id"`
-	Rate             float64   `json:"rate"`
-	LastUpdated      time.Time `json:"last_updated"`
-	BaseCurrency     *Currency `json:"base_currency,omitempty"`
-	TargetCurrency   *Currency `json:"target_currency,omitempty"`
+	ID               int           `json:"id"`
+	BaseCurrencyID   int           `json:"base_currency_id"`
+	TargetCurrencyID int           `json:"target_currency_id"`
+	Rate             money.Decimal `json:"rate"`
+	RateDate         time.Time     `json:"rate_date"` // дата, на которую действует курс
+	LastUpdated      time.Time     `json:"last_updated"`
+	BaseCurrency     *Currency     `json:"base_currency,omitempty"`
+	TargetCurrency   *Currency     `json:"target_currency,omitempty"`
+	// Цепочка курсов, по которой получен кросс-курс, и возраст самого старого из них в днях
+	Path          []ExchangeRateLeg `json:"path,omitempty"`
+	StalenessDays *int              `json:"staleness_days,omitempty"`
+	// "user" — личный курс пользователя; для общих курсов не заполняется
+	Source string `json:"source,omitempty"`
+}
+
+// Личный курс пользователя на дату (например, курс обменника)
+type UserExchangeRate struct {
+	ID               int           `json:"id"`
+	UserID           int           `json:"user_id"`
+	BaseCurrencyID   int           `json:"base_currency_id"`
+	TargetCurrencyID int           `json:"target_currency_id"`
+	Rate             money.Decimal `json:"rate"`
+	RateDate         time.Time     `json:"rate_date"`
+	Note             string        `json:"note"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+}
+
+// ExchangeRateLeg — звено цепочки кросс-курса
+type ExchangeRateLeg struct {
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Rate     money.Decimal `json:"rate"`
+	RateDate time.Time     `json:"rate_date"`
+	Inverted bool          `json:"inverted"` // курс получен обращением сохраненной пары To->From
+}
+
+// RateImportRow — строка файла с курсом и результат ее загрузки
+type RateImportRow struct {
+	Line         int            `json:"line"`
+	Date         string         `json:"date"`
+	Base         string         `json:"base"`
+	Target       string         `json:"target"`
+	Rate         money.Decimal  `json:"rate"`
+	Status       string         `json:"status"` // inserted, replaced
+	PreviousRate *money.Decimal `json:"previous_rate,omitempty"`
+}
+
+// RateImportResult — отчет об импорте курсов из файла
+type RateImportResult struct {
+	Inserted int             `json:"inserted"`
+	Replaced int             `json:"replaced"`
+	Skipped  int             `json:"skipped"`
+	Rows     []RateImportRow `json:"rows"`
+	Errors   []string        `json:"errors"`
 }
 
 type Category struct {
@@ -53,25 +121,61 @@ type Category struct {
 }
 
 type Transaction struct {
-	ID          int       `json:"id"`
-	UserID      int       `json:"user_id"`
-	CategoryID  int       `json:"category_id"`
-	AccountID   *int      `json:"account_id,omitempty"`
-	Amount      float64   `json:"amount"`
-	Description string    `json:"description"`
-	Date        time.Time `json:"date"`
-	Type        string    `json:"type"` // "income" или "expense"
-	CreatedAt   time.Time `json:"created_at"`
-	Account     *Account  `json:"account,omitempty"`
-	Category    *Category `json:"category,omitempty"`
+	ID          int           `json:"id"`
+	UserID      int           `json:"user_id"`
+	CategoryID  int           `json:"category_id"`
+	AccountID   *int          `json:"account_id,omitempty"`
+	Amount      money.Decimal `json:"amount"`
+	Description string        `json:"description"`
+	Date        time.Time     `json:"date"`
+	Type        string        `json:"type"` // "income" или "expense"
+	CreatedAt   time.Time     `json:"created_at"`
+	Account     *Account      `json:"account,omitempty"`
+	Category    *Category     `json:"category,omitempty"`
 }
 
+// Session — refresh-токен пользователя. Токены, выпущенные ротацией, входят в одно
+// семейство (FamilyID); сам токен не хранится, только его хеш.
 type Session struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	FamilyID   int        `json:"family_id"`
+	TokenHash  string     `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	SignedInAt time.Time  `json:"signed_in_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// SessionInfo — активная сессия (устройство) в списке пользователя; ID — идентификатор
+// семейства refresh-токенов, он не меняется при ротации
+type SessionInfo struct {
+	ID         int       `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+	// RevokedAt заполняется только в выгрузке данных, где есть и завершенные сессии
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SessionClient — клиент, от которого пришел запрос: записывается в сессию
+type SessionClient struct {
+	UserAgent string
+	IPAddress string
+}
+
+// TokenPair — короткоживущий access-токен и refresh-токен для его обновления
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // срок действия access-токена, секунд
 }
 
 // DTO (Data Transfer Objects)
@@ -80,6 +184,38 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// PasswordResetToken — одноразовый токен сброса пароля; сам токен не хранится, только хеш
+type PasswordResetToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
@@ -87,48 +223,68 @@ type RegisterRequest struct {
 }
 
 type TransactionRequest struct {
-	CategoryID  int     `json:"category_id" binding:"required"`
-	AccountID   *int    `json:"account_id,omitempty"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Description string  `json:"description"`
-	Date        string  `json:"date" binding:"required"`
-	Type        string  `json:"type" binding:"required,oneof=income expense"`
+	CategoryID  int           `json:"category_id" binding:"required"`
+	AccountID   *int          `json:"account_id,omitempty"`
+	Amount      money.Decimal `json:"amount" binding:"required,gt=0"`
+	Description string        `json:"description"`
+	Date        string        `json:"date" binding:"required"`
+	Type        string        `json:"type" binding:"required,oneof=income expense"`
 }
 
 // Модель бюджета
 type Budget struct {
-	ID         int       `json:"id"`
-	UserID     int       `json:"user_id"`
-	CategoryID int       `json:"category_id"`
-	Amount     float64   `json:"amount"`
-	Month      string    `json:"month"` // "2025-10"
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int           `json:"id"`
+	UserID     int           `json:"user_id"`
+	CategoryID int           `json:"category_id"`
+	Amount     money.Decimal `json:"amount"`
+	Month      string        `json:"month"` // "2025-10"
+	CreatedAt  time.Time     `json:"created_at"`
 }
 
 // МОДЕЛИ ДЛЯ СТАТИСТИКИ
+// Итоги в валюте отчета (CurrencyID); ByCurrency — подытоги в исходных валютах счетов
 type TransactionSummary struct {
-	TotalIncome      float64 `json:"total_income"`
-	TotalExpense     float64 `json:"total_expense"`
-	NetAmount        float64 `json:"net_amount"`
-	TransactionCount int     `json:"transaction_count"`
-	PeriodStart      string  `json:"period_start"`
-	PeriodEnd        string  `json:"period_end"`
+	TotalIncome      money.Decimal      `json:"total_income"`
+	TotalExpense     money.Decimal      `json:"total_expense"`
+	NetAmount        money.Decimal      `json:"net_amount"`
+	TransactionCount int                `json:"transaction_count"`
+	PeriodStart      string             `json:"period_start"`
+	PeriodEnd        string             `json:"period_end"`
+	CurrencyID       int                `json:"currency_id,omitempty"`
+	CurrencyCode     string             `json:"currency_code,omitempty"`
+	ByCurrency       []CurrencySubtotal `json:"by_currency,omitempty"`
 }
 
 type CategorySummary struct {
-	CategoryID   int     `json:"category_id"`
-	CategoryName string  `json:"category_name"`
-	Type         string  `json:"type"`
-	TotalAmount  float64 `json:"total_amount"`
-	Count        int     `json:"count"`
-	Percentage   float64 `json:"percentage"`
+	CategoryID   int                `json:"category_id"`
+	CategoryName string             `json:"category_name"`
+	Type         string             `json:"type"`
+	TotalAmount  money.Decimal      `json:"total_amount"`
+	Count        int                `json:"count"`
+	Percentage   float64            `json:"percentage"`
+	CurrencyID   int                `json:"currency_id,omitempty"`
+	CurrencyCode string             `json:"currency_code,omitempty"`
+	ByCurrency   []CurrencySubtotal `json:"by_currency,omitempty"`
 }
 
 type MonthlySummary struct {
-	Month        string  `json:"month"` // "2025-01"
-	TotalIncome  float64 `json:"total_income"`
-	TotalExpense float64 `json:"total_expense"`
-	NetAmount    float64 `json:"net_amount"`
+	Month        string             `json:"month"` // "2025-01"
+	TotalIncome  money.Decimal      `json:"total_income"`
+	TotalExpense money.Decimal      `json:"total_expense"`
+	NetAmount    money.Decimal      `json:"net_amount"`
+	CurrencyID   int                `json:"currency_id,omitempty"`
+	CurrencyCode string             `json:"currency_code,omitempty"`
+	ByCurrency   []CurrencySubtotal `json:"by_currency,omitempty"`
+}
+
+// Подытог по одной исходной валюте, без пересчета
+type CurrencySubtotal struct {
+	CurrencyID       int           `json:"currency_id"`
+	CurrencyCode     string        `json:"currency_code"`
+	TotalIncome      money.Decimal `json:"total_income"`
+	TotalExpense     money.Decimal `json:"total_expense"`
+	NetAmount        money.Decimal `json:"net_amount"`
+	TransactionCount int           `json:"transaction_count"`
 }
 
 // DTO для дашборда
@@ -152,45 +308,69 @@ type Dataset struct {
 
 // НОВЫЕ DTO ДЛЯ ВАЛЮТ И СЧЕТОВ
 type CurrencyRequest struct {
-	Code   string `json:"code" binding:"required"`
-	Name   string `json:"name" binding:"required"`
-	Symbol string `json:"symbol" binding:"required"`
+	Code           string `json:"code" binding:"required,alphanum,min=2,max=10"`
+	Name           string `json:"name" binding:"required,max=50"`
+	Symbol         string `json:"symbol" binding:"required,max=5"`
+	MinorUnits     *int32 `json:"minor_units" binding:"omitempty,min=0,max=18"` // по умолчанию 2
+	SymbolPosition string `json:"symbol_position" binding:"omitempty,oneof=before after"`
+	Kind           string `json:"kind" binding:"omitempty,oneof=fiat crypto"` // по умолчанию fiat
+}
+
+// UpdateCurrencyRequest — частичное изменение валюты; код валюты не меняется
+type UpdateCurrencyRequest struct {
+	Name           *string `json:"name" binding:"omitempty,min=1,max=50"`
+	Symbol         *string `json:"symbol" binding:"omitempty,min=1,max=5"`
+	MinorUnits     *int32  `json:"minor_units" binding:"omitempty,min=0,max=18"`
+	SymbolPosition *string `json:"symbol_position" binding:"omitempty,oneof=before after"`
+	IsActive       *bool   `json:"is_active"`
+}
+
+// UserCurrenciesRequest — валюты, активные для пользователя; пустой список снимает ограничение
+type UserCurrenciesRequest struct {
+	CurrencyIDs []int `json:"currency_ids" binding:"required"`
 }
 
 type AccountRequest struct {
-	Name           string  `json:"name" binding:"required"`
-	CurrencyID     int     `json:"currency_id" binding:"required"`
-	InitialBalance float64 `json:"initial_balance"`
-	IsDefault      *bool   `json:"is_default,omitempty"`
+	Name           string        `json:"name" binding:"required"`
+	CurrencyID     int           `json:"currency_id" binding:"required"`
+	InitialBalance money.Decimal `json:"initial_balance"`
+	Kind           string        `json:"kind" binding:"omitempty,oneof=cash investment credit_card loan"`
+	IsDefault      *bool         `json:"is_default,omitempty"`
 }
 
 type SetDefaultCurrencyRequest struct {
 	CurrencyID int `json:"currency_id" binding:"required"`
 }
 
+// ExchangeRateRequest — личный курс пары: сколько target дают за 1 base
 type ExchangeRateRequest struct {
-	BaseCurrencyID   int     `json:"base_currency_id" binding:"required"`
-	TargetCurrencyID int     `json:"target_currency_id" binding:"required"`
-	Rate             float64 `json:"rate" binding:"required,gt=0"`
+	BaseCurrencyID   int           `json:"base_currency_id" binding:"required"`
+	TargetCurrencyID int           `json:"target_currency_id" binding:"required"`
+	Rate             money.Decimal `json:"rate" binding:"required,gt=0"`
+	Date             string        `json:"date"` // "2006-01-02", по умолчанию сегодня
+	Note             string        `json:"note"`
 }
 
 type ConvertCurrencyRequest struct {
-	FromAccountID int     `json:"from_account_id" binding:"required"`
-	ToAccountID   int     `json:"to_account_id" binding:"required"`
-	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	FromAccountID int           `json:"from_account_id" binding:"required"`
+	ToAccountID   int           `json:"to_account_id" binding:"required"`
+	Amount        money.Decimal `json:"amount" binding:"required,gt=0"`
+	Rounding      string        `json:"rounding"` // Необязательно: half_even (по умолчанию), half_up, down, up, floor, ceiling
 }
 
 type ConvertSimpleRequest struct {
-	FromCurrencyID int     `json:"from_currency_id" binding:"required"`
-	ToCurrencyID   int     `json:"to_currency_id" binding:"required"`
-	Amount         float64 `json:"amount" binding:"required,gt=0"`
+	FromCurrencyID int           `json:"from_currency_id" binding:"required"`
+	ToCurrencyID   int           `json:"to_currency_id" binding:"required"`
+	Amount         money.Decimal `json:"amount" binding:"required,gt=0"`
+	Date           string        `json:"date"`     // Необязательно: курс на дату YYYY-MM-DD
+	Rounding       string        `json:"rounding"` // Необязательно: правило округления, как в ConvertCurrencyRequest
 }
 
 type AccountBalance struct {
-	AccountID    int     `json:"account_id"`
-	CurrencyCode string  `json:"currency_code"`
-	Balance      float64 `json:"balance"`
-	BalanceInUSD float64 `json:"balance_in_usd,omitempty"`
+	AccountID    int           `json:"account_id"`
+	CurrencyCode string        `json:"currency_code"`
+	Balance      money.Decimal `json:"balance"`
+	BalanceInUSD money.Decimal `json:"balance_in_usd,omitempty"`
 }
 
 type CategoryRequest struct {
@@ -198,3 +378,15 @@ type CategoryRequest struct {
 	Description string `json:"description"`
 	Type        string `json:"type" binding:"required,oneof=income expense"`
 }
+
+type UserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+// LoginThrottle — счетчик неудачных попыток входа или регистрации по одному ключу
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  *time.Time
+}
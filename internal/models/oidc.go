@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// UserIdentity — учетная запись у внешнего провайдера OpenID Connect, привязанная к пользователю
+type UserIdentity struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Issuer      string     `json:"issuer"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// OIDCAuthRequest — начатый вход через провайдера; хранится до возврата пользователя
+type OIDCAuthRequest struct {
+	ID           int
+	StateHash    string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// OIDCAuthorization — куда перенаправить пользователя для входа у провайдера
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	// State клиент сохраняет в браузере и сверяет со state возврата, прежде чем завершить вход:
+	// так код, полученный чужим входом, не приведет к входу в этом браузере
+	State     string `json:"state"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// OIDCCallbackRequest — параметры, с которыми провайдер вернул пользователя на OIDC_REDIRECT_URL
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// OIDCClaims — проверенные сведения о пользователе из ID-токена провайдера
+type OIDCClaims struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
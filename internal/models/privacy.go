@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// DeleteAccountRequest — подтверждение удаления учетной записи; Code нужен при включенной 2FA
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"`
+}
+
+// UserDataExport — все персональные данные пользователя в машиночитаемом виде
+type UserDataExport struct {
+	ExportedAt             time.Time               `json:"exported_at"`
+	Profile                *User                   `json:"profile"`
+	TwoFactor              *TwoFactorStatus        `json:"two_factor"`
+	Currencies             []Currency              `json:"currencies"`
+	Sessions               []SessionInfo           `json:"sessions"`
+	APIKeys                []APIKey                `json:"api_keys"`
+	Identities             []UserIdentity          `json:"identities"`
+	Accounts               []Account               `json:"accounts"`
+	Categories             []Category              `json:"categories"`
+	Transactions           []Transaction           `json:"transactions"`
+	Transfers              []Transfer              `json:"transfers"`
+	InvestmentTransactions []InvestmentTransaction `json:"investment_transactions"`
+	SavingsGoals           []SavingsGoal           `json:"savings_goals"`
+	GoalContributions      []GoalContribution      `json:"goal_contributions"`
+	ExchangeRates          []UserExchangeRate      `json:"exchange_rates"`
+	RateAlerts             []RateAlert             `json:"rate_alerts"`
+	Notifications          []Notification          `json:"notifications"`
+	AuditLog               []AuditEntry            `json:"audit_log"`
+}
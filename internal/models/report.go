@@ -0,0 +1,21 @@
+package models
+
+import "personal-finance-tracker/internal/money"
+
+// Точка истории чистого капитала на дату, в валюте отчета
+type NetWorthPoint struct {
+	Date          string                   `json:"date"`
+	Assets        money.Decimal            `json:"assets"`
+	Liabilities   money.Decimal            `json:"liabilities"`
+	NetWorth      money.Decimal            `json:"net_worth"`
+	ByAccountType map[string]money.Decimal `json:"by_account_type"`
+}
+
+type NetWorthReport struct {
+	CurrencyID   int             `json:"currency_id"`
+	CurrencyCode string          `json:"currency_code"`
+	Interval     string          `json:"interval"` // "day", "week" или "month"
+	PeriodStart  string          `json:"period_start"`
+	PeriodEnd    string          `json:"period_end"`
+	Points       []NetWorthPoint `json:"points"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"personal-finance-tracker/internal/money"
+	"time"
+)
+
+// Перевод между счетами пользователя; rate — фактически примененный курс
+type Transfer struct {
+	ID            int           `json:"id"`
+	UserID        int           `json:"user_id"`
+	FromAccountID int           `json:"from_account_id"`
+	ToAccountID   int           `json:"to_account_id"`
+	FromAmount    money.Decimal `json:"from_amount"`
+	ToAmount      money.Decimal `json:"to_amount"`
+	Rate          money.Decimal `json:"rate"`
+	RateSource    string        `json:"rate_source"` // "same", "user", "global" или "manual"
+	Date          time.Time     `json:"date"`
+	Description   string        `json:"description"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// DTO для перевода. Курс можно задать явно через rate или полученную сумму to_amount;
+// иначе применяется личный курс пользователя, а при его отсутствии — общий.
+type TransferRequest struct {
+	FromAccountID int            `json:"from_account_id" binding:"required"`
+	ToAccountID   int            `json:"to_account_id" binding:"required"`
+	Amount        money.Decimal  `json:"amount" binding:"required,gt=0"`
+	Rate          *money.Decimal `json:"rate,omitempty"`
+	ToAmount      *money.Decimal `json:"to_amount,omitempty"`
+	Date          string         `json:"date"` // "2006-01-02", по умолчанию сегодня
+	Description   string         `json:"description"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// TOTPState — настройки TOTP пользователя; Secret зашифрован
+type TOTPState struct {
+	Secret    string
+	EnabledAt *time.Time
+	LastStep  *int64
+}
+
+// TwoFactorSetup — новый секрет для приложения-аутентификатора
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorCodeRequest — код из приложения-аутентификатора или код восстановления
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest — второй шаг входа
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// LoginResult — результат шага входа: либо токены сессии, либо токен второго шага
+// (ChallengeToken), если у пользователя включена 2FA
+type LoginResult struct {
+	User           *User
+	Tokens         *TokenPair
+	ChallengeToken string
+	ChallengeTTL   time.Duration
+	// DeletionCanceled — вход отменил запрошенное удаление учетной записи
+	DeletionCanceled bool
+}
@@ -0,0 +1,274 @@
+// Package money содержит точный десятичный тип для денежных сумм, количеств и курсов.
+// В отличие от float64, сложение и умножение не теряют точность, а округление
+// выполняется только явно — до заданного числа знаков и по выбранному правилу.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Число знаков после запятой для курсов и других частных (деление не всегда конечно).
+// 18 знаков — точность wei; курс USD->BTC сохраняет достаточно значащих цифр.
+const RateScale = 18
+
+// Предел точности хранения (DECIMAL(38,18)): не больше 20 знаков в целой части.
+// Parse отклоняет более длинные записи до вычислений, чтобы "1e20000000" не занимал процессор.
+const (
+	maxPrecision     = 38
+	maxIntegerDigits = maxPrecision - RateScale
+)
+
+// Decimal — десятичное число coef·10^(-scale). Нулевое значение равно 0.
+// Значения неизменяемы: все операции возвращают новое число.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+// Zero — ноль
+var Zero = Decimal{}
+
+var bigTen = big.NewInt(10)
+
+// New возвращает value·10^(-scale), например New(12345, 2) = 123.45
+func New(value int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{coef: new(big.Int).Mul(big.NewInt(value), pow10(-scale))}
+	}
+	return Decimal{coef: big.NewInt(value), scale: scale}
+}
+
+// NewFromInt возвращает целое число
+func NewFromInt(value int64) Decimal {
+	return New(value, 0)
+}
+
+// NewFromFloat переводит float64 в десятичное по кратчайшему точному представлению
+// (0.1 → 0.1, а не 0.1000000000000000055…). NaN и бесконечности дают 0.
+func NewFromFloat(value float64) Decimal {
+	d, err := Parse(strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		return Zero
+	}
+	return d
+}
+
+// Parse разбирает запись вида "-123.45", "+1", "1.5e-3"
+func Parse(value string) (Decimal, error) {
+	s := strings.TrimSpace(value)
+	if s == "" {
+		return Zero, errors.New("empty decimal")
+	}
+
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Zero, fmt.Errorf("invalid decimal %q", value)
+		}
+		exp = e
+		s = s[:i]
+	}
+
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	digits := intPart + fracPart
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Zero, fmt.Errorf("invalid decimal %q", value)
+	}
+
+	// Границы проверяются по длине записи, до разбора числа и возведения в степень
+	if exp < -maxPrecision || exp > maxPrecision {
+		return Zero, fmt.Errorf("decimal %q is out of range", value)
+	}
+	significant := strings.TrimLeft(digits, "0")
+	if int64(len(significant)-len(fracPart))+exp > maxIntegerDigits {
+		return Zero, fmt.Errorf("decimal %q is out of range", value)
+	}
+	if int64(len(fracPart))-exp > maxPrecision {
+		return Zero, fmt.Errorf("decimal %q has too many fractional digits", value)
+	}
+
+	coef, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Zero, fmt.Errorf("invalid decimal %q", value)
+	}
+	if negative {
+		coef.Neg(coef)
+	}
+
+	scale := int64(len(fracPart)) - exp
+	if scale < 0 {
+		coef.Mul(coef, pow10(int32(-scale)))
+		scale = 0
+	}
+
+	return Decimal{coef: coef, scale: int32(scale)}, nil
+}
+
+// MustParse как Parse, но паникует при ошибке; для констант в коде
+func MustParse(value string) Decimal {
+	d, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) value() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// rescale приводит число к большему или равному числу знаков без потери точности
+func (d Decimal) rescale(scale int32) *big.Int {
+	coef := d.value()
+	if scale == d.scale {
+		return coef
+	}
+	return new(big.Int).Mul(coef, pow10(scale-d.scale))
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+	return Decimal{coef: new(big.Int).Add(d.rescale(scale), other.rescale(scale)), scale: scale}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	scale := max(d.scale, other.scale)
+	return Decimal{coef: new(big.Int).Sub(d.rescale(scale), other.rescale(scale)), scale: scale}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.value(), other.value()), scale: d.scale + other.scale}
+}
+
+// Div делит с округлением результата до scale знаков. Деление на ноль дает панику, как и для целых.
+func (d Decimal) Div(other Decimal, scale int32, mode RoundingMode) Decimal {
+	if other.IsZero() {
+		panic("money: division by zero")
+	}
+
+	// d/other = D·10^os / (O·10^ds); домножаем на 10^scale, чтобы получить scale знаков
+	num := new(big.Int).Mul(d.value(), pow10(other.scale+scale))
+	den := new(big.Int).Mul(other.value(), pow10(d.scale))
+
+	return Decimal{coef: roundQuo(num, den, mode), scale: scale}
+}
+
+// Round округляет до places знаков после запятой по правилу mode
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	if places >= d.scale {
+		return Decimal{coef: d.rescale(places), scale: places}
+	}
+	return Decimal{coef: roundQuo(d.value(), pow10(d.scale-places), mode), scale: places}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.value()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.value()), scale: d.scale}
+}
+
+// Cmp возвращает -1, 0 или 1
+func (d Decimal) Cmp(other Decimal) int {
+	scale := max(d.scale, other.scale)
+	return d.rescale(scale).Cmp(other.rescale(scale))
+}
+
+func (d Decimal) Equal(other Decimal) bool       { return d.Cmp(other) == 0 }
+func (d Decimal) GreaterThan(other Decimal) bool { return d.Cmp(other) > 0 }
+func (d Decimal) LessThan(other Decimal) bool    { return d.Cmp(other) < 0 }
+
+func (d Decimal) Sign() int        { return d.value().Sign() }
+func (d Decimal) IsZero() bool     { return d.Sign() == 0 }
+func (d Decimal) IsPositive() bool { return d.Sign() > 0 }
+func (d Decimal) IsNegative() bool { return d.Sign() < 0 }
+
+// Scale — число знаков после запятой в записи числа
+func (d Decimal) Scale() int32 { return d.scale }
+
+// Float64 возвращает приближенное значение — только для процентов, графиков и т.п.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String возвращает запись без экспоненты с числом знаков, равным scale
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.value()).String()
+	if d.scale > 0 {
+		if len(digits) <= int(d.scale) {
+			digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// StringFixed округляет до places знаков (половина — от нуля) и возвращает запись
+func (d Decimal) StringFixed(places int32) string {
+	return d.Round(places, RoundHalfUp).String()
+}
+
+// Normalize убирает незначащие нули в дробной части
+func (d Decimal) Normalize() Decimal {
+	coef := new(big.Int).Set(d.value())
+	scale := d.scale
+	rem := new(big.Int)
+	for scale > 0 {
+		q, r := new(big.Int).QuoRem(coef, bigTen, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		coef = q
+		scale--
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// Sum складывает значения
+func Sum(values ...Decimal) Decimal {
+	total := Zero
+	for _, v := range values {
+		total = total.Add(v)
+	}
+	return total
+}
+
+// Max возвращает большее из чисел
+func Max(a, b Decimal) Decimal {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// Min возвращает меньшее из чисел
+func Min(a, b Decimal) Decimal {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,210 @@
+package money
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"0", "0"},
+		{"123.45", "123.45"},
+		{"-123.45", "-123.45"},
+		{"+1", "1"},
+		{" 7.50 ", "7.50"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{"1.5e-3", "0.0015"},
+		{"1.5E3", "1500"},
+		{"2.3456789012345678e-05", "0.000023456789012345678"},
+		{"99999999999999999999.999999999999999999", "99999999999999999999.999999999999999999"},
+		{"1e19", "10000000000000000000"},
+		{"0.00000000000000000000000000000000000001", "0.00000000000000000000000000000000000001"},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	inputs := []string{
+		"",
+		"   ",
+		"abc",
+		"1.2.3",
+		"-",
+		"1e",
+		"1e+-2",
+		"0x10",
+		"1,5",
+		"NaN",
+	}
+
+	for _, input := range inputs {
+		if got, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) = %s, want error", input, got)
+		}
+	}
+}
+
+func TestParseOutOfRange(t *testing.T) {
+	inputs := []string{
+		"1e20000000",
+		"1e-20000000",
+		"0e2000000000",
+		"1e39",
+		"1e-39",
+		"100000000000000000000",
+		"1e20",
+		"-123456789012345678901.5",
+		"0." + strings.Repeat("1", 39),
+		"1" + strings.Repeat("0", 100000),
+	}
+
+	for _, input := range inputs {
+		if got, err := Parse(input); err == nil {
+			t.Errorf("Parse(%.40q) = %.40s, want error", input, got)
+		}
+	}
+}
+
+func TestNewFromFloat(t *testing.T) {
+	tests := []struct {
+		input float64
+		want  string
+	}{
+		{0.1, "0.1"},
+		{-2.5, "-2.5"},
+		{1e-20, "0.00000000000000000001"},
+		{1e30, "0"},
+	}
+
+	for _, tt := range tests {
+		if got := NewFromFloat(tt.input); got.String() != tt.want {
+			t.Errorf("NewFromFloat(%v) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		scale int32
+		mode  RoundingMode
+		want  string
+	}{
+		{"10", "4", 2, RoundHalfEven, "2.50"},
+		{"1", "3", 4, RoundHalfEven, "0.3333"},
+		{"2", "3", 4, RoundHalfEven, "0.6667"},
+		{"2", "3", 4, RoundDown, "0.6666"},
+		{"-2", "3", 4, RoundFloor, "-0.6667"},
+		{"-2", "3", 4, RoundCeiling, "-0.6666"},
+		{"1", "8", 2, RoundHalfEven, "0.12"},
+		{"1", "8", 2, RoundHalfUp, "0.13"},
+		{"1.5", "0.25", 0, RoundHalfEven, "6"},
+		{"1", "97.5", RateScale, RoundHalfEven, "0.010256410256410256"},
+	}
+
+	for _, tt := range tests {
+		got := MustParse(tt.a).Div(MustParse(tt.b), tt.scale, tt.mode)
+		if got.String() != tt.want {
+			t.Errorf("%s / %s (scale %d, %s) = %s, want %s", tt.a, tt.b, tt.scale, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestDivByZeroPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Div by zero did not panic")
+		}
+	}()
+	NewFromInt(1).Div(Zero, 2, RoundHalfEven)
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		input  string
+		places int32
+		mode   RoundingMode
+		want   string
+	}{
+		{"2.5", 0, RoundHalfEven, "2"},
+		{"3.5", 0, RoundHalfEven, "4"},
+		{"-2.5", 0, RoundHalfEven, "-2"},
+		{"2.51", 0, RoundHalfEven, "3"},
+		{"2.5", 0, RoundHalfUp, "3"},
+		{"-2.5", 0, RoundHalfUp, "-3"},
+		{"2.49", 0, RoundHalfUp, "2"},
+		{"2.9", 0, RoundDown, "2"},
+		{"-2.9", 0, RoundDown, "-2"},
+		{"2.1", 0, RoundUp, "3"},
+		{"-2.1", 0, RoundUp, "-3"},
+		{"2.9", 0, RoundFloor, "2"},
+		{"-2.1", 0, RoundFloor, "-3"},
+		{"2.1", 0, RoundCeiling, "3"},
+		{"-2.9", 0, RoundCeiling, "-2"},
+		{"1.005", 2, RoundHalfEven, "1.00"},
+		{"1.015", 2, RoundHalfEven, "1.02"},
+		{"1.23", 4, RoundHalfEven, "1.2300"},
+		{"7", 2, RoundDown, "7.00"},
+		{"0.015", 8, RoundHalfEven, "0.01500000"},
+	}
+
+	for _, tt := range tests {
+		got := MustParse(tt.input).Round(tt.places, tt.mode)
+		if got.String() != tt.want {
+			t.Errorf("Round(%s, %d, %s) = %s, want %s", tt.input, tt.places, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestRoundExactValueUnchanged(t *testing.T) {
+	modes := []RoundingMode{RoundHalfEven, RoundHalfUp, RoundDown, RoundUp, RoundFloor, RoundCeiling}
+	for _, mode := range modes {
+		if got := MustParse("-12.340").Round(2, mode); got.String() != "-12.34" {
+			t.Errorf("Round(-12.340, 2, %s) = %s, want -12.34", mode, got)
+		}
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  RoundingMode
+	}{
+		{"", RoundHalfEven},
+		{"half_even", RoundHalfEven},
+		{"HALF_UP", RoundHalfUp},
+		{" down ", RoundDown},
+		{"up", RoundUp},
+		{"floor", RoundFloor},
+		{"ceiling", RoundCeiling},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRoundingMode(tt.input)
+		if err != nil {
+			t.Errorf("ParseRoundingMode(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRoundingMode(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ParseRoundingMode("nearest"); err == nil {
+		t.Error("ParseRoundingMode(\"nearest\") did not fail")
+	}
+}
@@ -0,0 +1,82 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MarshalJSON пишет точное число без кавычек: 123.45
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON принимает число или строку ("123.45"); null дает 0
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*d = Zero
+		return nil
+	}
+
+	value := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalText и UnmarshalText нужны для ключей map и параметров форм
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// ScanNumeric читает значение NUMERIC из Postgres (pgx); NULL дает 0.
+// Незначащие нули, добавленные масштабом колонки (DECIMAL(38,18)), отбрасываются.
+func (d *Decimal) ScanNumeric(v pgtype.Numeric) error {
+	if !v.Valid {
+		*d = Zero
+		return nil
+	}
+	if v.NaN || v.InfinityModifier != pgtype.Finite {
+		return errors.New("money: cannot scan NaN or infinity")
+	}
+
+	coef := new(big.Int)
+	if v.Int != nil {
+		coef.Set(v.Int)
+	}
+	if v.Exp > 0 {
+		coef.Mul(coef, pow10(v.Exp))
+		*d = Decimal{coef: coef}
+		return nil
+	}
+
+	*d = Decimal{coef: coef, scale: -v.Exp}.Normalize()
+	return nil
+}
+
+// NumericValue передает значение в параметр NUMERIC (pgx)
+func (d Decimal) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: new(big.Int).Set(d.value()), Exp: -d.scale, Valid: true}, nil
+}
@@ -0,0 +1,95 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RoundingMode — правило округления отброшенных знаков
+type RoundingMode int
+
+const (
+	// RoundHalfEven — половина к четному (банковское округление); по умолчанию для конвертаций
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp — половина от нуля: 2.5 → 3, -2.5 → -3
+	RoundHalfUp
+	// RoundDown — к нулю (отбрасывание)
+	RoundDown
+	// RoundUp — от нуля
+	RoundUp
+	// RoundFloor — к минус бесконечности
+	RoundFloor
+	// RoundCeiling — к плюс бесконечности
+	RoundCeiling
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundHalfEven: "half_even",
+	RoundHalfUp:   "half_up",
+	RoundDown:     "down",
+	RoundUp:       "up",
+	RoundFloor:    "floor",
+	RoundCeiling:  "ceiling",
+}
+
+func (m RoundingMode) String() string {
+	if name, ok := roundingModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// ParseRoundingMode разбирает название правила; пустая строка — RoundHalfEven
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return RoundHalfEven, nil
+	}
+	for mode, modeName := range roundingModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return RoundHalfEven, fmt.Errorf("unknown rounding mode %q, use half_even, half_up, down, up, floor or ceiling", name)
+}
+
+// roundQuo делит num на den и округляет частное до целого по правилу mode
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	// Знак точного частного и сравнение остатка с половиной делителя
+	negative := (num.Sign() < 0) != (den.Sign() < 0)
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+	cmpHalf := half.Cmp(new(big.Int).Abs(den))
+
+	awayFromZero := false
+	switch mode {
+	case RoundHalfUp:
+		awayFromZero = cmpHalf >= 0
+	case RoundHalfEven:
+		awayFromZero = cmpHalf > 0 || (cmpHalf == 0 && quo.Bit(0) == 1)
+	case RoundDown:
+		awayFromZero = false
+	case RoundUp:
+		awayFromZero = true
+	case RoundFloor:
+		awayFromZero = negative
+	case RoundCeiling:
+		awayFromZero = !negative
+	}
+
+	if awayFromZero {
+		if negative {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	return quo
+}
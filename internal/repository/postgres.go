@@ -4,15 +4,26 @@ import (
 	"context"
 	"errors"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// dbExecutor — общие методы пула соединений и транзакции
+type dbExecutor interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type PostgresRepository struct {
-	db *pgxpool.Pool
+	// pool равен nil у репозитория, работающего внутри транзакции
+	pool *pgxpool.Pool
+	db   dbExecutor
 }
 
 func NewPostgresRepository(databaseURL string) (*PostgresRepository, error) {
@@ -33,20 +44,46 @@ func NewPostgresRepository(databaseURL string) (*PostgresRepository, error) {
 		return nil, err
 	}
 
-	return &PostgresRepository{db: pool}, nil
+	return &PostgresRepository{pool: pool, db: pool}, nil
 }
 
 func (r *PostgresRepository) Close() {
-	if r.db != nil {
-		r.db.Close()
+	if r.pool != nil {
+		r.pool.Close()
 	}
 }
 
+// WithTx выполняет fn в одной транзакции: если fn вернула ошибку, все изменения откатываются.
+// Вложенный вызов выполняется в уже открытой транзакции.
+func (r *PostgresRepository) WithTx(ctx context.Context, fn func(tx Repository) error) error {
+	return r.withTx(ctx, func(tx *PostgresRepository) error {
+		return fn(tx)
+	})
+}
+
+func (r *PostgresRepository) withTx(ctx context.Context, fn func(tx *PostgresRepository) error) error {
+	if r.pool == nil {
+		return fn(r)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&PostgresRepository{db: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 // User methods
 func (r *PostgresRepository) CreateUser(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (username, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (username, email, password, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -61,14 +98,28 @@ func (r *PostgresRepository) CreateUser(ctx context.Context, user *models.User)
 		user.Username,
 		user.Email,
 		string(hashedPassword),
+		user.Role,
 		time.Now(),
 		time.Now(),
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 }
 
+// UpdateUserPassword хеширует и сохраняет новый пароль пользователя
+func (r *PostgresRepository) UpdateUserPassword(ctx context.Context, userID int, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE users SET password = $1, updated_at = $2 WHERE id = $3`
+	_, err = r.db.Exec(ctx, query, string(hashedPassword), time.Now(), userID)
+	return err
+}
+
 func (r *PostgresRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT id, username, email, password, role, default_currency_id, verified_at, verification_sent_at,
+			totp_enabled_at IS NOT NULL, deletion_scheduled_at, created_at, updated_at
 		FROM users WHERE email = $1
 	`
 
@@ -78,6 +129,12 @@ func (r *PostgresRepository) GetUserByEmail(ctx context.Context, email string) (
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.DefaultCurrencyID,
+		&user.VerifiedAt,
+		&user.VerificationSentAt,
+		&user.TwoFactorEnabled,
+		&user.DeletionScheduledAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -94,7 +151,8 @@ func (r *PostgresRepository) GetUserByEmail(ctx context.Context, email string) (
 
 func (r *PostgresRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at
+		SELECT id, username, email, password, role, default_currency_id, verified_at, verification_sent_at,
+			totp_enabled_at IS NOT NULL, deletion_scheduled_at, created_at, updated_at
 		FROM users WHERE id = $1
 	`
 
@@ -104,6 +162,12 @@ func (r *PostgresRepository) GetUserByID(ctx context.Context, id int) (*models.U
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.DefaultCurrencyID,
+		&user.VerifiedAt,
+		&user.VerificationSentAt,
+		&user.TwoFactorEnabled,
+		&user.DeletionScheduledAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -144,8 +208,22 @@ func (r *PostgresRepository) GetCategoriesByUserID(ctx context.Context, userID i
         WHERE user_id = $1 OR user_id IS NULL
 		ORDER BY name
 	`
+	return r.queryCategories(ctx, query, userID)
+}
 
-	rows, err := r.db.Query(ctx, query, userID)
+// GetGlobalCategories возвращает общие категории, доступные всем пользователям
+func (r *PostgresRepository) GetGlobalCategories(ctx context.Context) ([]models.Category, error) {
+	query := `
+		SELECT id, user_id, name, description, type, created_at
+		FROM categories
+		WHERE user_id IS NULL
+		ORDER BY name
+	`
+	return r.queryCategories(ctx, query)
+}
+
+func (r *PostgresRepository) queryCategories(ctx context.Context, query string, args ...any) ([]models.Category, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -171,6 +249,18 @@ func (r *PostgresRepository) GetCategoriesByUserID(ctx context.Context, userID i
 	return categories, nil
 }
 
+func (r *PostgresRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	query := `UPDATE categories SET name = $1, description = $2, type = $3 WHERE id = $4`
+	_, err := r.db.Exec(ctx, query, category.Name, category.Description, category.Type, category.ID)
+	return err
+}
+
+func (r *PostgresRepository) DeleteCategory(ctx context.Context, id int) error {
+	query := `DELETE FROM categories WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
 func (r *PostgresRepository) GetCategoryByID(ctx context.Context, id int) (*models.Category, error) {
 	query := `
 		SELECT id, user_id, name, description, type, created_at
@@ -323,35 +413,56 @@ func (r *PostgresRepository) GetTransactionsByPeriod(ctx context.Context, userID
 }
 
 // Session methods
+
+// CreateSession сохраняет refresh-токен. Без FamilyID начинается новое семейство,
+// идентификатором которого становится id первой строки.
 func (r *PostgresRepository) CreateSession(ctx context.Context, session *models.Session) error {
 	query := `
-		INSERT INTO sessions (user_id, token, expires_at, created_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
+		WITH next AS (SELECT nextval(pg_get_serial_sequence('sessions', 'id')) AS id)
+		INSERT INTO sessions (id, user_id, token_hash, family_id, user_agent, ip_address,
+			signed_in_at, last_seen_at, expires_at, created_at)
+		SELECT id, $1, $2, COALESCE(NULLIF($3, 0), id), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9 FROM next
+		RETURNING id, family_id, created_at
 	`
 
 	return r.db.QueryRow(
 		ctx,
 		query,
 		session.UserID,
-		session.Token,
+		session.TokenHash,
+		session.FamilyID,
+		session.UserAgent,
+		session.IPAddress,
+		session.SignedInAt,
+		session.LastSeenAt,
 		session.ExpiresAt,
 		time.Now(),
-	).Scan(&session.ID, &session.CreatedAt)
+	).Scan(&session.ID, &session.FamilyID, &session.CreatedAt)
 }
 
-func (r *PostgresRepository) GetSessionByToken(ctx context.Context, token string) (*models.Session, error) {
+// GetSessionByTokenHash ищет refresh-токен по хешу, включая обмененные и отозванные,
+// чтобы распознать повторное использование
+func (r *PostgresRepository) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
 	query := `
-		SELECT id, user_id, token, expires_at, created_at
-		FROM sessions WHERE token = $1 AND expires_at > $2
+		SELECT id, user_id, family_id, token_hash, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+			COALESCE(signed_in_at, created_at), COALESCE(last_seen_at, created_at),
+			expires_at, rotated_at, revoked_at, created_at
+		FROM sessions WHERE token_hash = $1
 	`
 
 	var session models.Session
-	err := r.db.QueryRow(ctx, query, token, time.Now()).Scan(
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
 		&session.ID,
 		&session.UserID,
-		&session.Token,
+		&session.FamilyID,
+		&session.TokenHash,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.SignedInAt,
+		&session.LastSeenAt,
 		&session.ExpiresAt,
+		&session.RotatedAt,
+		&session.RevokedAt,
 		&session.CreatedAt,
 	)
 
@@ -365,12 +476,121 @@ func (r *PostgresRepository) GetSessionByToken(ctx context.Context, token string
 	return &session, nil
 }
 
-func (r *PostgresRepository) DeleteSession(token string) error {
-	query := `DELETE FROM sessions WHERE token = $1`
-	_, err := r.db.Exec(context.Background(), query, token)
+// RotateSession отмечает refresh-токен обмененным; false — токен уже обменян или отозван
+// (в том числе параллельным запросом)
+func (r *PostgresRepository) RotateSession(ctx context.Context, id int) (bool, error) {
+	query := `
+		UPDATE sessions SET rotated_at = $1
+		WHERE id = $2 AND rotated_at IS NULL AND revoked_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// IsSessionFamilyActive сообщает, есть ли у семейства неотозванный и неистекший токен
+func (r *PostgresRepository) IsSessionFamilyActive(ctx context.Context, familyID int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM sessions
+			WHERE family_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		)
+	`
+	var active bool
+	err := r.db.QueryRow(ctx, query, familyID, time.Now()).Scan(&active)
+	return active, err
+}
+
+func (r *PostgresRepository) RevokeSessionFamily(ctx context.Context, familyID int) error {
+	query := `UPDATE sessions SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+	_, err := r.db.Exec(ctx, query, time.Now(), familyID)
+	return err
+}
+
+// TouchSession обновляет время последней активности и IP текущего токена семейства,
+// если с прошлой отметки прошло больше staleBefore
+func (r *PostgresRepository) TouchSession(ctx context.Context, familyID int, ipAddress string, seenAt, staleBefore time.Time) error {
+	query := `
+		UPDATE sessions SET last_seen_at = $1, ip_address = COALESCE(NULLIF($2, ''), ip_address)
+		WHERE family_id = $3 AND rotated_at IS NULL AND revoked_at IS NULL
+			AND (last_seen_at IS NULL OR last_seen_at < $4)
+	`
+	_, err := r.db.Exec(ctx, query, seenAt, ipAddress, familyID, staleBefore)
+	return err
+}
+
+// GetActiveSessionsByUserID возвращает действующие сессии пользователя — по одной на семейство,
+// недавно активные первыми
+func (r *PostgresRepository) GetActiveSessionsByUserID(ctx context.Context, userID int) ([]models.SessionInfo, error) {
+	query := `
+		SELECT family_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+			COALESCE(signed_in_at, created_at), COALESCE(last_seen_at, created_at), expires_at
+		FROM sessions
+		WHERE user_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC NULLS LAST, family_id DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.SessionInfo
+	for rows.Next() {
+		var session models.SessionInfo
+		err := rows.Scan(
+			&session.ID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.SignedInAt,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeUserSession отзывает сессию пользователя; false — действующей сессии с таким id нет
+func (r *PostgresRepository) RevokeUserSession(ctx context.Context, userID, familyID int) (bool, error) {
+	query := `
+		UPDATE sessions SET revoked_at = $1
+		WHERE family_id = $2 AND user_id = $3 AND revoked_at IS NULL AND expires_at > $1
+	`
+	tag, err := r.db.Exec(ctx, query, time.Now(), familyID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RevokeOtherUserSessions отзывает все сессии пользователя, кроме keepFamilyID
+func (r *PostgresRepository) RevokeOtherUserSessions(ctx context.Context, userID, keepFamilyID int) error {
+	query := `
+		UPDATE sessions SET revoked_at = $1
+		WHERE user_id = $2 AND family_id <> $3 AND revoked_at IS NULL
+	`
+	_, err := r.db.Exec(ctx, query, time.Now(), userID, keepFamilyID)
 	return err
 }
 
+// DeleteExpiredSessions удаляет истекшие refresh-токены (по индексу idx_sessions_expires_at)
+func (r *PostgresRepository) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	query := `DELETE FROM sessions WHERE expires_at <= $1`
+	tag, err := r.db.Exec(ctx, query, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
 // User methods
 func (r *PostgresRepository) UpdateUser(user *models.User) error {
 	query := `
@@ -397,64 +617,167 @@ func (r *PostgresRepository) SetUserDefaultCurrency(userID, currencyID int) erro
 	return err
 }
 
-// Currency methods
-func (r *PostgresRepository) CreateCurrency(currency *models.Currency) error {
+func (r *PostgresRepository) SetUserRole(ctx context.Context, userID int, role string) error {
+	query := `UPDATE users SET role = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.Exec(ctx, query, role, time.Now(), userID)
+	return err
+}
+
+// SetUserVerified отмечает email пользователя подтвержденным (повторно время не меняется)
+func (r *PostgresRepository) SetUserVerified(ctx context.Context, userID int, verifiedAt time.Time) error {
+	query := `UPDATE users SET verified_at = COALESCE(verified_at, $1), updated_at = $1 WHERE id = $2`
+	_, err := r.db.Exec(ctx, query, verifiedAt, userID)
+	return err
+}
+
+// ClaimVerificationSend отмечает отправку письма с подтверждением, если email еще не подтвержден
+// и предыдущее письмо отправлено раньше sentBefore; false — отправлять нельзя
+func (r *PostgresRepository) ClaimVerificationSend(ctx context.Context, userID int, sentAt, sentBefore time.Time) (bool, error) {
 	query := `
-		INSERT INTO currencies (code, name, symbol, created_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at
+		UPDATE users SET verification_sent_at = $1
+		WHERE id = $2 AND verified_at IS NULL
+			AND (verification_sent_at IS NULL OR verification_sent_at < $3)
 	`
-
-	return r.db.QueryRow(
-		context.Background(),
-		query,
-		currency.Code,
-		currency.Name,
-		currency.Symbol,
-		time.Now(),
-	).Scan(&currency.ID, &currency.CreatedAt)
+	tag, err := r.db.Exec(ctx, query, sentAt, userID, sentBefore)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
 }
 
-func (r *PostgresRepository) GetAllCurrencies() ([]models.Currency, error) {
-	query := `SELECT id, code, name, symbol, created_at FROM currencies ORDER BY code`
+func (r *PostgresRepository) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	query := `
+		SELECT id, username, email, role, default_currency_id, verified_at, totp_enabled_at IS NOT NULL, deletion_scheduled_at,
+			created_at, updated_at
+		FROM users ORDER BY id
+	`
 
-	rows, err := r.db.Query(context.Background(), query)
+	rows, err := r.db.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var currencies []models.Currency
+	var users []models.User
 	for rows.Next() {
-		var currency models.Currency
+		var user models.User
 		err := rows.Scan(
-			&currency.ID,
-			&currency.Code,
-			&currency.Name,
-			&currency.Symbol,
-			&currency.CreatedAt,
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Role,
+			&user.DefaultCurrencyID,
+			&user.VerifiedAt,
+			&user.TwoFactorEnabled,
+			&user.DeletionScheduledAt,
+			&user.CreatedAt,
+			&user.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		currencies = append(currencies, currency)
+		users = append(users, user)
 	}
 
-	return currencies, nil
+	return users, rows.Err()
 }
 
-func (r *PostgresRepository) GetCurrencyByID(ctx context.Context, id int) (*models.Currency, error) {
-	query := `SELECT id, code, name, symbol, created_at FROM currencies WHERE id = $1`
+// Currency methods
+
+// currencyColumns — общий список колонок валюты
+const currencyColumns = `id, code, name, symbol, minor_units, symbol_position, is_active, kind, created_at, updated_at`
 
+func scanCurrency(row rowScanner) (*models.Currency, error) {
 	var currency models.Currency
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := row.Scan(
 		&currency.ID,
 		&currency.Code,
 		&currency.Name,
 		&currency.Symbol,
+		&currency.MinorUnits,
+		&currency.SymbolPosition,
+		&currency.IsActive,
+		&currency.Kind,
 		&currency.CreatedAt,
+		&currency.UpdatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &currency, nil
+}
+
+func (r *PostgresRepository) queryCurrencies(ctx context.Context, query string, args ...any) ([]models.Currency, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var currencies []models.Currency
+	for rows.Next() {
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			return nil, err
+		}
+		currencies = append(currencies, *currency)
+	}
+
+	return currencies, rows.Err()
+}
+
+func (r *PostgresRepository) CreateCurrency(currency *models.Currency) error {
+	query := `
+		INSERT INTO currencies (code, name, symbol, minor_units, symbol_position, is_active, kind, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.QueryRow(
+		context.Background(),
+		query,
+		currency.Code,
+		currency.Name,
+		currency.Symbol,
+		currency.MinorUnits,
+		currency.SymbolPosition,
+		currency.IsActive,
+		currency.Kind,
+		time.Now(),
+	).Scan(&currency.ID, &currency.CreatedAt, &currency.UpdatedAt)
+}
 
+func (r *PostgresRepository) UpdateCurrency(ctx context.Context, currency *models.Currency) error {
+	query := `
+		UPDATE currencies
+		SET name = $1, symbol = $2, minor_units = $3, symbol_position = $4, is_active = $5, updated_at = $6
+		WHERE id = $7
+		RETURNING updated_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		currency.Name,
+		currency.Symbol,
+		currency.MinorUnits,
+		currency.SymbolPosition,
+		currency.IsActive,
+		time.Now(),
+		currency.ID,
+	).Scan(&currency.UpdatedAt)
+}
+
+func (r *PostgresRepository) GetAllCurrencies() ([]models.Currency, error) {
+	query := `SELECT ` + currencyColumns + ` FROM currencies ORDER BY code`
+	return r.queryCurrencies(context.Background(), query)
+}
+
+func (r *PostgresRepository) GetCurrencyByID(ctx context.Context, id int) (*models.Currency, error) {
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE id = $1`
+
+	currency, err := scanCurrency(r.db.QueryRow(ctx, query, id))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
@@ -462,21 +785,13 @@ func (r *PostgresRepository) GetCurrencyByID(ctx context.Context, id int) (*mode
 		return nil, err
 	}
 
-	return &currency, nil
+	return currency, nil
 }
 
 func (r *PostgresRepository) GetCurrencyByCode(code string) (*models.Currency, error) {
-	query := `SELECT id, code, name, symbol, created_at FROM currencies WHERE code = $1`
-
-	var currency models.Currency
-	err := r.db.QueryRow(context.Background(), query, code).Scan(
-		&currency.ID,
-		&currency.Code,
-		&currency.Name,
-		&currency.Symbol,
-		&currency.CreatedAt,
-	)
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = $1`
 
+	currency, err := scanCurrency(r.db.QueryRow(context.Background(), query, code))
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
@@ -484,14 +799,43 @@ func (r *PostgresRepository) GetCurrencyByCode(code string) (*models.Currency, e
 		return nil, err
 	}
 
-	return &currency, nil
+	return currency, nil
+}
+
+// GetUserCurrencies возвращает валюты, выбранные пользователем (пустой список — выбор не сделан)
+func (r *PostgresRepository) GetUserCurrencies(ctx context.Context, userID int) ([]models.Currency, error) {
+	query := `
+		SELECT ` + currencyColumns + `
+		FROM currencies
+		WHERE id IN (SELECT currency_id FROM user_currencies WHERE user_id = $1)
+		ORDER BY code
+	`
+	return r.queryCurrencies(ctx, query, userID)
+}
+
+// SetUserCurrencies заменяет список валют пользователя одним запросом
+func (r *PostgresRepository) SetUserCurrencies(ctx context.Context, userID int, currencyIDs []int) error {
+	query := `
+		WITH removed AS (
+			DELETE FROM user_currencies WHERE user_id = $1 AND NOT (currency_id = ANY($2))
+		)
+		INSERT INTO user_currencies (user_id, currency_id)
+		SELECT $1, unnest($2::int[])
+		ON CONFLICT (user_id, currency_id) DO NOTHING
+	`
+
+	if currencyIDs == nil {
+		currencyIDs = []int{}
+	}
+	_, err := r.db.Exec(ctx, query, userID, currencyIDs)
+	return err
 }
 
 // Account methods
 func (r *PostgresRepository) CreateAccount(account *models.Account) error {
 	query := `
-		INSERT INTO accounts (user_id, currency_id, balance, is_default, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO accounts (user_id, currency_id, balance, kind, is_default, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -501,6 +845,7 @@ func (r *PostgresRepository) CreateAccount(account *models.Account) error {
 		account.UserID,
 		account.CurrencyID,
 		account.Balance,
+		account.Kind,
 		account.IsDefault,
 		time.Now(),
 		time.Now(),
@@ -509,8 +854,8 @@ func (r *PostgresRepository) CreateAccount(account *models.Account) error {
 
 func (r *PostgresRepository) GetAccountsByUserID(ctx context.Context, userID int) ([]models.Account, error) {
 	query := `
-		SELECT a.id, a.user_id, a.currency_id, a.balance, a.is_default, a.created_at, a.updated_at,
-		       c.id, c.code, c.name, c.symbol, c.created_at
+		SELECT a.id, a.user_id, a.currency_id, a.balance, a.kind, a.is_default, a.created_at, a.updated_at,
+		       c.id, c.code, c.name, c.symbol, c.minor_units, c.symbol_position, c.is_active, c.kind, c.created_at
 		FROM accounts a
 		JOIN currencies c ON a.currency_id = c.id
 		WHERE a.user_id = $1
@@ -532,6 +877,7 @@ func (r *PostgresRepository) GetAccountsByUserID(ctx context.Context, userID int
 			&account.UserID,
 			&account.CurrencyID,
 			&account.Balance,
+			&account.Kind,
 			&account.IsDefault,
 			&account.CreatedAt,
 			&account.UpdatedAt,
@@ -539,6 +885,10 @@ func (r *PostgresRepository) GetAccountsByUserID(ctx context.Context, userID int
 			&currency.Code,
 			&currency.Name,
 			&currency.Symbol,
+			&currency.MinorUnits,
+			&currency.SymbolPosition,
+			&currency.IsActive,
+			&currency.Kind,
 			&currency.CreatedAt,
 		)
 		if err != nil {
@@ -552,13 +902,25 @@ func (r *PostgresRepository) GetAccountsByUserID(ctx context.Context, userID int
 }
 
 func (r *PostgresRepository) GetAccountByID(ctx context.Context, id int) (*models.Account, error) {
+	return r.getAccountByID(ctx, id, false)
+}
+
+// GetAccountByIDForUpdate читает счет и блокирует его строку до конца транзакции
+func (r *PostgresRepository) GetAccountByIDForUpdate(ctx context.Context, id int) (*models.Account, error) {
+	return r.getAccountByID(ctx, id, true)
+}
+
+func (r *PostgresRepository) getAccountByID(ctx context.Context, id int, lock bool) (*models.Account, error) {
 	query := `
-		SELECT a.id, a.user_id, a.currency_id, a.balance, a.is_default, a.created_at, a.updated_at,
-		       c.id, c.code, c.name, c.symbol, c.created_at
+		SELECT a.id, a.user_id, a.currency_id, a.balance, a.kind, a.is_default, a.created_at, a.updated_at,
+		       c.id, c.code, c.name, c.symbol, c.minor_units, c.symbol_position, c.is_active, c.kind, c.created_at
 		FROM accounts a
 		JOIN currencies c ON a.currency_id = c.id
 		WHERE a.id = $1
 	`
+	if lock {
+		query += ` FOR UPDATE OF a`
+	}
 
 	var account models.Account
 	var currency models.Currency
@@ -567,6 +929,7 @@ func (r *PostgresRepository) GetAccountByID(ctx context.Context, id int) (*model
 		&account.UserID,
 		&account.CurrencyID,
 		&account.Balance,
+		&account.Kind,
 		&account.IsDefault,
 		&account.CreatedAt,
 		&account.UpdatedAt,
@@ -574,6 +937,10 @@ func (r *PostgresRepository) GetAccountByID(ctx context.Context, id int) (*model
 		&currency.Code,
 		&currency.Name,
 		&currency.Symbol,
+		&currency.MinorUnits,
+		&currency.SymbolPosition,
+		&currency.IsActive,
+		&currency.Kind,
 		&currency.CreatedAt,
 	)
 
@@ -590,8 +957,8 @@ func (r *PostgresRepository) GetAccountByID(ctx context.Context, id int) (*model
 
 func (r *PostgresRepository) GetDefaultAccount(ctx context.Context, userID int) (*models.Account, error) {
 	query := `
-		SELECT a.id, a.user_id, a.currency_id, a.balance, a.is_default, a.created_at, a.updated_at,
-		       c.id, c.code, c.name, c.symbol, c.created_at
+		SELECT a.id, a.user_id, a.currency_id, a.balance, a.kind, a.is_default, a.created_at, a.updated_at,
+		       c.id, c.code, c.name, c.symbol, c.minor_units, c.symbol_position, c.is_active, c.kind, c.created_at
 		FROM accounts a
 		JOIN currencies c ON a.currency_id = c.id
 		WHERE a.user_id = $1 AND a.is_default = true
@@ -604,6 +971,7 @@ func (r *PostgresRepository) GetDefaultAccount(ctx context.Context, userID int)
 		&account.UserID,
 		&account.CurrencyID,
 		&account.Balance,
+		&account.Kind,
 		&account.IsDefault,
 		&account.CreatedAt,
 		&account.UpdatedAt,
@@ -611,6 +979,10 @@ func (r *PostgresRepository) GetDefaultAccount(ctx context.Context, userID int)
 		&currency.Code,
 		&currency.Name,
 		&currency.Symbol,
+		&currency.MinorUnits,
+		&currency.SymbolPosition,
+		&currency.IsActive,
+		&currency.Kind,
 		&currency.CreatedAt,
 	)
 
@@ -625,7 +997,7 @@ func (r *PostgresRepository) GetDefaultAccount(ctx context.Context, userID int)
 	return &account, nil
 }
 
-func (r *PostgresRepository) UpdateAccountBalance(accountID int, newBalance float64) error {
+func (r *PostgresRepository) UpdateAccountBalance(accountID int, newBalance money.Decimal) error {
 	query := `UPDATE accounts SET balance = $1, updated_at = $2 WHERE id = $3`
 	_, err := r.db.Exec(context.Background(), query, newBalance, time.Now(), accountID)
 	return err
@@ -646,59 +1018,46 @@ func (r *PostgresRepository) SetDefaultAccount(userID, accountID int) error {
 }
 
 // Exchange Rate methods
-func (r *PostgresRepository) CreateOrUpdateExchangeRate(rate *models.ExchangeRate) error {
-	query := `
-		INSERT INTO exchange_rates (base_currency_id, target_currency_id, rate, last_updated)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (base_currency_id, target_currency_id) 
-		DO UPDATE SET rate = EXCLUDED.rate, last_updated = EXCLUDED.last_updated
-		RETURNING id, last_updated
-	`
 
-	return r.db.QueryRow(
-		context.Background(),
-		query,
-		rate.BaseCurrencyID,
-		rate.TargetCurrencyID,
-		rate.Rate,
-		time.Now(),
-	).Scan(&rate.ID, &rate.LastUpdated)
-}
+// exchangeRateColumns — общий список колонок курса с базовой и целевой валютой
+const exchangeRateColumns = `
+		er.id, er.base_currency_id, er.target_currency_id, er.rate, er.rate_date, er.last_updated,
+		bc.id, bc.code, bc.name, bc.symbol, bc.minor_units, bc.symbol_position, bc.is_active, bc.kind, bc.created_at,
+		tc.id, tc.code, tc.name, tc.symbol, tc.minor_units, tc.symbol_position, tc.is_active, tc.kind, tc.created_at`
 
-func (r *PostgresRepository) GetExchangeRate(baseCurrencyID, targetCurrencyID int) (*models.ExchangeRate, error) {
-	query := `
-		SELECT er.id, er.base_currency_id, er.target_currency_id, er.rate, er.last_updated,
-		       bc.id, bc.code, bc.name, bc.symbol, bc.created_at,
-		       tc.id, tc.code, tc.name, tc.symbol, tc.created_at
-		FROM exchange_rates er
-		JOIN currencies bc ON er.base_currency_id = bc.id
-		JOIN currencies tc ON er.target_currency_id = tc.id
-		WHERE er.base_currency_id = $1 AND er.target_currency_id = $2
-	`
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
+func scanExchangeRate(row rowScanner) (*models.ExchangeRate, error) {
 	var rate models.ExchangeRate
 	var baseCurrency, targetCurrency models.Currency
-	err := r.db.QueryRow(context.Background(), query, baseCurrencyID, targetCurrencyID).Scan(
+	err := row.Scan(
 		&rate.ID,
 		&rate.BaseCurrencyID,
 		&rate.TargetCurrencyID,
 		&rate.Rate,
+		&rate.RateDate,
 		&rate.LastUpdated,
 		&baseCurrency.ID,
 		&baseCurrency.Code,
 		&baseCurrency.Name,
 		&baseCurrency.Symbol,
+		&baseCurrency.MinorUnits,
+		&baseCurrency.SymbolPosition,
+		&baseCurrency.IsActive,
+		&baseCurrency.Kind,
 		&baseCurrency.CreatedAt,
 		&targetCurrency.ID,
 		&targetCurrency.Code,
 		&targetCurrency.Name,
 		&targetCurrency.Symbol,
+		&targetCurrency.MinorUnits,
+		&targetCurrency.SymbolPosition,
+		&targetCurrency.IsActive,
+		&targetCurrency.Kind,
 		&targetCurrency.CreatedAt,
 	)
-
-	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, nil
-	}
 	if err != nil {
 		return nil, err
 	}
@@ -708,18 +1067,8 @@ func (r *PostgresRepository) GetExchangeRate(baseCurrencyID, targetCurrencyID in
 	return &rate, nil
 }
 
-func (r *PostgresRepository) GetAllExchangeRates() ([]models.ExchangeRate, error) {
-	query := `
-		SELECT er.id, er.base_currency_id, er.target_currency_id, er.rate, er.last_updated,
-		       bc.id, bc.code, bc.name, bc.symbol, bc.created_at,
-		       tc.id, tc.code, tc.name, tc.symbol, tc.created_at
-		FROM exchange_rates er
-		JOIN currencies bc ON er.base_currency_id = bc.id
-		JOIN currencies tc ON er.target_currency_id = tc.id
-		ORDER BY bc.code, tc.code
-	`
-
-	rows, err := r.db.Query(context.Background(), query)
+func (r *PostgresRepository) queryExchangeRates(query string, args ...any) ([]models.ExchangeRate, error) {
+	rows, err := r.db.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -727,84 +1076,120 @@ func (r *PostgresRepository) GetAllExchangeRates() ([]models.ExchangeRate, error
 
 	var rates []models.ExchangeRate
 	for rows.Next() {
-		var rate models.ExchangeRate
-		var baseCurrency, targetCurrency models.Currency
-		err := rows.Scan(
-			&rate.ID,
-			&rate.BaseCurrencyID,
-			&rate.TargetCurrencyID,
-			&rate.Rate,
-			&rate.LastUpdated,
-			&baseCurrency.ID,
-			&baseCurrency.Code,
-			&baseCurrency.Name,
-			&baseCurrency.Symbol,
-			&baseCurrency.CreatedAt,
-			&targetCurrency.ID,
-			&targetCurrency.Code,
-			&targetCurrency.Name,
-			&targetCurrency.Symbol,
-			&targetCurrency.CreatedAt,
-		)
+		rate, err := scanExchangeRate(rows)
 		if err != nil {
 			return nil, err
 		}
-		rate.BaseCurrency = &baseCurrency
-		rate.TargetCurrency = &targetCurrency
-		rates = append(rates, rate)
+		rates = append(rates, *rate)
 	}
 
 	return rates, nil
 }
 
-func (r *PostgresRepository) GetExchangeRatesByBaseCurrency(baseCurrencyID int) ([]models.ExchangeRate, error) {
+// CreateOrUpdateExchangeRate сохраняет курс на дату rate.RateDate (по умолчанию — сегодня).
+// Возвращает прежний курс на эту дату, если он был заменен, или nil для новой записи.
+func (r *PostgresRepository) CreateOrUpdateExchangeRate(rate *models.ExchangeRate) (*money.Decimal, error) {
 	query := `
-		SELECT er.id, er.base_currency_id, er.target_currency_id, er.rate, er.last_updated,
-		       bc.id, bc.code, bc.name, bc.symbol, bc.created_at,
-		       tc.id, tc.code, tc.name, tc.symbol, tc.created_at
+		WITH previous AS (
+			SELECT rate FROM exchange_rates
+			WHERE base_currency_id = $1 AND target_currency_id = $2 AND rate_date = $4::date
+		)
+		INSERT INTO exchange_rates (base_currency_id, target_currency_id, rate, rate_date, last_updated)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (base_currency_id, target_currency_id, rate_date)
+		DO UPDATE SET rate = EXCLUDED.rate, last_updated = EXCLUDED.last_updated
+		RETURNING id, rate_date, last_updated, (SELECT rate FROM previous)
+	`
+
+	rateDate := rate.RateDate
+	if rateDate.IsZero() {
+		rateDate = time.Now()
+	}
+
+	var previous *money.Decimal
+	err := r.db.QueryRow(
+		context.Background(),
+		query,
+		rate.BaseCurrencyID,
+		rate.TargetCurrencyID,
+		rate.Rate,
+		rateDate,
+		time.Now(),
+	).Scan(&rate.ID, &rate.RateDate, &rate.LastUpdated, &previous)
+	if err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+// GetExchangeRate возвращает последний курс на дату asOf или ранее (nil, если таких нет)
+func (r *PostgresRepository) GetExchangeRate(baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.ExchangeRate, error) {
+	query := `
+		SELECT ` + exchangeRateColumns + `
 		FROM exchange_rates er
 		JOIN currencies bc ON er.base_currency_id = bc.id
 		JOIN currencies tc ON er.target_currency_id = tc.id
-		WHERE er.base_currency_id = $1
-		ORDER BY tc.code
+		WHERE er.base_currency_id = $1 AND er.target_currency_id = $2 AND er.rate_date <= $3::date
+		ORDER BY er.rate_date DESC
+		LIMIT 1
 	`
 
-	rows, err := r.db.Query(context.Background(), query, baseCurrencyID)
+	rate, err := scanExchangeRate(r.db.QueryRow(context.Background(), query, baseCurrencyID, targetCurrencyID, asOf))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var rates []models.ExchangeRate
-	for rows.Next() {
-		var rate models.ExchangeRate
-		var baseCurrency, targetCurrency models.Currency
-		err := rows.Scan(
-			&rate.ID,
-			&rate.BaseCurrencyID,
-			&rate.TargetCurrencyID,
-			&rate.Rate,
-			&rate.LastUpdated,
-			&baseCurrency.ID,
-			&baseCurrency.Code,
-			&baseCurrency.Name,
-			&baseCurrency.Symbol,
-			&baseCurrency.CreatedAt,
-			&targetCurrency.ID,
-			&targetCurrency.Code,
-			&targetCurrency.Name,
-			&targetCurrency.Symbol,
-			&targetCurrency.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		rate.BaseCurrency = &baseCurrency
-		rate.TargetCurrency = &targetCurrency
-		rates = append(rates, rate)
-	}
+	return rate, nil
+}
 
-	return rates, nil
+// GetAllExchangeRates возвращает последний курс на дату asOf по каждой паре
+func (r *PostgresRepository) GetAllExchangeRates(asOf time.Time) ([]models.ExchangeRate, error) {
+	query := `
+		SELECT ` + exchangeRateColumns + `
+		FROM (
+			SELECT DISTINCT ON (base_currency_id, target_currency_id) *
+			FROM exchange_rates
+			WHERE rate_date <= $1::date
+			ORDER BY base_currency_id, target_currency_id, rate_date DESC
+		) er
+		JOIN currencies bc ON er.base_currency_id = bc.id
+		JOIN currencies tc ON er.target_currency_id = tc.id
+		ORDER BY bc.code, tc.code
+	`
+
+	return r.queryExchangeRates(query, asOf)
+}
+
+func (r *PostgresRepository) GetExchangeRatesByBaseCurrency(baseCurrencyID int) ([]models.ExchangeRate, error) {
+	query := `
+		SELECT DISTINCT ON (er.target_currency_id) ` + exchangeRateColumns + `
+		FROM exchange_rates er
+		JOIN currencies bc ON er.base_currency_id = bc.id
+		JOIN currencies tc ON er.target_currency_id = tc.id
+		WHERE er.base_currency_id = $1
+		ORDER BY er.target_currency_id, er.rate_date DESC
+	`
+
+	return r.queryExchangeRates(query, baseCurrencyID)
+}
+
+// GetExchangeRateHistory возвращает временной ряд курса пары за период
+func (r *PostgresRepository) GetExchangeRateHistory(baseCurrencyID, targetCurrencyID int, start, end time.Time) ([]models.ExchangeRate, error) {
+	query := `
+		SELECT ` + exchangeRateColumns + `
+		FROM exchange_rates er
+		JOIN currencies bc ON er.base_currency_id = bc.id
+		JOIN currencies tc ON er.target_currency_id = tc.id
+		WHERE er.base_currency_id = $1 AND er.target_currency_id = $2
+		  AND er.rate_date >= $3::date AND er.rate_date <= $4::date
+		ORDER BY er.rate_date
+	`
+
+	return r.queryExchangeRates(query, baseCurrencyID, targetCurrencyID, start, end)
 }
 
 // New method to get transactions by account ID
@@ -892,7 +1277,7 @@ func (r *PostgresRepository) GetTransactionSummaryByAccountID(ctx context.Contex
         WHERE t.account_id = $1 AND t.date >= $2 AND t.date <= $3
 	`
 
-	var totalIncome, totalExpense float64
+	var totalIncome, totalExpense money.Decimal
 	var transactionCount int
 	err := r.db.QueryRow(ctx, query, accountID, start, end).Scan(&totalIncome, &totalExpense, &transactionCount)
 	if err != nil {
@@ -902,7 +1287,7 @@ func (r *PostgresRepository) GetTransactionSummaryByAccountID(ctx context.Contex
 	return &models.TransactionSummary{
 		TotalIncome:      totalIncome,
 		TotalExpense:     totalExpense,
-		NetAmount:        totalIncome - totalExpense,
+		NetAmount:        totalIncome.Sub(totalExpense),
 		TransactionCount: transactionCount,
 		PeriodStart:      start.Format("2006-01-02"),
 		PeriodEnd:        end.Format("2006-01-02"),
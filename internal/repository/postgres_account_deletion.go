@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"personal-finance-tracker/internal/models"
+	"time"
+)
+
+// Account deletion methods
+func (r *PostgresRepository) CountUsersByRole(ctx context.Context, role string) (int, error) {
+	query := `SELECT COUNT(*) FROM users WHERE role = $1`
+	var count int
+	err := r.db.QueryRow(ctx, query, role).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresRepository) ScheduleUserDeletion(ctx context.Context, userID int, at time.Time) error {
+	query := `UPDATE users SET deletion_scheduled_at = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.Exec(ctx, query, at, time.Now(), userID)
+	return err
+}
+
+func (r *PostgresRepository) CancelUserDeletion(ctx context.Context, userID int) error {
+	query := `UPDATE users SET deletion_scheduled_at = NULL, updated_at = $1 WHERE id = $2`
+	_, err := r.db.Exec(ctx, query, time.Now(), userID)
+	return err
+}
+
+// DeleteScheduledUsers удаляет пользователей, срок удаления которых наступил; связанные
+// данные удаляются каскадно
+func (r *PostgresRepository) DeleteScheduledUsers(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deletion_scheduled_at <= $1`
+	tag, err := r.db.Exec(ctx, query, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetSessionHistoryByUserID возвращает все сохраненные сессии пользователя, включая
+// завершенные, — по последнему токену каждого семейства
+func (r *PostgresRepository) GetSessionHistoryByUserID(ctx context.Context, userID int) ([]models.SessionInfo, error) {
+	query := `
+		SELECT DISTINCT ON (family_id) family_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+			COALESCE(signed_in_at, created_at), COALESCE(last_seen_at, created_at), expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1
+		ORDER BY family_id DESC, id DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.SessionInfo
+	for rows.Next() {
+		var session models.SessionInfo
+		err := rows.Scan(
+			&session.ID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.SignedInAt,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+			&session.RevokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
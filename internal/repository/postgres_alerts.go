@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const rateAlertColumns = `
+	id, user_id, base_currency_id, target_currency_id, condition, threshold,
+	COALESCE(webhook_url, ''), is_active, last_rate, last_triggered_at, created_at, updated_at`
+
+func scanRateAlert(row rowScanner) (*models.RateAlert, error) {
+	var alert models.RateAlert
+	err := row.Scan(
+		&alert.ID,
+		&alert.UserID,
+		&alert.BaseCurrencyID,
+		&alert.TargetCurrencyID,
+		&alert.Condition,
+		&alert.Threshold,
+		&alert.WebhookURL,
+		&alert.IsActive,
+		&alert.LastRate,
+		&alert.LastTriggeredAt,
+		&alert.CreatedAt,
+		&alert.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *PostgresRepository) queryRateAlerts(ctx context.Context, query string, args ...any) ([]models.RateAlert, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []models.RateAlert
+	for rows.Next() {
+		alert, err := scanRateAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// Rate alert methods
+func (r *PostgresRepository) CreateRateAlert(ctx context.Context, alert *models.RateAlert) error {
+	query := `
+		INSERT INTO rate_alerts (user_id, base_currency_id, target_currency_id, condition, threshold,
+			webhook_url, is_active, last_rate, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		alert.UserID,
+		alert.BaseCurrencyID,
+		alert.TargetCurrencyID,
+		alert.Condition,
+		alert.Threshold,
+		alert.WebhookURL,
+		alert.IsActive,
+		alert.LastRate,
+		time.Now(),
+		time.Now(),
+	).Scan(&alert.ID, &alert.CreatedAt, &alert.UpdatedAt)
+}
+
+func (r *PostgresRepository) GetRateAlertsByUserID(ctx context.Context, userID int) ([]models.RateAlert, error) {
+	query := `SELECT ` + rateAlertColumns + ` FROM rate_alerts WHERE user_id = $1 ORDER BY created_at`
+	return r.queryRateAlerts(ctx, query, userID)
+}
+
+func (r *PostgresRepository) GetActiveRateAlerts(ctx context.Context) ([]models.RateAlert, error) {
+	query := `SELECT ` + rateAlertColumns + ` FROM rate_alerts WHERE is_active ORDER BY id`
+	return r.queryRateAlerts(ctx, query)
+}
+
+func (r *PostgresRepository) GetRateAlertByID(ctx context.Context, id int) (*models.RateAlert, error) {
+	query := `SELECT ` + rateAlertColumns + ` FROM rate_alerts WHERE id = $1`
+
+	alert, err := scanRateAlert(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return alert, err
+}
+
+func (r *PostgresRepository) UpdateRateAlert(ctx context.Context, alert *models.RateAlert) error {
+	query := `
+		UPDATE rate_alerts
+		SET base_currency_id = $1, target_currency_id = $2, condition = $3, threshold = $4,
+			webhook_url = NULLIF($5, ''), is_active = $6, last_rate = $7, updated_at = $8
+		WHERE id = $9
+		RETURNING updated_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		alert.BaseCurrencyID,
+		alert.TargetCurrencyID,
+		alert.Condition,
+		alert.Threshold,
+		alert.WebhookURL,
+		alert.IsActive,
+		alert.LastRate,
+		time.Now(),
+		alert.ID,
+	).Scan(&alert.UpdatedAt)
+}
+
+// UpdateRateAlertState сохраняет результат проверки правила: последний курс и время срабатывания
+func (r *PostgresRepository) UpdateRateAlertState(ctx context.Context, id int, lastRate money.Decimal, triggeredAt *time.Time) error {
+	query := `
+		UPDATE rate_alerts
+		SET last_rate = $1, last_triggered_at = COALESCE($2, last_triggered_at)
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(ctx, query, lastRate, triggeredAt, id)
+	return err
+}
+
+func (r *PostgresRepository) DeleteRateAlert(ctx context.Context, id int) error {
+	query := `DELETE FROM rate_alerts WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+// Notification methods
+func (r *PostgresRepository) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, kind, title, message, rate_alert_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		notification.UserID,
+		notification.Kind,
+		notification.Title,
+		notification.Message,
+		notification.RateAlertID,
+		time.Now(),
+	).Scan(&notification.ID, &notification.CreatedAt)
+}
+
+// GetNotificationsByUserID возвращает последние уведомления пользователя, новые первыми;
+// limit 0 — все уведомления
+func (r *PostgresRepository) GetNotificationsByUserID(ctx context.Context, userID int, unreadOnly bool, limit int) ([]models.Notification, error) {
+	query := `
+		SELECT id, user_id, kind, title, message, rate_alert_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT NULLIF($3, 0)
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var notification models.Notification
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Kind,
+			&notification.Title,
+			&notification.Message,
+			&notification.RateAlertID,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkNotificationRead отмечает уведомление прочитанным; false — уведомление не найдено у пользователя
+func (r *PostgresRepository) MarkNotificationRead(ctx context.Context, userID, id int) (bool, error) {
+	query := `
+		UPDATE notifications SET read_at = COALESCE(read_at, $1)
+		WHERE id = $2 AND user_id = $3
+	`
+	tag, err := r.db.Exec(ctx, query, time.Now(), id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PostgresRepository) MarkAllNotificationsRead(ctx context.Context, userID int) error {
+	query := `UPDATE notifications SET read_at = $1 WHERE user_id = $2 AND read_at IS NULL`
+	_, err := r.db.Exec(ctx, query, time.Now(), userID)
+	return err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"personal-finance-tracker/internal/models"
+	"time"
+)
+
+// Audit log methods
+func (r *PostgresRepository) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (user_id, actor_id, api_key_id, action, entity_type, entity_id,
+			before_data, after_data, ip_address, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), $11)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		entry.UserID,
+		entry.ActorID,
+		entry.APIKeyID,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		nullableJSON(entry.Before),
+		nullableJSON(entry.After),
+		entry.IPAddress,
+		entry.RequestID,
+		time.Now(),
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// GetAuditEntries возвращает записи журнала по фильтру, новые первыми; Limit 0 — все записи
+func (r *PostgresRepository) GetAuditEntries(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	query := `
+		SELECT id, user_id, actor_id, api_key_id, action, entity_type, entity_id,
+			before_data, after_data, COALESCE(ip_address, ''), COALESCE(request_id, ''), created_at
+		FROM audit_log
+		WHERE ($1::int IS NULL OR user_id = $1)
+			AND ($2 = '' OR entity_type = $2)
+			AND ($3::int IS NULL OR entity_id = $3)
+			AND ($4 = '' OR action = $4)
+			AND ($5 = '' OR request_id = $5)
+			AND ($6::timestamptz IS NULL OR created_at >= $6)
+			AND ($7::timestamptz IS NULL OR created_at < $7)
+			AND ($8 = 0 OR id < $8)
+		ORDER BY id DESC
+		LIMIT NULLIF($9, 0)
+	`
+
+	rows, err := r.db.Query(ctx, query,
+		filter.UserID,
+		filter.EntityType,
+		filter.EntityID,
+		filter.Action,
+		filter.RequestID,
+		filter.From,
+		filter.To,
+		filter.BeforeID,
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.ActorID,
+			&entry.APIKeyID,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&before,
+			&after,
+			&entry.IPAddress,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.Before = before
+		entry.After = after
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// nullableJSON превращает пустой JSON в NULL
+func nullableJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Savings goal methods
+func (r *PostgresRepository) CreateSavingsGoal(ctx context.Context, goal *models.SavingsGoal) error {
+	query := `
+		INSERT INTO savings_goals (user_id, name, target_amount, currency_id, deadline, account_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		goal.UserID,
+		goal.Name,
+		goal.TargetAmount,
+		goal.CurrencyID,
+		goal.Deadline,
+		goal.AccountID,
+		time.Now(),
+		time.Now(),
+	).Scan(&goal.ID, &goal.CreatedAt, &goal.UpdatedAt)
+}
+
+func (r *PostgresRepository) GetSavingsGoalsByUserID(ctx context.Context, userID int) ([]models.SavingsGoal, error) {
+	query := `
+		SELECT id, user_id, name, target_amount, currency_id, deadline, account_id, created_at, updated_at
+		FROM savings_goals
+		WHERE user_id = $1
+		ORDER BY deadline NULLS LAST, created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []models.SavingsGoal
+	for rows.Next() {
+		var goal models.SavingsGoal
+		err := rows.Scan(
+			&goal.ID,
+			&goal.UserID,
+			&goal.Name,
+			&goal.TargetAmount,
+			&goal.CurrencyID,
+			&goal.Deadline,
+			&goal.AccountID,
+			&goal.CreatedAt,
+			&goal.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, goal)
+	}
+
+	return goals, nil
+}
+
+func (r *PostgresRepository) GetSavingsGoalByID(ctx context.Context, id int) (*models.SavingsGoal, error) {
+	query := `
+		SELECT id, user_id, name, target_amount, currency_id, deadline, account_id, created_at, updated_at
+		FROM savings_goals WHERE id = $1
+	`
+
+	var goal models.SavingsGoal
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&goal.ID,
+		&goal.UserID,
+		&goal.Name,
+		&goal.TargetAmount,
+		&goal.CurrencyID,
+		&goal.Deadline,
+		&goal.AccountID,
+		&goal.CreatedAt,
+		&goal.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &goal, nil
+}
+
+func (r *PostgresRepository) UpdateSavingsGoal(ctx context.Context, goal *models.SavingsGoal) error {
+	query := `
+		UPDATE savings_goals
+		SET name = $1, target_amount = $2, currency_id = $3, deadline = $4, account_id = $5, updated_at = $6
+		WHERE id = $7
+		RETURNING updated_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		goal.Name,
+		goal.TargetAmount,
+		goal.CurrencyID,
+		goal.Deadline,
+		goal.AccountID,
+		time.Now(),
+		goal.ID,
+	).Scan(&goal.UpdatedAt)
+}
+
+func (r *PostgresRepository) DeleteSavingsGoal(ctx context.Context, id int) error {
+	query := `DELETE FROM savings_goals WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+func (r *PostgresRepository) CreateGoalContribution(ctx context.Context, contribution *models.GoalContribution) error {
+	query := `
+		INSERT INTO goal_contributions (goal_id, amount, date, note, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		contribution.GoalID,
+		contribution.Amount,
+		contribution.Date,
+		contribution.Note,
+		time.Now(),
+	).Scan(&contribution.ID, &contribution.CreatedAt)
+}
+
+func (r *PostgresRepository) GetGoalContributions(ctx context.Context, goalID int) ([]models.GoalContribution, error) {
+	query := `
+		SELECT id, goal_id, amount, date, COALESCE(note, ''), created_at
+		FROM goal_contributions
+		WHERE goal_id = $1
+		ORDER BY date DESC, created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contributions []models.GoalContribution
+	for rows.Next() {
+		var contribution models.GoalContribution
+		err := rows.Scan(
+			&contribution.ID,
+			&contribution.GoalID,
+			&contribution.Amount,
+			&contribution.Date,
+			&contribution.Note,
+			&contribution.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		contributions = append(contributions, contribution)
+	}
+
+	return contributions, nil
+}
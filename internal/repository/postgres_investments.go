@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Investment methods
+func (r *PostgresRepository) CreateSecurity(ctx context.Context, security *models.Security) error {
+	query := `
+		INSERT INTO securities (ticker, name, asset_class, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		security.Ticker,
+		security.Name,
+		security.AssetClass,
+		time.Now(),
+	).Scan(&security.ID, &security.CreatedAt)
+}
+
+func (r *PostgresRepository) GetSecurityByTicker(ctx context.Context, ticker string) (*models.Security, error) {
+	query := `SELECT id, ticker, name, asset_class, created_at FROM securities WHERE ticker = $1`
+
+	var security models.Security
+	err := r.db.QueryRow(ctx, query, ticker).Scan(
+		&security.ID,
+		&security.Ticker,
+		&security.Name,
+		&security.AssetClass,
+		&security.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &security, nil
+}
+
+func (r *PostgresRepository) GetAllSecurities(ctx context.Context) ([]models.Security, error) {
+	query := `SELECT id, ticker, name, asset_class, created_at FROM securities ORDER BY ticker`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var securities []models.Security
+	for rows.Next() {
+		var security models.Security
+		err := rows.Scan(
+			&security.ID,
+			&security.Ticker,
+			&security.Name,
+			&security.AssetClass,
+			&security.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		securities = append(securities, security)
+	}
+
+	return securities, nil
+}
+
+func (r *PostgresRepository) CreateInvestmentTransaction(ctx context.Context, transaction *models.InvestmentTransaction) error {
+	query := `
+		INSERT INTO investment_transactions (user_id, account_id, security_id, type, quantity, price, fee, amount, date, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		transaction.UserID,
+		transaction.AccountID,
+		transaction.SecurityID,
+		transaction.Type,
+		transaction.Quantity,
+		transaction.Price,
+		transaction.Fee,
+		transaction.Amount,
+		transaction.Date,
+		transaction.Description,
+		time.Now(),
+	).Scan(&transaction.ID, &transaction.CreatedAt)
+}
+
+// GetInvestmentTransactionsByAccountID возвращает операции в хронологическом порядке (нужно для FIFO)
+func (r *PostgresRepository) GetInvestmentTransactionsByAccountID(ctx context.Context, accountID int) ([]models.InvestmentTransaction, error) {
+	query := `
+		SELECT it.id, it.user_id, it.account_id, it.security_id, it.type, it.quantity, it.price, it.fee, it.amount,
+		       it.date, COALESCE(it.description, ''), it.created_at,
+		       s.id, s.ticker, s.name, s.asset_class, s.created_at
+		FROM investment_transactions it
+		JOIN securities s ON it.security_id = s.id
+		WHERE it.account_id = $1
+		ORDER BY it.date, it.id
+	`
+
+	rows, err := r.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.InvestmentTransaction
+	for rows.Next() {
+		var transaction models.InvestmentTransaction
+		var security models.Security
+		err := rows.Scan(
+			&transaction.ID,
+			&transaction.UserID,
+			&transaction.AccountID,
+			&transaction.SecurityID,
+			&transaction.Type,
+			&transaction.Quantity,
+			&transaction.Price,
+			&transaction.Fee,
+			&transaction.Amount,
+			&transaction.Date,
+			&transaction.Description,
+			&transaction.CreatedAt,
+			&security.ID,
+			&security.Ticker,
+			&security.Name,
+			&security.AssetClass,
+			&security.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transaction.Security = &security
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+func (r *PostgresRepository) UpsertSecurityPrice(ctx context.Context, price *models.SecurityPrice) error {
+	query := `
+		INSERT INTO security_prices (security_id, date, price)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (security_id, date)
+		DO UPDATE SET price = EXCLUDED.price
+	`
+
+	_, err := r.db.Exec(ctx, query, price.SecurityID, price.Date, price.Price)
+	return err
+}
+
+// GetSecurityPriceAt возвращает последнюю котировку на дату или ранее
+func (r *PostgresRepository) GetSecurityPriceAt(ctx context.Context, securityID int, date time.Time) (*models.SecurityPrice, error) {
+	query := `
+		SELECT sp.security_id, s.ticker, sp.date, sp.price
+		FROM security_prices sp
+		JOIN securities s ON sp.security_id = s.id
+		WHERE sp.security_id = $1 AND sp.date <= $2
+		ORDER BY sp.date DESC
+		LIMIT 1
+	`
+
+	var price models.SecurityPrice
+	err := r.db.QueryRow(ctx, query, securityID, date).Scan(
+		&price.SecurityID,
+		&price.Ticker,
+		&price.Date,
+		&price.Price,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &price, nil
+}
+
+func (r *PostgresRepository) GetSecurityPrices(ctx context.Context, securityID int, start, end time.Time) ([]models.SecurityPrice, error) {
+	query := `
+		SELECT sp.security_id, s.ticker, sp.date, sp.price
+		FROM security_prices sp
+		JOIN securities s ON sp.security_id = s.id
+		WHERE sp.security_id = $1 AND sp.date >= $2 AND sp.date <= $3
+		ORDER BY sp.date
+	`
+
+	rows, err := r.db.Query(ctx, query, securityID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []models.SecurityPrice
+	for rows.Next() {
+		var price models.SecurityPrice
+		err := rows.Scan(
+			&price.SecurityID,
+			&price.Ticker,
+			&price.Date,
+			&price.Price,
+		)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+
+	return prices, nil
+}
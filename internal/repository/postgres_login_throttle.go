@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Login throttle methods
+
+// RecordLoginAttempt атомарно учитывает попытку по ключу. Если ключ заблокирован, попытка не
+// учитывается и возвращается срок блокировки. Иначе счетчик увеличивается (если последняя попытка
+// была раньше resetBefore — начинается заново), и ключ сразу блокируется на delay(счетчик).
+// Строка остается заблокированной до конца транзакции, поэтому одновременные попытки
+// по тому же ключу видят уже обновленный срок.
+func (r *PostgresRepository) RecordLoginAttempt(ctx context.Context, key string, at, resetBefore time.Time, delay func(attempts int) time.Duration) (*time.Time, error) {
+	query := `
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.blocked_until > $2 THEN login_throttles.failures
+				WHEN login_throttles.last_failure_at < $3 THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = CASE
+				WHEN login_throttles.blocked_until > $2 THEN login_throttles.last_failure_at
+				ELSE EXCLUDED.last_failure_at
+			END
+		RETURNING failures, blocked_until
+	`
+
+	var blockedUntil *time.Time
+	err := r.withTx(ctx, func(tx *PostgresRepository) error {
+		var attempts int
+		var current *time.Time
+		if err := tx.db.QueryRow(ctx, query, key, at, resetBefore).Scan(&attempts, &current); err != nil {
+			return err
+		}
+		if current != nil && current.After(at) {
+			blockedUntil = current
+			return nil
+		}
+
+		if d := delay(attempts); d > 0 {
+			_, err := tx.db.Exec(ctx, `UPDATE login_throttles SET blocked_until = $1 WHERE key = $2`, at.Add(d), key)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blockedUntil, nil
+}
+
+// ReleaseLoginAttempt отменяет учтенную попытку, оказавшуюся успешной, и снимает блокировку,
+// установленную ею
+func (r *PostgresRepository) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	query := `
+		UPDATE login_throttles SET failures = GREATEST(failures - 1, 0), blocked_until = NULL
+		WHERE key = $1
+	`
+	_, err := r.db.Exec(ctx, query, key)
+	return err
+}
+
+func (r *PostgresRepository) ResetLoginThrottles(ctx context.Context, keys []string) error {
+	query := `DELETE FROM login_throttles WHERE key = ANY($1)`
+	_, err := r.db.Exec(ctx, query, keys)
+	return err
+}
+
+// PurgeLoginThrottles удаляет счетчики без неудач после failedBefore и без действующей блокировки
+func (r *PostgresRepository) PurgeLoginThrottles(ctx context.Context, failedBefore, now time.Time) (int64, error) {
+	query := `
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (blocked_until IS NULL OR blocked_until < $2)
+	`
+	tag, err := r.db.Exec(ctx, query, failedBefore, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const userIdentityColumns = `id, user_id, issuer, subject, COALESCE(email, ''), created_at, last_login_at`
+
+func scanUserIdentity(row rowScanner) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := row.Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Issuer,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+		&identity.LastLoginAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// UsernameExists сообщает, занято ли имя пользователя
+func (r *PostgresRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
+	return exists, err
+}
+
+// User identity methods
+func (r *PostgresRepository) CreateUserIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, issuer, subject, email, created_at, last_login_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		identity.UserID,
+		identity.Issuer,
+		identity.Subject,
+		identity.Email,
+		time.Now(),
+		identity.LastLoginAt,
+	).Scan(&identity.ID, &identity.CreatedAt)
+}
+
+func (r *PostgresRepository) GetUserIdentity(ctx context.Context, issuer, subject string) (*models.UserIdentity, error) {
+	query := `SELECT ` + userIdentityColumns + ` FROM user_identities WHERE issuer = $1 AND subject = $2`
+
+	identity, err := scanUserIdentity(r.db.QueryRow(ctx, query, issuer, subject))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (r *PostgresRepository) GetUserIdentitiesByUserID(ctx context.Context, userID int) ([]models.UserIdentity, error) {
+	query := `SELECT ` + userIdentityColumns + ` FROM user_identities WHERE user_id = $1 ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []models.UserIdentity
+	for rows.Next() {
+		identity, err := scanUserIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, *identity)
+	}
+
+	return identities, rows.Err()
+}
+
+// TouchUserIdentity запоминает время входа и email, полученный от провайдера
+func (r *PostgresRepository) TouchUserIdentity(ctx context.Context, id int, email string, loginAt time.Time) error {
+	query := `UPDATE user_identities SET email = NULLIF($1, ''), last_login_at = $2 WHERE id = $3`
+	_, err := r.db.Exec(ctx, query, email, loginAt, id)
+	return err
+}
+
+// OIDC auth request methods
+func (r *PostgresRepository) CreateOIDCAuthRequest(ctx context.Context, req *models.OIDCAuthRequest) error {
+	query := `
+		INSERT INTO oidc_auth_requests (state_hash, code_verifier, nonce, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		req.StateHash,
+		req.CodeVerifier,
+		req.Nonce,
+		req.ExpiresAt,
+		time.Now(),
+	).Scan(&req.ID, &req.CreatedAt)
+}
+
+// ConsumeOIDCAuthRequest удаляет начатый вход и возвращает его; nil — state не найден
+// или истек. Один и тот же state можно использовать только один раз.
+func (r *PostgresRepository) ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (*models.OIDCAuthRequest, error) {
+	query := `
+		DELETE FROM oidc_auth_requests
+		WHERE state_hash = $1 AND expires_at > $2
+		RETURNING id, state_hash, code_verifier, nonce, expires_at, created_at
+	`
+
+	var req models.OIDCAuthRequest
+	err := r.db.QueryRow(ctx, query, stateHash, time.Now()).Scan(
+		&req.ID,
+		&req.StateHash,
+		&req.CodeVerifier,
+		&req.Nonce,
+		&req.ExpiresAt,
+		&req.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// DeleteExpiredOIDCAuthRequests удаляет входы, не завершенные вовремя
+func (r *PostgresRepository) DeleteExpiredOIDCAuthRequests(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM oidc_auth_requests WHERE expires_at <= $1`, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Password reset token methods
+func (r *PostgresRepository) CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		time.Now(),
+	).Scan(&token.ID, &token.CreatedAt)
+}
+
+// ConsumePasswordResetToken отмечает токен использованным и возвращает его; nil — токен
+// не найден, истек или уже использован. Повторно использовать токен нельзя даже параллельно.
+func (r *PostgresRepository) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	query := `
+		UPDATE password_reset_tokens SET used_at = $1
+		WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1
+		RETURNING id, user_id, token_hash, expires_at, used_at, created_at
+	`
+
+	var token models.PasswordResetToken
+	err := r.db.QueryRow(ctx, query, time.Now(), tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// InvalidatePasswordResetTokens гасит неиспользованные токены пользователя
+func (r *PostgresRepository) InvalidatePasswordResetTokens(ctx context.Context, userID int) error {
+	query := `UPDATE password_reset_tokens SET used_at = $1 WHERE user_id = $2 AND used_at IS NULL`
+	_, err := r.db.Exec(ctx, query, time.Now(), userID)
+	return err
+}
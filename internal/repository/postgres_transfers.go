@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"personal-finance-tracker/internal/models"
+	"time"
+)
+
+// Transfer methods
+func (r *PostgresRepository) CreateTransfer(ctx context.Context, transfer *models.Transfer) error {
+	query := `
+		INSERT INTO transfers (user_id, from_account_id, to_account_id, from_amount, to_amount, rate, rate_source, date, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(
+		ctx,
+		query,
+		transfer.UserID,
+		transfer.FromAccountID,
+		transfer.ToAccountID,
+		transfer.FromAmount,
+		transfer.ToAmount,
+		transfer.Rate,
+		transfer.RateSource,
+		transfer.Date,
+		transfer.Description,
+		time.Now(),
+	).Scan(&transfer.ID, &transfer.CreatedAt)
+}
+
+func (r *PostgresRepository) GetTransfersByUserID(ctx context.Context, userID int) ([]models.Transfer, error) {
+	query := `
+		SELECT id, user_id, from_account_id, to_account_id, from_amount, to_amount, rate, rate_source,
+			date, COALESCE(description, ''), created_at
+		FROM transfers
+		WHERE user_id = $1
+		ORDER BY date DESC, created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []models.Transfer
+	for rows.Next() {
+		var transfer models.Transfer
+		err := rows.Scan(
+			&transfer.ID,
+			&transfer.UserID,
+			&transfer.FromAccountID,
+			&transfer.ToAccountID,
+			&transfer.FromAmount,
+			&transfer.ToAmount,
+			&transfer.Rate,
+			&transfer.RateSource,
+			&transfer.Date,
+			&transfer.Description,
+			&transfer.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, rows.Err()
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Two-factor methods
+func (r *PostgresRepository) GetTOTPState(ctx context.Context, userID int) (*models.TOTPState, error) {
+	query := `
+		SELECT totp_secret, totp_enabled_at, totp_last_step
+		FROM users WHERE id = $1 AND totp_secret IS NOT NULL
+	`
+
+	var state models.TOTPState
+	err := r.db.QueryRow(ctx, query, userID).Scan(&state.Secret, &state.EnabledAt, &state.LastStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// SetPendingTOTPSecret сохраняет новый секрет, который еще нужно подтвердить кодом
+func (r *PostgresRepository) SetPendingTOTPSecret(ctx context.Context, userID int, secret string) error {
+	query := `
+		UPDATE users SET totp_secret = $1, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = $2
+		WHERE id = $3
+	`
+	_, err := r.db.Exec(ctx, query, secret, time.Now(), userID)
+	return err
+}
+
+func (r *PostgresRepository) EnableTOTP(ctx context.Context, userID int, enabledAt time.Time) error {
+	query := `UPDATE users SET totp_enabled_at = $1, updated_at = $1 WHERE id = $2 AND totp_secret IS NOT NULL`
+	_, err := r.db.Exec(ctx, query, enabledAt, userID)
+	return err
+}
+
+// DisableTOTP удаляет секрет и коды восстановления
+func (r *PostgresRepository) DisableTOTP(ctx context.Context, userID int) error {
+	query := `
+		WITH codes AS (DELETE FROM two_factor_recovery_codes WHERE user_id = $2)
+		UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = $1
+		WHERE id = $2
+	`
+	_, err := r.db.Exec(ctx, query, time.Now(), userID)
+	return err
+}
+
+// ClaimTOTPStep запоминает принятый интервал TOTP; false — код этого или более позднего
+// интервала уже использован
+func (r *PostgresRepository) ClaimTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `
+		UPDATE users SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+	`
+	tag, err := r.db.Exec(ctx, query, step, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя новыми
+func (r *PostgresRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	query := `
+		WITH old AS (DELETE FROM two_factor_recovery_codes WHERE user_id = $1)
+		INSERT INTO two_factor_recovery_codes (user_id, code_hash, created_at)
+		SELECT $1, hash, $3 FROM unnest($2::text[]) AS hash
+	`
+	_, err := r.db.Exec(ctx, query, userID, codeHashes, time.Now())
+	return err
+}
+
+// UseRecoveryCode гасит код восстановления; false — кода нет или он уже использован
+func (r *PostgresRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `
+		UPDATE two_factor_recovery_codes SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, query, time.Now(), userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *PostgresRepository) CountUnusedRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM two_factor_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	var count int
+	err := r.db.QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
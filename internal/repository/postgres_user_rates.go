@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const userExchangeRateColumns = `
+	id, user_id, base_currency_id, target_currency_id, rate, rate_date, COALESCE(note, ''), created_at, updated_at`
+
+func scanUserExchangeRate(row rowScanner) (*models.UserExchangeRate, error) {
+	var rate models.UserExchangeRate
+	err := row.Scan(
+		&rate.ID,
+		&rate.UserID,
+		&rate.BaseCurrencyID,
+		&rate.TargetCurrencyID,
+		&rate.Rate,
+		&rate.RateDate,
+		&rate.Note,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// User exchange rate methods
+
+// UpsertUserExchangeRate сохраняет личный курс; курс на ту же пару и дату заменяется.
+// Возвращает замененный курс или nil, если курса на эту дату не было.
+func (r *PostgresRepository) UpsertUserExchangeRate(ctx context.Context, rate *models.UserExchangeRate) (*models.UserExchangeRate, error) {
+	query := `
+		WITH previous AS (
+			SELECT rate, COALESCE(note, '') AS note, updated_at FROM user_exchange_rates
+			WHERE user_id = $1 AND base_currency_id = $2 AND target_currency_id = $3 AND rate_date = $5
+		)
+		INSERT INTO user_exchange_rates (user_id, base_currency_id, target_currency_id, rate, rate_date, note, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $7)
+		ON CONFLICT (user_id, base_currency_id, target_currency_id, rate_date)
+		DO UPDATE SET rate = EXCLUDED.rate, note = EXCLUDED.note, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at, updated_at,
+		          (SELECT rate FROM previous), (SELECT note FROM previous), (SELECT updated_at FROM previous)
+	`
+
+	var previousRate *money.Decimal
+	var previousNote *string
+	var previousUpdatedAt *time.Time
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		rate.UserID,
+		rate.BaseCurrencyID,
+		rate.TargetCurrencyID,
+		rate.Rate,
+		rate.RateDate,
+		rate.Note,
+		time.Now(),
+	).Scan(&rate.ID, &rate.CreatedAt, &rate.UpdatedAt, &previousRate, &previousNote, &previousUpdatedAt)
+	if err != nil || previousRate == nil {
+		return nil, err
+	}
+
+	// Замененная запись сохраняет id и дату создания
+	previous := *rate
+	previous.Rate = *previousRate
+	previous.Note = *previousNote
+	previous.UpdatedAt = *previousUpdatedAt
+	return &previous, nil
+}
+
+func (r *PostgresRepository) GetUserExchangeRates(ctx context.Context, userID int) ([]models.UserExchangeRate, error) {
+	query := `
+		SELECT ` + userExchangeRateColumns + `
+		FROM user_exchange_rates
+		WHERE user_id = $1
+		ORDER BY rate_date DESC, base_currency_id, target_currency_id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.UserExchangeRate
+	for rows.Next() {
+		rate, err := scanUserExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (r *PostgresRepository) GetUserExchangeRateByID(ctx context.Context, id int) (*models.UserExchangeRate, error) {
+	query := `SELECT ` + userExchangeRateColumns + ` FROM user_exchange_rates WHERE id = $1`
+
+	rate, err := scanUserExchangeRate(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return rate, err
+}
+
+// GetLatestUserExchangeRate возвращает последний личный курс пары на дату asOf — прямой
+// или обратный (base и target в результате надо сверить с запрошенными)
+func (r *PostgresRepository) GetLatestUserExchangeRate(ctx context.Context, userID, baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.UserExchangeRate, error) {
+	query := `
+		SELECT ` + userExchangeRateColumns + `
+		FROM user_exchange_rates
+		WHERE user_id = $1
+			AND ((base_currency_id = $2 AND target_currency_id = $3) OR (base_currency_id = $3 AND target_currency_id = $2))
+			AND rate_date <= $4::date
+		ORDER BY rate_date DESC, (base_currency_id = $2) DESC
+		LIMIT 1
+	`
+
+	rate, err := scanUserExchangeRate(r.db.QueryRow(ctx, query, userID, baseCurrencyID, targetCurrencyID, asOf))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return rate, err
+}
+
+func (r *PostgresRepository) DeleteUserExchangeRate(ctx context.Context, id int) error {
+	query := `DELETE FROM user_exchange_rates WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
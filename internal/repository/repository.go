@@ -3,41 +3,70 @@ package repository
 import (
 	"context"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
 	"time"
 )
 
 type Repository interface {
+	// WithTx выполняет fn в одной транзакции; репозиторий tx действует только внутри fn
+	WithTx(ctx context.Context, fn func(tx Repository) error) error
+
 	// User methods
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	UpdateUserPassword(ctx context.Context, userID int, password string) error
 	UpdateUser(user *models.User) error
 	SetUserDefaultCurrency(userID, currencyID int) error
+	SetUserRole(ctx context.Context, userID int, role string) error
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	SetUserVerified(ctx context.Context, userID int, verifiedAt time.Time) error
+	ClaimVerificationSend(ctx context.Context, userID int, sentAt, sentBefore time.Time) (bool, error)
+	CountUsersByRole(ctx context.Context, role string) (int, error)
+	ScheduleUserDeletion(ctx context.Context, userID int, at time.Time) error
+	CancelUserDeletion(ctx context.Context, userID int) error
+	DeleteScheduledUsers(ctx context.Context, now time.Time) (int64, error)
+	UsernameExists(ctx context.Context, username string) (bool, error)
 
 	// Currency methods
 	CreateCurrency(currency *models.Currency) error
 	GetAllCurrencies() ([]models.Currency, error)
 	GetCurrencyByID(ctx context.Context, id int) (*models.Currency, error)
 	GetCurrencyByCode(code string) (*models.Currency, error)
+	UpdateCurrency(ctx context.Context, currency *models.Currency) error
+	GetUserCurrencies(ctx context.Context, userID int) ([]models.Currency, error)
+	SetUserCurrencies(ctx context.Context, userID int, currencyIDs []int) error
 
 	// Account methods
 	CreateAccount(account *models.Account) error
 	GetAccountsByUserID(ctx context.Context, userID int) ([]models.Account, error)
 	GetAccountByID(ctx context.Context, id int) (*models.Account, error)
+	GetAccountByIDForUpdate(ctx context.Context, id int) (*models.Account, error)
 	GetDefaultAccount(ctx context.Context, userID int) (*models.Account, error)
-	UpdateAccountBalance(accountID int, newBalance float64) error
+	UpdateAccountBalance(accountID int, newBalance money.Decimal) error
 	SetDefaultAccount(userID, accountID int) error
 
 	// Exchange Rate methods
-	CreateOrUpdateExchangeRate(rate *models.ExchangeRate) error
-	GetExchangeRate(baseCurrencyID, targetCurrencyID int) (*models.ExchangeRate, error)
-	GetAllExchangeRates() ([]models.ExchangeRate, error)
+	CreateOrUpdateExchangeRate(rate *models.ExchangeRate) (*money.Decimal, error)
+	GetExchangeRate(baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.ExchangeRate, error)
+	GetAllExchangeRates(asOf time.Time) ([]models.ExchangeRate, error)
 	GetExchangeRatesByBaseCurrency(baseCurrencyID int) ([]models.ExchangeRate, error)
+	GetExchangeRateHistory(baseCurrencyID, targetCurrencyID int, start, end time.Time) ([]models.ExchangeRate, error)
+
+	// User exchange rate methods
+	UpsertUserExchangeRate(ctx context.Context, rate *models.UserExchangeRate) (*models.UserExchangeRate, error)
+	GetUserExchangeRates(ctx context.Context, userID int) ([]models.UserExchangeRate, error)
+	GetUserExchangeRateByID(ctx context.Context, id int) (*models.UserExchangeRate, error)
+	GetLatestUserExchangeRate(ctx context.Context, userID, baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.UserExchangeRate, error)
+	DeleteUserExchangeRate(ctx context.Context, id int) error
 
 	// Category methods
 	CreateCategory(ctx context.Context, category *models.Category) error
 	GetCategoriesByUserID(ctx context.Context, userID int) ([]models.Category, error)
 	GetCategoryByID(ctx context.Context, id int) (*models.Category, error)
+	GetGlobalCategories(ctx context.Context) ([]models.Category, error)
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	DeleteCategory(ctx context.Context, id int) error
 
 	// Transaction methods
 	CreateTransaction(ctx context.Context, transaction *models.Transaction) error
@@ -48,8 +77,97 @@ type Repository interface {
 	GetTransactionsByAccountIDAndPeriod(ctx context.Context, accountID int, start, end time.Time) ([]models.Transaction, error)
 	GetTransactionSummaryByAccountID(ctx context.Context, accountID int, start, end time.Time) (*models.TransactionSummary, error)
 
+	// Transfer methods
+	CreateTransfer(ctx context.Context, transfer *models.Transfer) error
+	GetTransfersByUserID(ctx context.Context, userID int) ([]models.Transfer, error)
+
+	// Two-factor methods
+	GetTOTPState(ctx context.Context, userID int) (*models.TOTPState, error)
+	SetPendingTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableTOTP(ctx context.Context, userID int, enabledAt time.Time) error
+	DisableTOTP(ctx context.Context, userID int) error
+	ClaimTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID int) (int, error)
+
+	// API key methods
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeysByUserID(ctx context.Context, userID int) ([]models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	DeleteUserAPIKey(ctx context.Context, userID, id int) (bool, error)
+	TouchAPIKey(ctx context.Context, id int, usedAt, staleBefore time.Time) error
+
+	// User identity methods
+	CreateUserIdentity(ctx context.Context, identity *models.UserIdentity) error
+	GetUserIdentity(ctx context.Context, issuer, subject string) (*models.UserIdentity, error)
+	GetUserIdentitiesByUserID(ctx context.Context, userID int) ([]models.UserIdentity, error)
+	TouchUserIdentity(ctx context.Context, id int, email string, loginAt time.Time) error
+
+	// OIDC auth request methods
+	CreateOIDCAuthRequest(ctx context.Context, req *models.OIDCAuthRequest) error
+	ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (*models.OIDCAuthRequest, error)
+	DeleteExpiredOIDCAuthRequests(ctx context.Context) (int64, error)
+
+	// Audit log methods
+	CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEntry, error)
+
+	// Login throttle methods
+	RecordLoginAttempt(ctx context.Context, key string, at, resetBefore time.Time, delay func(attempts int) time.Duration) (*time.Time, error)
+	ReleaseLoginAttempt(ctx context.Context, key string) error
+	ResetLoginThrottles(ctx context.Context, keys []string) error
+	PurgeLoginThrottles(ctx context.Context, failedBefore, now time.Time) (int64, error)
+
+	// Password reset token methods
+	CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	InvalidatePasswordResetTokens(ctx context.Context, userID int) error
+
 	// Session methods
 	CreateSession(ctx context.Context, session *models.Session) error
-	GetSessionByToken(ctx context.Context, token string) (*models.Session, error)
-	DeleteSession(token string) error
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	RotateSession(ctx context.Context, id int) (bool, error)
+	IsSessionFamilyActive(ctx context.Context, familyID int) (bool, error)
+	RevokeSessionFamily(ctx context.Context, familyID int) error
+	TouchSession(ctx context.Context, familyID int, ipAddress string, seenAt, staleBefore time.Time) error
+	GetActiveSessionsByUserID(ctx context.Context, userID int) ([]models.SessionInfo, error)
+	GetSessionHistoryByUserID(ctx context.Context, userID int) ([]models.SessionInfo, error)
+	RevokeUserSession(ctx context.Context, userID, familyID int) (bool, error)
+	RevokeOtherUserSessions(ctx context.Context, userID, keepFamilyID int) error
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+
+	// Savings goal methods
+	CreateSavingsGoal(ctx context.Context, goal *models.SavingsGoal) error
+	GetSavingsGoalsByUserID(ctx context.Context, userID int) ([]models.SavingsGoal, error)
+	GetSavingsGoalByID(ctx context.Context, id int) (*models.SavingsGoal, error)
+	UpdateSavingsGoal(ctx context.Context, goal *models.SavingsGoal) error
+	DeleteSavingsGoal(ctx context.Context, id int) error
+	CreateGoalContribution(ctx context.Context, contribution *models.GoalContribution) error
+	GetGoalContributions(ctx context.Context, goalID int) ([]models.GoalContribution, error)
+
+	// Investment methods
+	CreateSecurity(ctx context.Context, security *models.Security) error
+	GetSecurityByTicker(ctx context.Context, ticker string) (*models.Security, error)
+	GetAllSecurities(ctx context.Context) ([]models.Security, error)
+	CreateInvestmentTransaction(ctx context.Context, transaction *models.InvestmentTransaction) error
+	GetInvestmentTransactionsByAccountID(ctx context.Context, accountID int) ([]models.InvestmentTransaction, error)
+	UpsertSecurityPrice(ctx context.Context, price *models.SecurityPrice) error
+	GetSecurityPriceAt(ctx context.Context, securityID int, date time.Time) (*models.SecurityPrice, error)
+	GetSecurityPrices(ctx context.Context, securityID int, start, end time.Time) ([]models.SecurityPrice, error)
+
+	// Rate alert methods
+	CreateRateAlert(ctx context.Context, alert *models.RateAlert) error
+	GetRateAlertsByUserID(ctx context.Context, userID int) ([]models.RateAlert, error)
+	GetActiveRateAlerts(ctx context.Context) ([]models.RateAlert, error)
+	GetRateAlertByID(ctx context.Context, id int) (*models.RateAlert, error)
+	UpdateRateAlert(ctx context.Context, alert *models.RateAlert) error
+	UpdateRateAlertState(ctx context.Context, id int, lastRate money.Decimal, triggeredAt *time.Time) error
+	DeleteRateAlert(ctx context.Context, id int) error
+
+	// Notification methods
+	CreateNotification(ctx context.Context, notification *models.Notification) error
+	GetNotificationsByUserID(ctx context.Context, userID int, unreadOnly bool, limit int) ([]models.Notification, error)
+	MarkNotificationRead(ctx context.Context, userID, id int) (bool, error)
+	MarkAllNotificationsRead(ctx context.Context, userID int) error
 }
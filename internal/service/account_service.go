@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
 	"personal-finance-tracker/internal/repository"
 )
 
@@ -12,16 +13,17 @@ type AccountService interface {
 	GetUserAccounts(ctx context.Context, userID int) ([]models.Account, error)
 	GetAccountByID(ctx context.Context, id int) (*models.Account, error)
 	GetDefaultAccount(ctx context.Context, userID int) (*models.Account, error)
-	UpdateAccountBalance(accountID int, amount float64, isIncome bool) error
+	UpdateAccountBalance(accountID int, amount money.Decimal, isIncome bool) error
 	SetDefaultAccount(ctx context.Context, userID, accountID int) error
 }
 
 type accountService struct {
-	repo repository.Repository
+	repo  repository.Repository
+	audit AuditService
 }
 
-func NewAccountService(repo repository.Repository) AccountService {
-	return &accountService{repo: repo}
+func NewAccountService(repo repository.Repository, audit AuditService) AccountService {
+	return &accountService{repo: repo, audit: audit}
 }
 
 func (s *accountService) CreateAccount(ctx context.Context, account *models.Account) error {
@@ -33,6 +35,12 @@ func (s *accountService) CreateAccount(ctx context.Context, account *models.Acco
 	if currency == nil {
 		return errors.New("currency not found")
 	}
+	if !currency.IsActive {
+		return errors.New("currency is disabled")
+	}
+	if err := checkPrecision(account.Balance, currency); err != nil {
+		return err
+	}
 
 	// Если это первый счет пользователя, устанавливаем его как дефолтный
 	existingAccounts, err := s.repo.GetAccountsByUserID(ctx, account.UserID)
@@ -40,17 +48,21 @@ func (s *accountService) CreateAccount(ctx context.Context, account *models.Acco
 		return err
 	}
 
-	if len(existingAccounts) == 0 {
-		account.IsDefault = true
-	} else if account.IsDefault {
-		// Если устанавливаем новый счет как дефолтный, сбрасываем дефолтный статус у других
-		err = s.repo.SetDefaultAccount(account.UserID, 0) // 0 означает сброс всех
-		if err != nil {
-			return err
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if len(existingAccounts) == 0 {
+			account.IsDefault = true
+		} else if account.IsDefault {
+			// Если устанавливаем новый счет как дефолтный, сбрасываем дефолтный статус у других
+			if err := tx.SetDefaultAccount(account.UserID, 0); err != nil { // 0 означает сброс всех
+				return err
+			}
 		}
-	}
 
-	return s.repo.CreateAccount(account)
+		if err := tx.CreateAccount(account); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, account.UserID, models.AuditCreate, AuditEntityAccount, account.ID, nil, account)
+	})
 }
 
 func (s *accountService) GetUserAccounts(ctx context.Context, userID int) ([]models.Account, error) {
@@ -78,10 +90,18 @@ func (s *accountService) SetDefaultAccount(ctx context.Context, userID, accountI
 		return errors.New("account does not belong to user")
 	}
 
-	return s.repo.SetDefaultAccount(userID, accountID)
+	after := *account
+	after.IsDefault = true
+
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.SetDefaultAccount(userID, accountID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditUpdate, AuditEntityAccount, accountID, account, after)
+	})
 }
 
-func (s *accountService) UpdateAccountBalance(accountID int, amount float64, isIncome bool) error {
+func (s *accountService) UpdateAccountBalance(accountID int, amount money.Decimal, isIncome bool) error {
 	account, err := s.repo.GetAccountByID(context.Background(), accountID)
 	if err != nil {
 		return err
@@ -90,11 +110,11 @@ func (s *accountService) UpdateAccountBalance(accountID int, amount float64, isI
 		return errors.New("account not found")
 	}
 
-	var newBalance float64
+	var newBalance money.Decimal
 	if isIncome {
-		newBalance = account.Balance + amount
+		newBalance = account.Balance.Add(amount)
 	} else {
-		newBalance = account.Balance - amount
+		newBalance = account.Balance.Sub(amount)
 	}
 
 	return s.repo.UpdateAccountBalance(accountID, newBalance)
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"personal-finance-tracker/internal/repository"
+	"time"
+)
+
+// Условия правил оповещения о курсе
+const (
+	AlertAbove  = "above"  // курс поднялся до порога или выше
+	AlertBelow  = "below"  // курс опустился до порога или ниже
+	AlertChange = "change" // курс изменился за день не меньше чем на порог, %
+)
+
+// Знаков после запятой у курса в тексте уведомления
+const alertRateDisplayPlaces = 6
+
+type AlertService interface {
+	CreateAlert(ctx context.Context, userID int, req *models.RateAlertRequest) (*models.RateAlert, error)
+	GetUserAlerts(ctx context.Context, userID int) ([]models.RateAlert, error)
+	UpdateAlert(ctx context.Context, userID, id int, req *models.RateAlertRequest) (*models.RateAlert, error)
+	DeleteAlert(ctx context.Context, userID, id int) error
+	// EvaluateAlerts проверяет активные правила по текущим курсам; вызывается после обновления курсов
+	EvaluateAlerts(ctx context.Context) error
+}
+
+type alertService struct {
+	repo                repository.Repository
+	exchangeService     ExchangeService
+	notificationService NotificationService
+	audit               AuditService
+}
+
+func NewAlertService(repo repository.Repository, exchangeService ExchangeService, notificationService NotificationService, audit AuditService) AlertService {
+	return &alertService{
+		repo:                repo,
+		exchangeService:     exchangeService,
+		notificationService: notificationService,
+		audit:               audit,
+	}
+}
+
+// Тело webhook-запроса при срабатывании правила
+type rateAlertPayload struct {
+	Event         string         `json:"event"`
+	AlertID       int            `json:"alert_id"`
+	Pair          string         `json:"pair"`
+	Condition     string         `json:"condition"`
+	Threshold     money.Decimal  `json:"threshold"`
+	Rate          money.Decimal  `json:"rate"`
+	PreviousRate  money.Decimal  `json:"previous_rate"`
+	ChangePercent *money.Decimal `json:"change_percent,omitempty"`
+	RateDate      string         `json:"rate_date"`
+	Message       string         `json:"message"`
+	TriggeredAt   time.Time      `json:"triggered_at"`
+}
+
+func (s *alertService) CreateAlert(ctx context.Context, userID int, req *models.RateAlertRequest) (*models.RateAlert, error) {
+	alert := &models.RateAlert{UserID: userID, IsActive: true}
+	if err := s.applyRequest(ctx, alert, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.CreateRateAlert(ctx, alert); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditCreate, AuditEntityRateAlert, alert.ID, nil, alert)
+	}); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+func (s *alertService) GetUserAlerts(ctx context.Context, userID int) ([]models.RateAlert, error) {
+	return s.repo.GetRateAlertsByUserID(ctx, userID)
+}
+
+func (s *alertService) UpdateAlert(ctx context.Context, userID, id int, req *models.RateAlertRequest) (*models.RateAlert, error) {
+	alert, err := s.getOwnedAlert(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *alert
+
+	if err := s.applyRequest(ctx, alert, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.UpdateRateAlert(ctx, alert); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditUpdate, AuditEntityRateAlert, alert.ID, before, alert)
+	}); err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+func (s *alertService) DeleteAlert(ctx context.Context, userID, id int) error {
+	alert, err := s.getOwnedAlert(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.DeleteRateAlert(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditDelete, AuditEntityRateAlert, id, alert, nil)
+	})
+}
+
+func (s *alertService) EvaluateAlerts(ctx context.Context) error {
+	alerts, err := s.repo.GetActiveRateAlerts(ctx)
+	if err != nil || len(alerts) == 0 {
+		return err
+	}
+
+	currencies, err := s.repo.GetAllCurrencies()
+	if err != nil {
+		return err
+	}
+	codes := make(map[int]string, len(currencies))
+	for _, currency := range currencies {
+		codes[currency.ID] = currency.Code
+	}
+
+	var errs []error
+	for i := range alerts {
+		if err := s.evaluateAlert(ctx, &alerts[i], codes); err != nil {
+			errs = append(errs, fmt.Errorf("alert %d: %w", alerts[i].ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// evaluateAlert проверяет правило и сохраняет курс, с которым сравнивается следующая проверка
+func (s *alertService) evaluateAlert(ctx context.Context, alert *models.RateAlert, codes map[int]string) error {
+	now := time.Now()
+	rate, err := s.exchangeService.GetExchangeRate(alert.BaseCurrencyID, alert.TargetCurrencyID, now)
+	if err != nil {
+		return nil // курса пары пока нет — правило проверится после следующего обновления
+	}
+
+	pair := codes[alert.BaseCurrencyID] + "/" + codes[alert.TargetCurrencyID]
+	payload := &rateAlertPayload{
+		Event:     NotificationRateAlert,
+		AlertID:   alert.ID,
+		Pair:      pair,
+		Condition: alert.Condition,
+		Threshold: alert.Threshold,
+		Rate:      rate.Rate,
+		RateDate:  rate.RateDate.Format("2006-01-02"),
+	}
+
+	triggered := false
+	switch alert.Condition {
+	case AlertAbove, AlertBelow:
+		// Срабатывает только при пересечении порога, а не при каждой проверке выше/ниже него
+		if alert.LastRate != nil && !thresholdReached(alert, *alert.LastRate) && thresholdReached(alert, rate.Rate) {
+			triggered = true
+			payload.PreviousRate = *alert.LastRate
+			direction := "поднялся"
+			if alert.Condition == AlertBelow {
+				direction = "опустился"
+			}
+			payload.Message = fmt.Sprintf("Курс %s %s до %s и пересек порог %s",
+				pair, direction, displayRate(rate.Rate), displayRate(alert.Threshold))
+		}
+	case AlertChange:
+		// Сравниваем с предыдущим доступным курсом; за одну дату курса правило срабатывает один раз
+		if alert.LastTriggeredAt != nil && !alert.LastTriggeredAt.Before(rate.RateDate) {
+			break
+		}
+		previous, err := s.exchangeService.GetExchangeRate(alert.BaseCurrencyID, alert.TargetCurrencyID, rate.RateDate.AddDate(0, 0, -1))
+		if err != nil || !previous.RateDate.Before(rate.RateDate) || !previous.Rate.IsPositive() {
+			break
+		}
+		change := rate.Rate.Sub(previous.Rate).Mul(money.NewFromInt(100)).Div(previous.Rate, 2, money.RoundHalfEven)
+		if change.Abs().Cmp(alert.Threshold) >= 0 {
+			triggered = true
+			payload.PreviousRate = previous.Rate
+			payload.ChangePercent = &change
+			sign := ""
+			if change.IsPositive() {
+				sign = "+"
+			}
+			payload.Message = fmt.Sprintf("Курс %s изменился на %s%s%% за день: %s → %s",
+				pair, sign, change.String(), displayRate(previous.Rate), displayRate(rate.Rate))
+		}
+	}
+
+	var triggeredAt *time.Time
+	if triggered {
+		payload.TriggeredAt = now
+		notification := &models.Notification{
+			UserID:      alert.UserID,
+			Kind:        NotificationRateAlert,
+			Title:       "Курс " + pair,
+			Message:     payload.Message,
+			RateAlertID: &alert.ID,
+		}
+		if err := s.notificationService.Notify(ctx, notification, alert.WebhookURL, payload); err != nil {
+			return err
+		}
+		triggeredAt = &now
+	}
+
+	return s.repo.UpdateRateAlertState(ctx, alert.ID, rate.Rate, triggeredAt)
+}
+
+// applyRequest проверяет запрос и переносит его в правило. Отсчет пересечения порога
+// начинается заново от текущего курса пары.
+func (s *alertService) applyRequest(ctx context.Context, alert *models.RateAlert, req *models.RateAlertRequest) error {
+	if req.BaseCurrencyID == req.TargetCurrencyID {
+		return errors.New("base and target currencies must differ")
+	}
+	for _, id := range []int{req.BaseCurrencyID, req.TargetCurrencyID} {
+		currency, err := s.repo.GetCurrencyByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if currency == nil {
+			return errors.New("currency not found")
+		}
+	}
+	if req.WebhookURL != "" {
+		if err := validateWebhookURL(ctx, req.WebhookURL); err != nil {
+			return err
+		}
+	}
+
+	alert.BaseCurrencyID = req.BaseCurrencyID
+	alert.TargetCurrencyID = req.TargetCurrencyID
+	alert.Condition = req.Condition
+	alert.Threshold = req.Threshold
+	alert.WebhookURL = req.WebhookURL
+	if req.IsActive != nil {
+		alert.IsActive = *req.IsActive
+	}
+
+	alert.LastRate = nil
+	if rate, err := s.exchangeService.GetExchangeRate(req.BaseCurrencyID, req.TargetCurrencyID, time.Now()); err == nil {
+		alert.LastRate = &rate.Rate
+	}
+
+	return nil
+}
+
+func (s *alertService) getOwnedAlert(ctx context.Context, userID, id int) (*models.RateAlert, error) {
+	alert, err := s.repo.GetRateAlertByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil || alert.UserID != userID {
+		return nil, errors.New("alert not found")
+	}
+
+	return alert, nil
+}
+
+// thresholdReached сообщает, выполнено ли условие above/below для курса
+func thresholdReached(alert *models.RateAlert, rate money.Decimal) bool {
+	if alert.Condition == AlertBelow {
+		return rate.Cmp(alert.Threshold) <= 0
+	}
+	return rate.Cmp(alert.Threshold) >= 0
+}
+
+// displayRate округляет курс для текста уведомления
+func displayRate(rate money.Decimal) string {
+	return rate.Round(alertRateDisplayPlaces, money.RoundHalfEven).Normalize().String()
+}
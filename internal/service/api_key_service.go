@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"personal-finance-tracker/internal/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix отличает API-ключи от JWT в заголовке Authorization
+const APIKeyPrefix = "pft_"
+
+// Сколько символов ключа сохраняется для отображения в списке
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// Максимальное число ключей у одного пользователя
+const maxAPIKeysPerUser = 20
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, user *models.User, req *models.APIKeyRequest) (*models.CreatedAPIKey, error)
+	GetKeys(ctx context.Context, userID int) ([]models.APIKey, error)
+	RevokeKey(ctx context.Context, userID, id int) error
+	// Authenticate проверяет ключ и возвращает его владельца
+	Authenticate(ctx context.Context, rawKey string) (*models.User, *models.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo repository.Repository
+}
+
+func NewAPIKeyService(repo repository.Repository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, user *models.User, req *models.APIKeyRequest) (*models.CreatedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if scope == models.APIKeyScopeAdmin && user.Role != models.RoleAdmin {
+			return nil, errors.New("admin scope requires admin role")
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	keys, err := s.repo.GetAPIKeysByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) >= maxAPIKeysPerUser {
+		return nil, errors.New("too many api keys, revoke unused ones first")
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	rawKey := APIKeyPrefix + token
+
+	key := models.APIKey{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    rawKey[:apiKeyDisplayLength],
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := s.repo.CreateAPIKey(ctx, &key); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: key, Key: rawKey}, nil
+}
+
+func (s *apiKeyService) GetKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+	return s.repo.GetAPIKeysByUserID(ctx, userID)
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, userID, id int) error {
+	found, err := s.repo.DeleteUserAPIKey(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("api key not found")
+	}
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*models.User, *models.APIKey, error) {
+	if !strings.HasPrefix(rawKey, APIKeyPrefix) {
+		return nil, nil, errors.New("invalid api key")
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, utils.HashToken(rawKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if key == nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, nil, errors.New("invalid api key")
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID, now, now.Add(-sessionTouchInterval)); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.repo.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Ключи не действуют, пока учетная запись ожидает удаления
+	if user == nil || user.DeletionScheduledAt != nil {
+		return nil, nil, errors.New("invalid api key")
+	}
+
+	return user, key, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+)
+
+// Виды объектов в журнале аудита
+const (
+	AuditEntityTransaction           = "transaction"
+	AuditEntityInvestmentTransaction = "investment_transaction"
+	AuditEntityTransfer              = "transfer"
+	AuditEntityAccount               = "account"
+	AuditEntityCategory              = "category"
+	AuditEntitySavingsGoal           = "savings_goal"
+	AuditEntityGoalContribution      = "goal_contribution"
+	AuditEntityRateAlert             = "rate_alert"
+	AuditEntityUserSettings          = "user_settings"
+	AuditEntityUserRole              = "user_role"
+	AuditEntityCurrency              = "currency"
+	AuditEntityExchangeRates         = "exchange_rates"
+	AuditEntityUserExchangeRate      = "user_exchange_rate"
+)
+
+// Записей журнала за один запрос: по умолчанию и не больше
+const (
+	auditDefaultLimit = 100
+	auditMaxLimit     = 500
+)
+
+type AuditService interface {
+	// Record добавляет запись в журнал через tx — репозиторий транзакции, в которой выполнено
+	// изменение: изменение и запись о нем сохраняются только вместе. ownerID — чьи данные изменены
+	// (0 — общие справочники), before/after сериализуются в JSON (nil — состояния нет). Исполнитель,
+	// IP и ID запроса берутся из контекста. При ошибке вызывающий код должен отменить изменение.
+	Record(ctx context.Context, tx repository.Repository, ownerID int, action, entityType string, entityID int, before, after any) error
+	// GetEntries возвращает записи журнала; Limit ограничивается auditMaxLimit
+	GetEntries(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type auditService struct {
+	repo repository.Repository
+}
+
+func NewAuditService(repo repository.Repository) AuditService {
+	return &auditService{repo: repo}
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor сохраняет в контексте исполнителя запроса для журнала аудита
+func WithAuditActor(ctx context.Context, actor models.AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func (s *auditService) Record(ctx context.Context, tx repository.Repository, ownerID int, action, entityType string, entityID int, before, after any) error {
+	entry := &models.AuditEntry{
+		UserID:     optionalID(ownerID),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   optionalID(entityID),
+	}
+
+	// Фоновые задачи выполняются без исполнителя
+	if actor, ok := ctx.Value(auditActorKey{}).(models.AuditActor); ok {
+		entry.ActorID = optionalID(actor.UserID)
+		entry.APIKeyID = actor.APIKeyID
+		entry.IPAddress = actor.IPAddress
+		entry.RequestID = actor.RequestID
+	}
+
+	var err error
+	if entry.Before, err = marshalAuditState(before); err == nil {
+		entry.After, err = marshalAuditState(after)
+	}
+	if err == nil {
+		err = tx.CreateAuditEntry(ctx, entry)
+	}
+	if err != nil {
+		log.Printf("Failed to record audit entry %s %s %d: %v", action, entityType, entityID, err)
+		return errors.New("failed to record audit entry")
+	}
+	return nil
+}
+
+func (s *auditService) GetEntries(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = auditDefaultLimit
+	}
+	filter.Limit = min(filter.Limit, auditMaxLimit)
+
+	return s.repo.GetAuditEntries(ctx, filter)
+}
+
+func marshalAuditState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
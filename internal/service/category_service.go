@@ -2,26 +2,42 @@ package service
 
 import (
 	"context"
+	"errors"
 	"personal-finance-tracker/internal/models"
 	"personal-finance-tracker/internal/repository"
 )
 
 type CategoryService interface {
-	CreateCategory(category *models.Category) error
+	CreateCategory(ctx context.Context, category *models.Category) error
 	GetUserCategories(userID int) ([]models.Category, error)
 	GetCategoryByID(id int) (*models.Category, error)
+	GetGlobalCategories(ctx context.Context) ([]models.Category, error)
+	UpdateGlobalCategory(ctx context.Context, id int, req *models.CategoryRequest) (*models.Category, error)
+	DeleteGlobalCategory(ctx context.Context, id int) error
 }
 
 type categoryService struct {
-	repo repository.Repository
+	repo  repository.Repository
+	audit AuditService
 }
 
-func NewCategoryService(repo repository.Repository) CategoryService {
-	return &categoryService{repo: repo}
+func NewCategoryService(repo repository.Repository, audit AuditService) CategoryService {
+	return &categoryService{repo: repo, audit: audit}
 }
 
-func (s *categoryService) CreateCategory(category *models.Category) error {
-	return s.repo.CreateCategory(context.Background(), category)
+// CreateCategory создает личную категорию пользователя или, без UserID, общую
+func (s *categoryService) CreateCategory(ctx context.Context, category *models.Category) error {
+	ownerID := 0
+	if category.UserID != nil {
+		ownerID = *category.UserID
+	}
+
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.CreateCategory(ctx, category); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, ownerID, models.AuditCreate, AuditEntityCategory, category.ID, nil, category)
+	})
 }
 
 func (s *categoryService) GetUserCategories(userID int) ([]models.Category, error) {
@@ -31,3 +47,58 @@ func (s *categoryService) GetUserCategories(userID int) ([]models.Category, erro
 func (s *categoryService) GetCategoryByID(id int) (*models.Category, error) {
 	return s.repo.GetCategoryByID(context.Background(), id)
 }
+
+// GetGlobalCategories возвращает общие категории (без владельца), видимые всем пользователям
+func (s *categoryService) GetGlobalCategories(ctx context.Context) ([]models.Category, error) {
+	return s.repo.GetGlobalCategories(ctx)
+}
+
+func (s *categoryService) UpdateGlobalCategory(ctx context.Context, id int, req *models.CategoryRequest) (*models.Category, error) {
+	category, err := s.getGlobalCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *category
+
+	category.Name = req.Name
+	category.Description = req.Description
+	category.Type = req.Type
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.UpdateCategory(ctx, category); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, 0, models.AuditUpdate, AuditEntityCategory, category.ID, before, category)
+	}); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteGlobalCategory удаляет общую категорию; у транзакций с ней категория сбрасывается
+func (s *categoryService) DeleteGlobalCategory(ctx context.Context, id int) error {
+	category, err := s.getGlobalCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.DeleteCategory(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, 0, models.AuditDelete, AuditEntityCategory, id, category, nil)
+	})
+}
+
+// getGlobalCategory не дает администратору менять личные категории пользователей
+func (s *categoryService) getGlobalCategory(ctx context.Context, id int) (*models.Category, error) {
+	category, err := s.repo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil || category.UserID != nil {
+		return nil, errors.New("category not found")
+	}
+
+	return category, nil
+}
@@ -2,28 +2,72 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
 	"personal-finance-tracker/internal/repository"
+	"sort"
+	"strings"
 )
 
+// Положение символа валюты относительно суммы
+const (
+	SymbolBefore = "before"
+	SymbolAfter  = "after"
+)
+
+// Виды валют
+const (
+	CurrencyFiat   = "fiat"
+	CurrencyCrypto = "crypto"
+)
+
+// Валюта, к которой приводятся курсы провайдеров; ее нельзя отключить
+const baseCurrencyCode = "USD"
+
 type CurrencyService interface {
 	GetAllCurrencies() ([]models.Currency, error)
+	GetActiveCurrencies() ([]models.Currency, error)
 	GetCurrencyByID(id int) (*models.Currency, error)
 	GetCurrencyByCode(code string) (*models.Currency, error)
+	CreateCurrency(ctx context.Context, req *models.CurrencyRequest) (*models.Currency, error)
+	UpdateCurrency(ctx context.Context, id int, req *models.UpdateCurrencyRequest) (*models.Currency, error)
+	SetCurrencyActive(ctx context.Context, id int, active bool) (*models.Currency, error)
+	GetUserCurrencies(ctx context.Context, userID int) ([]models.Currency, error)
+	SetUserCurrencies(ctx context.Context, userID int, currencyIDs []int) ([]models.Currency, error)
 }
 
 type currencyService struct {
-	repo repository.Repository
+	repo  repository.Repository
+	audit AuditService
 }
 
-func NewCurrencyService(repo repository.Repository) CurrencyService {
-	return &currencyService{repo: repo}
+func NewCurrencyService(repo repository.Repository, audit AuditService) CurrencyService {
+	return &currencyService{repo: repo, audit: audit}
 }
 
 func (s *currencyService) GetAllCurrencies() ([]models.Currency, error) {
 	return s.repo.GetAllCurrencies()
 }
 
+// GetActiveCurrencies возвращает валюты, не отключенные администратором
+func (s *currencyService) GetActiveCurrencies() ([]models.Currency, error) {
+	currencies, err := s.repo.GetAllCurrencies()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]models.Currency, 0, len(currencies))
+	for _, currency := range currencies {
+		if currency.IsActive {
+			active = append(active, currency)
+		}
+	}
+
+	return active, nil
+}
+
 func (s *currencyService) GetCurrencyByID(id int) (*models.Currency, error) {
 	return s.repo.GetCurrencyByID(context.Background(), id)
 }
@@ -31,3 +75,243 @@ func (s *currencyService) GetCurrencyByID(id int) (*models.Currency, error) {
 func (s *currencyService) GetCurrencyByCode(code string) (*models.Currency, error) {
 	return s.repo.GetCurrencyByCode(code)
 }
+
+func (s *currencyService) CreateCurrency(ctx context.Context, req *models.CurrencyRequest) (*models.Currency, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+
+	existing, err := s.repo.GetCurrencyByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("currency already exists")
+	}
+
+	currency := &models.Currency{
+		Code:           code,
+		Name:           strings.TrimSpace(req.Name),
+		Symbol:         strings.TrimSpace(req.Symbol),
+		MinorUnits:     2,
+		SymbolPosition: SymbolBefore,
+		IsActive:       true,
+		Kind:           CurrencyFiat,
+	}
+	if req.Kind != "" {
+		currency.Kind = req.Kind
+	}
+	if req.MinorUnits != nil {
+		currency.MinorUnits = *req.MinorUnits
+	}
+	if req.SymbolPosition != "" {
+		currency.SymbolPosition = req.SymbolPosition
+	}
+
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.CreateCurrency(currency); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, 0, models.AuditCreate, AuditEntityCurrency, currency.ID, nil, currency)
+	}); err != nil {
+		return nil, err
+	}
+
+	return currency, nil
+}
+
+func (s *currencyService) UpdateCurrency(ctx context.Context, id int, req *models.UpdateCurrencyRequest) (*models.Currency, error) {
+	currency, err := s.getCurrency(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *currency
+
+	if req.Name != nil {
+		currency.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Symbol != nil {
+		currency.Symbol = strings.TrimSpace(*req.Symbol)
+	}
+	if req.MinorUnits != nil {
+		currency.MinorUnits = *req.MinorUnits
+	}
+	if req.SymbolPosition != nil {
+		currency.SymbolPosition = *req.SymbolPosition
+	}
+	if req.IsActive != nil {
+		if err := checkCanDisable(currency, *req.IsActive); err != nil {
+			return nil, err
+		}
+		currency.IsActive = *req.IsActive
+	}
+
+	if err := s.updateCurrency(ctx, &before, currency); err != nil {
+		return nil, err
+	}
+
+	return currency, nil
+}
+
+// SetCurrencyActive включает или отключает валюту. Отключенная валюта остается
+// у существующих счетов, но недоступна для новых счетов и выбора пользователями.
+func (s *currencyService) SetCurrencyActive(ctx context.Context, id int, active bool) (*models.Currency, error) {
+	currency, err := s.getCurrency(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanDisable(currency, active); err != nil {
+		return nil, err
+	}
+	before := *currency
+
+	currency.IsActive = active
+	if err := s.updateCurrency(ctx, &before, currency); err != nil {
+		return nil, err
+	}
+
+	return currency, nil
+}
+
+// updateCurrency сохраняет валюту вместе с записью журнала
+func (s *currencyService) updateCurrency(ctx context.Context, before, currency *models.Currency) error {
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.UpdateCurrency(ctx, currency); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, 0, models.AuditUpdate, AuditEntityCurrency, currency.ID, before, currency)
+	})
+}
+
+// GetUserCurrencies возвращает валюты, выбранные пользователем (если выбора нет — все активные),
+// а также валюты его счетов, чтобы существующие счета всегда можно было отобразить
+func (s *currencyService) GetUserCurrencies(ctx context.Context, userID int) ([]models.Currency, error) {
+	selected, err := s.repo.GetUserCurrencies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		if selected, err = s.repo.GetAllCurrencies(); err != nil {
+			return nil, err
+		}
+	}
+
+	accounts, err := s.repo.GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	inUse := make(map[int]bool, len(accounts))
+	for _, account := range accounts {
+		inUse[account.CurrencyID] = true
+	}
+
+	result := make([]models.Currency, 0, len(selected))
+	seen := make(map[int]bool, len(selected))
+	for _, currency := range selected {
+		// Валюты, отключенные после выбора, показываются только при наличии счетов в них
+		if currency.IsActive || inUse[currency.ID] {
+			result = append(result, currency)
+			seen[currency.ID] = true
+		}
+	}
+	for _, account := range accounts {
+		if account.Currency != nil && !seen[account.CurrencyID] {
+			result = append(result, *account.Currency)
+			seen[account.CurrencyID] = true
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result, nil
+}
+
+// SetUserCurrencies заменяет список валют пользователя; пустой список снимает ограничение
+func (s *currencyService) SetUserCurrencies(ctx context.Context, userID int, currencyIDs []int) ([]models.Currency, error) {
+	unique := make(map[int]bool, len(currencyIDs))
+	ids := make([]int, 0, len(currencyIDs))
+	for _, id := range currencyIDs {
+		if unique[id] {
+			continue
+		}
+		unique[id] = true
+
+		currency, err := s.getCurrency(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !currency.IsActive {
+			return nil, errors.New("currency is disabled")
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	previous, err := s.repo.GetUserCurrencies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	previousIDs := make([]int, len(previous))
+	for i, currency := range previous {
+		previousIDs[i] = currency.ID
+	}
+
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.SetUserCurrencies(ctx, userID, ids); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditUpdate, AuditEntityUserSettings, userID,
+			map[string]any{"currency_ids": previousIDs}, map[string]any{"currency_ids": ids})
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.GetUserCurrencies(ctx, userID)
+}
+
+func (s *currencyService) getCurrency(ctx context.Context, id int) (*models.Currency, error) {
+	currency, err := s.repo.GetCurrencyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if currency == nil {
+		return nil, errors.New("currency not found")
+	}
+
+	return currency, nil
+}
+
+// checkCanDisable запрещает отключать базовую валюту курсов
+func checkCanDisable(currency *models.Currency, active bool) error {
+	if !active && currency.Code == baseCurrencyCode {
+		return errors.New("base currency cannot be disabled")
+	}
+	return nil
+}
+
+// checkPrecision проверяет, что в сумме не больше знаков, чем в минимальной единице валюты
+func checkPrecision(amount money.Decimal, currency *models.Currency) error {
+	if currency != nil && amount.Normalize().Scale() > currency.MinorUnits {
+		return fmt.Errorf("amount has more than %d decimal places allowed for %s", currency.MinorUnits, currency.Code)
+	}
+	return nil
+}
+
+// FormatAmount форматирует сумму с символом валюты: "$1234.50", "1234.50 ₽", "₿0.015".
+// Криптовалюты округляются до точности сети без незначащих нулей.
+func FormatAmount(amount money.Decimal, currency *models.Currency) string {
+	if currency == nil {
+		return amount.String()
+	}
+
+	rounded := roundToCurrency(amount, currency, money.RoundHalfEven)
+	if currency.Kind == CurrencyCrypto {
+		rounded = rounded.Normalize()
+	}
+
+	value := rounded.String()
+	if currency.SymbolPosition == SymbolAfter {
+		return value + " " + currency.Symbol
+	}
+	if strings.HasPrefix(value, "-") {
+		return "-" + currency.Symbol + strings.TrimPrefix(value, "-")
+	}
+	return currency.Symbol + value
+}
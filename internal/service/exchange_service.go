@@ -2,176 +2,226 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
 	"personal-finance-tracker/internal/repository"
+	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Источники курса перевода между счетами
+const (
+	RateSourceSame   = "same"   // счета в одной валюте
+	RateSourceUser   = "user"   // личный курс пользователя
+	RateSourceGlobal = "global" // общий курс провайдеров
+	RateSourceManual = "manual" // курс или полученная сумма указаны при переводе
 )
 
+// Курсы старше этого срока считаются устаревшими и обновляются в фоне
+const rateMaxAge = 24 * time.Hour
+
+// Минимальный интервал между фоновыми обновлениями, чтобы недоступный провайдер
+// не опрашивался на каждый запрос
+const rateRefreshCooldown = time.Minute
+
 type ExchangeService interface {
-	GetExchangeRate(baseCurrencyID, targetCurrencyID int) (*models.ExchangeRate, error)
-	GetAllExchangeRates() ([]models.ExchangeRate, error)
-	UpdateExchangeRates() error
-	ConvertAmount(amount float64, fromAccountID, toAccountID int) (float64, error)
+	GetExchangeRate(baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.ExchangeRate, error)
+	GetAllExchangeRates(asOf time.Time) ([]models.ExchangeRate, error)
+	GetExchangeRateHistory(baseCurrencyID, targetCurrencyID int, start, end time.Time) ([]models.ExchangeRate, error)
+	UpdateExchangeRates(ctx context.Context) error
+	ImportExchangeRates(ctx context.Context, r io.Reader, format string) (*models.RateImportResult, error)
+	ConvertAmount(amount money.Decimal, fromAccountID, toAccountID int, mode money.RoundingMode) (money.Decimal, error)
+	ConvertCurrencyAmount(ctx context.Context, amount money.Decimal, fromCurrencyID, toCurrencyID int, asOf time.Time, mode money.RoundingMode) (money.Decimal, *models.ExchangeRate, error)
 	GetUserBalancesInUSD(userID int) ([]models.AccountBalance, error)
+	// Личные курсы пользователя: в его пересчетах приоритетнее общих курсов
+	GetUserExchangeRate(ctx context.Context, userID, baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.ExchangeRate, error)
+	SetUserExchangeRate(ctx context.Context, userID int, req *models.ExchangeRateRequest) (*models.UserExchangeRate, error)
+	GetUserExchangeRates(ctx context.Context, userID int) ([]models.UserExchangeRate, error)
+	DeleteUserExchangeRate(ctx context.Context, userID, id int) error
+	// OnRatesUpdated регистрирует обработчик, вызываемый после каждого обновления курсов
+	OnRatesUpdated(listener func(ctx context.Context) error)
 }
 
 type exchangeService struct {
-	repo        repository.Repository
-	apiEndpoint string
+	repo      repository.Repository
+	audit     AuditService
+	providers []RateProvider
+	cache     *rateCache
+
+	// Одновременные обновления курсов объединяются в один запрос к провайдеру
+	refresh       singleflight.Group
+	refreshMu     sync.Mutex
+	lastRefreshAt time.Time
+
+	listenersMu sync.Mutex
+	listeners   []func(ctx context.Context) error
 }
 
-// ExchangeRateAPIResponse структура для ответа от ExchangeRate-API
-type ExchangeRateAPIResponse struct {
-	Base  string             `json:"base"`
-	Rates map[string]float64 `json:"rates"`
-}
-
-func NewExchangeService(repo repository.Repository, apiEndpoint string) ExchangeService {
-	// fallback на дефолт, если в конфиге пусто
-	if apiEndpoint == "" {
-		apiEndpoint = "https://api.exchangerate-api.com/v4/latest/USD"
-	}
+// NewExchangeService создает сервис курсов. Каждый источник (например, цепочка фиатных
+// провайдеров и провайдер криптовалют) опрашивается при обновлении независимо.
+func NewExchangeService(repo repository.Repository, audit AuditService, providers ...RateProvider) ExchangeService {
 	return &exchangeService{
-		repo:        repo,
-		apiEndpoint: apiEndpoint,
+		repo:      repo,
+		audit:     audit,
+		providers: providers,
+		cache:     newRateCache(repo),
 	}
 }
 
-func (s *exchangeService) GetExchangeRate(baseCurrencyID, targetCurrencyID int) (*models.ExchangeRate, error) {
+// GetExchangeRate возвращает курс, действовавший на дату asOf. Если прямого курса нет,
+// он выводится по кратчайшей цепочке известных курсов; путь и возраст курса попадают в ответ.
+func (s *exchangeService) GetExchangeRate(baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.ExchangeRate, error) {
 	if baseCurrencyID == targetCurrencyID {
 		return &models.ExchangeRate{
 			BaseCurrencyID:   baseCurrencyID,
 			TargetCurrencyID: targetCurrencyID,
-			Rate:             1.0,
+			Rate:             money.NewFromInt(1),
+			RateDate:         asOf,
 			LastUpdated:      time.Now(),
 		}, nil
 	}
 
-	rate, err := s.repo.GetExchangeRate(baseCurrencyID, targetCurrencyID)
+	rate, err := s.cache.rate(baseCurrencyID, targetCurrencyID, asOf)
 	if err != nil {
 		return nil, err
 	}
 
-	// Если курс устарел (старше 24 часов), обновляем его
-	if rate == nil || time.Since(rate.LastUpdated) > 24*time.Hour {
-		err = s.UpdateExchangeRates()
-		if err != nil {
-			log.Printf("Failed to update exchange rates: %v", err)
-			// Возвращаем старый курс, если обновление не удалось
-			if rate != nil {
-				return rate, nil
-			}
-		} else {
-			// Пытаемся получить обновленный прямой курс
-			if r2, e2 := s.repo.GetExchangeRate(baseCurrencyID, targetCurrencyID); e2 == nil && r2 != nil {
-				rate = r2
-			}
-		}
+	// Устаревший текущий курс обновляется в фоне; запрос не ждет провайдера.
+	// Исторические курсы не обновляются — провайдер отдает только текущие.
+	if isCurrentDate(asOf) && (rate == nil || time.Since(rate.LastUpdated) > rateMaxAge) {
+		s.refreshInBackground()
 	}
 
-	if rate != nil {
-		return rate, nil
+	if rate == nil {
+		return nil, errors.New("exchange rate not available")
 	}
 
-	// 1) Пытаемся использовать обратный курс (target->base) как 1/reverse
-	if reverse, e := s.repo.GetExchangeRate(targetCurrencyID, baseCurrencyID); e == nil && reverse != nil {
-		return &models.ExchangeRate{
-			BaseCurrencyID:   baseCurrencyID,
-			TargetCurrencyID: targetCurrencyID,
-			Rate:             1.0 / reverse.Rate,
-			LastUpdated:      reverse.LastUpdated,
-			BaseCurrency:     reverse.TargetCurrency,
-			TargetCurrency:   reverse.BaseCurrency,
-		}, nil
-	}
+	return rate, nil
+}
 
-	// 2) Пытаемся вычислить через USD-пивот: base->USD и USD->target
-	usd, e := s.repo.GetCurrencyByCode("USD")
-	if e != nil {
-		return nil, e
+// GetAllExchangeRates возвращает последний курс на дату asOf по каждой паре
+func (s *exchangeService) GetAllExchangeRates(asOf time.Time) ([]models.ExchangeRate, error) {
+	rates, err := s.cache.all(asOf)
+	if err != nil {
+		return nil, err
 	}
-	if usd != nil {
-		// base -> USD (прямой или обратный)
-		var baseToUSD *models.ExchangeRate
-		if r, e := s.repo.GetExchangeRate(baseCurrencyID, usd.ID); e == nil {
-			baseToUSD = r
-		}
-		if baseToUSD == nil {
-			if r, e := s.repo.GetExchangeRate(usd.ID, baseCurrencyID); e == nil && r != nil {
-				baseToUSD = &models.ExchangeRate{Rate: 1.0 / r.Rate, LastUpdated: r.LastUpdated}
-			}
-		}
 
-		// USD -> target (прямой или обратный)
-		var usdToTarget *models.ExchangeRate
-		if r, e := s.repo.GetExchangeRate(usd.ID, targetCurrencyID); e == nil {
-			usdToTarget = r
-		}
-		if usdToTarget == nil {
-			if r, e := s.repo.GetExchangeRate(targetCurrencyID, usd.ID); e == nil && r != nil {
-				usdToTarget = &models.ExchangeRate{Rate: 1.0 / r.Rate, LastUpdated: r.LastUpdated}
+	if isCurrentDate(asOf) {
+		// Проверяем, есть ли актуальные курсы
+		needsUpdate := len(rates) == 0
+		for _, rate := range rates {
+			if time.Since(rate.LastUpdated) > rateMaxAge {
+				needsUpdate = true
+				break
 			}
 		}
-
-		if baseToUSD != nil && usdToTarget != nil {
-			// Берем минимально свежее время как lastUpdated
-			last := baseToUSD.LastUpdated
-			if usdToTarget.LastUpdated.Before(last) {
-				last = usdToTarget.LastUpdated
-			}
-			return &models.ExchangeRate{
-				BaseCurrencyID:   baseCurrencyID,
-				TargetCurrencyID: targetCurrencyID,
-				Rate:             baseToUSD.Rate * usdToTarget.Rate,
-				LastUpdated:      last,
-			}, nil
+		if needsUpdate {
+			s.refreshInBackground()
 		}
 	}
 
-	return nil, errors.New("exchange rate not available")
+	if len(rates) == 0 && isCurrentDate(asOf) {
+		return nil, errors.New("no exchange rates available")
+	}
+
+	return rates, nil
 }
 
-func (s *exchangeService) GetAllExchangeRates() ([]models.ExchangeRate, error) {
-	rates, err := s.repo.GetAllExchangeRates()
+// GetExchangeRateHistory возвращает историю курса пары; если хранится только обратная пара — пересчитывает ее
+func (s *exchangeService) GetExchangeRateHistory(baseCurrencyID, targetCurrencyID int, start, end time.Time) ([]models.ExchangeRate, error) {
+	if end.Before(start) {
+		return nil, errors.New("end date must not be before start date")
+	}
+
+	rates, err := s.repo.GetExchangeRateHistory(baseCurrencyID, targetCurrencyID, start, end)
 	if err != nil {
 		return nil, err
 	}
+	if len(rates) > 0 {
+		return rates, nil
+	}
 
-	// Проверяем, есть ли актуальные курсы
-	needsUpdate := len(rates) == 0
-	if !needsUpdate {
-		for _, rate := range rates {
-			if time.Since(rate.LastUpdated) > 24*time.Hour {
-				needsUpdate = true
-				break
-			}
-		}
+	reverse, err := s.repo.GetExchangeRateHistory(targetCurrencyID, baseCurrencyID, start, end)
+	if err != nil {
+		return nil, err
 	}
 
-	if needsUpdate {
-		err = s.UpdateExchangeRates()
-		if err != nil {
-			log.Printf("Failed to update exchange rates: %v", err)
-			// Возвращаем старые курсы, если обновление не удалось
-			if len(rates) > 0 {
-				return rates, nil
-			}
-			return nil, errors.New("no exchange rates available")
-		}
-		// Получаем обновленные курсы
-		return s.repo.GetAllExchangeRates()
+	rates = make([]models.ExchangeRate, 0, len(reverse))
+	for _, r := range reverse {
+		rates = append(rates, models.ExchangeRate{
+			BaseCurrencyID:   baseCurrencyID,
+			TargetCurrencyID: targetCurrencyID,
+			Rate:             money.NewFromInt(1).Div(r.Rate, money.RateScale, money.RoundHalfEven),
+			RateDate:         r.RateDate,
+			LastUpdated:      r.LastUpdated,
+			BaseCurrency:     r.TargetCurrency,
+			TargetCurrency:   r.BaseCurrency,
+		})
 	}
 
 	return rates, nil
 }
 
-func (s *exchangeService) UpdateExchangeRates() error {
+// UpdateExchangeRates запрашивает курсы у провайдеров; одновременные вызовы выполняют один запрос.
+// Исполнитель из ctx попадает в журнал аудита; отмена ctx и его срок действуют на запросы к провайдерам.
+func (s *exchangeService) UpdateExchangeRates(ctx context.Context) error {
+	s.refreshMu.Lock()
+	s.lastRefreshAt = time.Now()
+	s.refreshMu.Unlock()
+
+	_, err, _ := s.refresh.Do("update", func() (any, error) {
+		err := s.updateExchangeRates(ctx)
+		// Даже частично сохраненные курсы должны стать видны
+		s.cache.invalidate()
+		s.notifyRatesUpdated()
+		return nil, err
+	})
+	return err
+}
+
+func (s *exchangeService) OnRatesUpdated(listener func(ctx context.Context) error) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// notifyRatesUpdated вызывает обработчики обновления курсов; их ошибки только логируются
+func (s *exchangeService) notifyRatesUpdated() {
+	s.listenersMu.Lock()
+	listeners := append([]func(ctx context.Context) error(nil), s.listeners...)
+	s.listenersMu.Unlock()
+
+	for _, listener := range listeners {
+		if err := listener(context.Background()); err != nil {
+			log.Printf("Exchange rates update listener failed: %v", err)
+		}
+	}
+}
+
+// refreshInBackground запускает обновление курсов, не дожидаясь его
+func (s *exchangeService) refreshInBackground() {
+	s.refreshMu.Lock()
+	if time.Since(s.lastRefreshAt) < rateRefreshCooldown {
+		s.refreshMu.Unlock()
+		return
+	}
+	s.lastRefreshAt = time.Now()
+	s.refreshMu.Unlock()
+
+	go func() {
+		if err := s.UpdateExchangeRates(context.Background()); err != nil {
+			log.Printf("Failed to update exchange rates in background: %v", err)
+		}
+	}()
+}
+
+func (s *exchangeService) updateExchangeRates(ctx context.Context) error {
 	// Получаем все валюты
 	currencies, err := s.repo.GetAllCurrencies()
 	if err != nil {
@@ -195,71 +245,96 @@ func (s *exchangeService) UpdateExchangeRates() error {
 		return errors.New("USD currency not found")
 	}
 
-	// Получаем курсы из API
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(s.apiEndpoint)
-	if err != nil {
-		return fmt.Errorf("failed to fetch exchange rates: %v", err)
+	// Опрашиваем все источники и пересчитываем их курсы к USD.
+	// Курс валюты берется у первого источника, который его вернул.
+	type sourcedRate struct {
+		rate     money.Decimal
+		date     time.Time
+		provider string
 	}
-	defer resp.Body.Close()
+	rates := make(map[string]sourcedRate)
+	var errs []error
+	for _, provider := range s.providers {
+		providerRates, err := provider.FetchRates(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status: %d", resp.StatusCode)
-	}
+		rebased, err := providerRates.rebase("USD")
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %v", err)
+		for code, rate := range rebased {
+			if _, exists := rates[code]; !exists {
+				rates[code] = sourcedRate{rate: rate, date: providerRates.Date, provider: providerRates.Provider}
+			}
+		}
 	}
-
-	var apiResponse ExchangeRateAPIResponse
-	err = json.Unmarshal(body, &apiResponse)
-	if err != nil {
-		return fmt.Errorf("failed to parse JSON: %v", err)
+	if len(rates) == 0 {
+		if len(errs) == 0 {
+			return errors.New("no exchange rate providers configured")
+		}
+		return errors.Join(errs...)
 	}
 
-	// Сохраняем курсы в базу
-	for _, targetCurrency := range currencies {
-		if targetCurrency.Code == "USD" {
-			continue // Пропускаем базовую валюту
-		}
+	// Сохраняем курсы в базу одной транзакцией вместе с записью журнала
+	err = s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		changes := newRateAuditChanges()
+		for _, targetCurrency := range currencies {
+			if targetCurrency.Code == "USD" || !targetCurrency.IsActive {
+				continue // Пропускаем базовую и отключенные валюты
+			}
 
-		rate, exists := apiResponse.Rates[targetCurrency.Code]
-		if !exists {
-			log.Printf("Exchange rate for %s not found in API response", targetCurrency.Code)
-			continue
-		}
+			rate, exists := rates[targetCurrency.Code]
+			if !exists {
+				log.Printf("Exchange rate for %s not found in provider responses", targetCurrency.Code)
+				continue
+			}
 
-		exchangeRate := &models.ExchangeRate{
-			BaseCurrencyID:   usdCurrency.ID,
-			TargetCurrencyID: targetCurrency.ID,
-			Rate:             rate,
-		}
+			exchangeRate := &models.ExchangeRate{
+				BaseCurrencyID:   usdCurrency.ID,
+				TargetCurrencyID: targetCurrency.ID,
+				Rate:             rate.rate,
+				RateDate:         rate.date,
+			}
 
-		err = s.repo.CreateOrUpdateExchangeRate(exchangeRate)
-		if err != nil {
-			log.Printf("Failed to save exchange rate for %s: %v", targetCurrency.Code, err)
+			previous, err := tx.CreateOrUpdateExchangeRate(exchangeRate)
+			if err != nil {
+				return fmt.Errorf("failed to save %s exchange rate for %s: %w", rate.provider, targetCurrency.Code, err)
+			}
+			changes.add(usdCurrency.Code, targetCurrency.Code, rate.date, previous, rate.rate)
 		}
+
+		return changes.record(ctx, tx, s.audit)
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	// Курсы доступных источников сохранены; сбой остальных все равно сообщаем
+	return errors.Join(errs...)
 }
 
-func (s *exchangeService) ConvertAmount(amount float64, fromAccountID, toAccountID int) (float64, error) {
+// ConvertAmount пересчитывает сумму между счетами по текущему курсу с округлением
+// до минимальной единицы валюты счета-получателя
+func (s *exchangeService) ConvertAmount(amount money.Decimal, fromAccountID, toAccountID int, mode money.RoundingMode) (money.Decimal, error) {
 	fromAccount, err := s.repo.GetAccountByID(context.Background(), fromAccountID)
 	if err != nil {
-		return 0, err
+		return money.Zero, err
 	}
 	if fromAccount == nil {
-		return 0, errors.New("source account not found")
+		return money.Zero, errors.New("source account not found")
 	}
 
 	toAccount, err := s.repo.GetAccountByID(context.Background(), toAccountID)
 	if err != nil {
-		return 0, err
+		return money.Zero, err
 	}
 	if toAccount == nil {
-		return 0, errors.New("target account not found")
+		return money.Zero, errors.New("target account not found")
 	}
 
 	// Если счета в одной валюте, конвертация не нужна
@@ -267,17 +342,42 @@ func (s *exchangeService) ConvertAmount(amount float64, fromAccountID, toAccount
 		return amount, nil
 	}
 
-	// Получаем курс обмена
-	exchangeRate, err := s.GetExchangeRate(fromAccount.CurrencyID, toAccount.CurrencyID)
+	// Получаем курс обмена с учетом личных курсов владельца счета
+	exchangeRate, err := s.GetUserExchangeRate(context.Background(), fromAccount.UserID, fromAccount.CurrencyID, toAccount.CurrencyID, time.Now())
+	if err != nil {
+		return money.Zero, err
+	}
+
+	return convertMoney(amount, exchangeRate.Rate, toAccount.Currency, mode), nil
+}
+
+// ConvertCurrencyAmount пересчитывает сумму между валютами по курсу на дату asOf
+// с округлением до минимальной единицы целевой валюты
+func (s *exchangeService) ConvertCurrencyAmount(ctx context.Context, amount money.Decimal, fromCurrencyID, toCurrencyID int, asOf time.Time, mode money.RoundingMode) (money.Decimal, *models.ExchangeRate, error) {
+	target, err := s.repo.GetCurrencyByID(ctx, toCurrencyID)
+	if err != nil {
+		return money.Zero, nil, err
+	}
+	if target == nil {
+		return money.Zero, nil, errors.New("currency not found")
+	}
+
+	exchangeRate, err := s.GetExchangeRate(fromCurrencyID, toCurrencyID, asOf)
 	if err != nil {
-		return 0, err
+		return money.Zero, nil, err
 	}
+	if exchangeRate != nil && exchangeRate.TargetCurrency == nil {
+		// Курс из кэша общий — дополняем копию
+		withCurrency := *exchangeRate
+		withCurrency.TargetCurrency = target
+		exchangeRate = &withCurrency
+	}
+
 	if exchangeRate == nil {
-		return 0, errors.New("exchange rate not available")
+		return money.Zero, nil, nil
 	}
 
-	convertedAmount := amount * exchangeRate.Rate
-	return convertedAmount, nil
+	return convertMoney(amount, exchangeRate.Rate, target, mode), exchangeRate, nil
 }
 
 func (s *exchangeService) GetUserBalancesInUSD(userID int) ([]models.AccountBalance, error) {
@@ -304,9 +404,9 @@ func (s *exchangeService) GetUserBalancesInUSD(userID int) ([]models.AccountBala
 
 		// Конвертируем баланс в USD
 		if account.Currency.Code != "USD" {
-			exchangeRate, err := s.GetExchangeRate(account.CurrencyID, usdCurrency.ID)
+			exchangeRate, err := s.GetUserExchangeRate(context.Background(), userID, account.CurrencyID, usdCurrency.ID, time.Now())
 			if err == nil && exchangeRate != nil {
-				balance.BalanceInUSD = account.Balance * exchangeRate.Rate
+				balance.BalanceInUSD = convertMoney(account.Balance, exchangeRate.Rate, usdCurrency, money.RoundHalfEven)
 			}
 		} else {
 			balance.BalanceInUSD = account.Balance
@@ -317,3 +417,158 @@ func (s *exchangeService) GetUserBalancesInUSD(userID int) ([]models.AccountBala
 
 	return balances, nil
 }
+
+// GetUserExchangeRate возвращает курс для пересчетов пользователя. Личный курс пары (прямой
+// или обратный) действует с его даты, пока не появится более свежий общий курс; при равных
+// датах приоритет у личного.
+func (s *exchangeService) GetUserExchangeRate(ctx context.Context, userID, baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.ExchangeRate, error) {
+	if baseCurrencyID == targetCurrencyID {
+		return s.GetExchangeRate(baseCurrencyID, targetCurrencyID, asOf)
+	}
+
+	personal, err := s.repo.GetLatestUserExchangeRate(ctx, userID, baseCurrencyID, targetCurrencyID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	global, globalErr := s.GetExchangeRate(baseCurrencyID, targetCurrencyID, asOf)
+	if personal == nil || (globalErr == nil && global.RateDate.After(personal.RateDate)) {
+		return global, globalErr
+	}
+
+	rate := personal.Rate
+	if personal.BaseCurrencyID != baseCurrencyID {
+		rate = money.NewFromInt(1).Div(rate, money.RateScale, money.RoundHalfEven).Normalize()
+	}
+
+	return &models.ExchangeRate{
+		BaseCurrencyID:   baseCurrencyID,
+		TargetCurrencyID: targetCurrencyID,
+		Rate:             rate,
+		RateDate:         personal.RateDate,
+		LastUpdated:      personal.UpdatedAt,
+		Source:           RateSourceUser,
+	}, nil
+}
+
+// SetUserExchangeRate сохраняет личный курс пары на дату; курс на ту же дату заменяется
+func (s *exchangeService) SetUserExchangeRate(ctx context.Context, userID int, req *models.ExchangeRateRequest) (*models.UserExchangeRate, error) {
+	if req.BaseCurrencyID == req.TargetCurrencyID {
+		return nil, errors.New("base and target currencies must differ")
+	}
+	for _, id := range []int{req.BaseCurrencyID, req.TargetCurrencyID} {
+		currency, err := s.repo.GetCurrencyByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if currency == nil {
+			return nil, errors.New("currency not found")
+		}
+	}
+
+	y, m, d := time.Now().Date()
+	rateDate := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return nil, errors.New("invalid date format, use YYYY-MM-DD")
+		}
+		rateDate = parsed
+	}
+
+	rate := &models.UserExchangeRate{
+		UserID:           userID,
+		BaseCurrencyID:   req.BaseCurrencyID,
+		TargetCurrencyID: req.TargetCurrencyID,
+		Rate:             req.Rate,
+		RateDate:         rateDate,
+		Note:             req.Note,
+	}
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		previous, err := tx.UpsertUserExchangeRate(ctx, rate)
+		if err != nil {
+			return err
+		}
+		// Курс на ту же пару и дату заменяется — в журнал попадает прежнее значение
+		if previous != nil {
+			return s.audit.Record(ctx, tx, userID, models.AuditUpdate, AuditEntityUserExchangeRate, rate.ID, previous, rate)
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditCreate, AuditEntityUserExchangeRate, rate.ID, nil, rate)
+	}); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func (s *exchangeService) GetUserExchangeRates(ctx context.Context, userID int) ([]models.UserExchangeRate, error) {
+	return s.repo.GetUserExchangeRates(ctx, userID)
+}
+
+func (s *exchangeService) DeleteUserExchangeRate(ctx context.Context, userID, id int) error {
+	rate, err := s.repo.GetUserExchangeRateByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rate == nil || rate.UserID != userID {
+		return errors.New("exchange rate not found")
+	}
+
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.DeleteUserExchangeRate(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditDelete, AuditEntityUserExchangeRate, id, rate, nil)
+	})
+}
+
+// rateAuditChanges собирает изменения общих курсов для одной записи журнала;
+// ключ — пара и дата курса, например "USD/EUR 2024-03-01"
+type rateAuditChanges struct {
+	before map[string]money.Decimal
+	after  map[string]money.Decimal
+}
+
+func newRateAuditChanges() *rateAuditChanges {
+	return &rateAuditChanges{
+		before: make(map[string]money.Decimal),
+		after:  make(map[string]money.Decimal),
+	}
+}
+
+func (c *rateAuditChanges) add(base, target string, date time.Time, previous *money.Decimal, rate money.Decimal) {
+	key := base + "/" + target + " " + date.Format("2006-01-02")
+	if previous != nil {
+		c.before[key] = *previous
+	}
+	c.after[key] = rate
+}
+
+// record добавляет запись в журнал через tx, если курсы менялись
+func (c *rateAuditChanges) record(ctx context.Context, tx repository.Repository, audit AuditService) error {
+	if len(c.after) == 0 {
+		return nil
+	}
+	return audit.Record(ctx, tx, 0, models.AuditUpdate, AuditEntityExchangeRates, 0, c.before, c.after)
+}
+
+// convertMoney умножает сумму на курс и округляет до минимальной единицы валюты
+func convertMoney(amount, rate money.Decimal, currency *models.Currency, mode money.RoundingMode) money.Decimal {
+	return roundToCurrency(amount.Mul(rate), currency, mode)
+}
+
+// roundToCurrency округляет сумму до минимальной единицы валюты (по умолчанию — до сотых)
+func roundToCurrency(amount money.Decimal, currency *models.Currency, mode money.RoundingMode) money.Decimal {
+	places := int32(2)
+	if currency != nil {
+		places = currency.MinorUnits
+	}
+	return amount.Round(places, mode)
+}
+
+// isCurrentDate сообщает, относится ли дата к сегодняшнему дню или будущему
+func isCurrentDate(date time.Time) bool {
+	y, m, d := time.Now().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, date.Location())
+	return !date.Before(today)
+}
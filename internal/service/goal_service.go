@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"personal-finance-tracker/internal/repository"
+	"time"
+)
+
+// Окно, по которому оценивается скорость накопления
+const goalVelocityMonths = 3
+
+// Средняя длина месяца в днях для пересчета месяцев в даты
+const daysPerMonth = 30.44
+
+// Горизонт прогноза: дальше дата достижения цели не вычисляется
+const goalForecastYears = 100
+
+type GoalService interface {
+	CreateGoal(ctx context.Context, goal *models.SavingsGoal) error
+	GetUserGoals(ctx context.Context, userID int) ([]models.SavingsGoal, error)
+	GetGoalByID(ctx context.Context, id int) (*models.SavingsGoal, error)
+	UpdateGoal(ctx context.Context, goal *models.SavingsGoal) error
+	DeleteGoal(ctx context.Context, userID, goalID int) error
+	AddContribution(ctx context.Context, userID int, contribution *models.GoalContribution) error
+	GetContributions(ctx context.Context, userID, goalID int) ([]models.GoalContribution, error)
+}
+
+type goalService struct {
+	repo            repository.Repository
+	exchangeService ExchangeService
+	audit           AuditService
+}
+
+func NewGoalService(repo repository.Repository, exchangeService ExchangeService, audit AuditService) GoalService {
+	return &goalService{
+		repo:            repo,
+		exchangeService: exchangeService,
+		audit:           audit,
+	}
+}
+
+func (s *goalService) CreateGoal(ctx context.Context, goal *models.SavingsGoal) error {
+	if err := s.validateGoal(ctx, goal); err != nil {
+		return err
+	}
+
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.CreateSavingsGoal(ctx, goal); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, goal.UserID, models.AuditCreate, AuditEntitySavingsGoal, goal.ID, nil, goal)
+	}); err != nil {
+		return err
+	}
+
+	return s.attachProgress(ctx, goal)
+}
+
+func (s *goalService) GetUserGoals(ctx context.Context, userID int) ([]models.SavingsGoal, error) {
+	goals, err := s.repo.GetSavingsGoalsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range goals {
+		if err := s.attachProgress(ctx, &goals[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return goals, nil
+}
+
+func (s *goalService) GetGoalByID(ctx context.Context, id int) (*models.SavingsGoal, error) {
+	goal, err := s.repo.GetSavingsGoalByID(ctx, id)
+	if err != nil || goal == nil {
+		return goal, err
+	}
+
+	if err := s.attachProgress(ctx, goal); err != nil {
+		return nil, err
+	}
+
+	return goal, nil
+}
+
+func (s *goalService) UpdateGoal(ctx context.Context, goal *models.SavingsGoal) error {
+	before, err := s.getOwnedGoal(ctx, goal.UserID, goal.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.validateGoal(ctx, goal); err != nil {
+		return err
+	}
+
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.UpdateSavingsGoal(ctx, goal); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, goal.UserID, models.AuditUpdate, AuditEntitySavingsGoal, goal.ID, before, goal)
+	}); err != nil {
+		return err
+	}
+
+	return s.attachProgress(ctx, goal)
+}
+
+func (s *goalService) DeleteGoal(ctx context.Context, userID, goalID int) error {
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.DeleteSavingsGoal(ctx, goalID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditDelete, AuditEntitySavingsGoal, goalID, goal, nil)
+	})
+}
+
+func (s *goalService) AddContribution(ctx context.Context, userID int, contribution *models.GoalContribution) error {
+	goal, err := s.getOwnedGoal(ctx, userID, contribution.GoalID)
+	if err != nil {
+		return err
+	}
+
+	// Прогресс цели со счетом определяется балансом счета
+	if goal.AccountID != nil {
+		return errors.New("goal is linked to an account, manual contributions are not allowed")
+	}
+
+	currency, err := s.repo.GetCurrencyByID(ctx, goal.CurrencyID)
+	if err != nil {
+		return err
+	}
+	if err := checkPrecision(contribution.Amount, currency); err != nil {
+		return err
+	}
+
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.CreateGoalContribution(ctx, contribution); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditCreate, AuditEntityGoalContribution, contribution.ID, nil, contribution)
+	})
+}
+
+func (s *goalService) GetContributions(ctx context.Context, userID, goalID int) ([]models.GoalContribution, error) {
+	if _, err := s.getOwnedGoal(ctx, userID, goalID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetGoalContributions(ctx, goalID)
+}
+
+// getOwnedGoal возвращает цель, если она принадлежит пользователю
+func (s *goalService) getOwnedGoal(ctx context.Context, userID, goalID int) (*models.SavingsGoal, error) {
+	goal, err := s.repo.GetSavingsGoalByID(ctx, goalID)
+	if err != nil {
+		return nil, err
+	}
+	if goal == nil {
+		return nil, errors.New("goal not found")
+	}
+	if goal.UserID != userID {
+		return nil, errors.New("goal does not belong to user")
+	}
+
+	return goal, nil
+}
+
+func (s *goalService) validateGoal(ctx context.Context, goal *models.SavingsGoal) error {
+	currency, err := s.repo.GetCurrencyByID(ctx, goal.CurrencyID)
+	if err != nil {
+		return err
+	}
+	if currency == nil {
+		return errors.New("currency not found")
+	}
+	if err := checkPrecision(goal.TargetAmount, currency); err != nil {
+		return err
+	}
+
+	if goal.AccountID != nil {
+		account, err := s.repo.GetAccountByID(ctx, *goal.AccountID)
+		if err != nil {
+			return err
+		}
+		if account == nil {
+			return errors.New("account not found")
+		}
+		if account.UserID != goal.UserID {
+			return errors.New("account does not belong to user")
+		}
+	}
+
+	return nil
+}
+
+// attachProgress рассчитывает прогресс, требуемый ежемесячный взнос и прогноз даты достижения цели
+func (s *goalService) attachProgress(ctx context.Context, goal *models.SavingsGoal) error {
+	now := time.Now()
+	windowStart := now.AddDate(0, -goalVelocityMonths, 0)
+
+	currency, err := s.repo.GetCurrencyByID(ctx, goal.CurrencyID)
+	if err != nil {
+		return err
+	}
+
+	current, recent := money.Zero, money.Zero
+	if goal.AccountID != nil {
+		account, err := s.repo.GetAccountByID(ctx, *goal.AccountID)
+		if err != nil {
+			return err
+		}
+		if account == nil {
+			return errors.New("account not found")
+		}
+
+		// Скорость накопления — чистый приток по счету за последние месяцы
+		summary, err := s.repo.GetTransactionSummaryByAccountID(ctx, account.ID, windowStart, now)
+		if err != nil {
+			return err
+		}
+
+		current, recent = account.Balance, summary.NetAmount
+		if account.CurrencyID != goal.CurrencyID {
+			exchangeRate, err := s.exchangeService.GetUserExchangeRate(ctx, goal.UserID, account.CurrencyID, goal.CurrencyID, now)
+			if err != nil {
+				return err
+			}
+			current = convertMoney(current, exchangeRate.Rate, currency, money.RoundHalfEven)
+			recent = convertMoney(recent, exchangeRate.Rate, currency, money.RoundHalfEven)
+		}
+	} else {
+		contributions, err := s.repo.GetGoalContributions(ctx, goal.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range contributions {
+			current = current.Add(c.Amount)
+			if !c.Date.Before(windowStart) {
+				recent = recent.Add(c.Amount)
+			}
+		}
+	}
+
+	progress := &models.GoalProgress{
+		CurrentAmount:   money.Max(current, money.Zero),
+		RemainingAmount: money.Max(goal.TargetAmount.Sub(current), money.Zero),
+		MonthlyVelocity: roundToCurrency(recent.Div(money.NewFromInt(goalVelocityMonths), money.RateScale, money.RoundHalfEven), currency, money.RoundHalfEven),
+	}
+	if goal.TargetAmount.IsPositive() {
+		progress.ProgressPercent = math.Min(progress.CurrentAmount.Float64()/goal.TargetAmount.Float64()*100, 100)
+	}
+	progress.Completed = progress.RemainingAmount.IsZero()
+
+	if goal.Deadline != nil && !progress.Completed {
+		// До дедлайна осталось не меньше одного месяца, иначе вся сумма нужна сразу
+		months := math.Max(goal.Deadline.Sub(now).Hours()/24/daysPerMonth, 1)
+		// Взнос округляем вверх, чтобы при его соблюдении цель точно была достигнута
+		required := roundToCurrency(progress.RemainingAmount.Div(money.NewFromFloat(months), money.RateScale, money.RoundHalfEven), currency, money.RoundCeiling)
+		progress.RequiredMonthly = &required
+	}
+
+	var projected *time.Time
+	if progress.Completed {
+		projected = &now
+	} else if progress.MonthlyVelocity.IsPositive() {
+		days := progress.RemainingAmount.Float64() / progress.MonthlyVelocity.Float64() * daysPerMonth
+		// При малой скорости срок уходит за горизонт, и дата не прогнозируется
+		if days <= goalForecastYears*12*daysPerMonth {
+			date := now.AddDate(0, 0, int(math.Ceil(days)))
+			projected = &date
+		}
+	}
+
+	if projected != nil {
+		formatted := projected.Format("2006-01-02")
+		progress.ProjectedCompletion = &formatted
+	}
+
+	if goal.Deadline != nil {
+		onTrack := progress.Completed || (projected != nil && !projected.After(*goal.Deadline))
+		progress.OnTrack = &onTrack
+	}
+
+	goal.Progress = progress
+	return nil
+}
@@ -0,0 +1,575 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"personal-finance-tracker/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+type InvestmentService interface {
+	CreateTransaction(ctx context.Context, transaction *models.InvestmentTransaction) error
+	GetTransactions(ctx context.Context, userID, accountID int) ([]models.InvestmentTransaction, error)
+	GetHoldings(ctx context.Context, userID, accountID int, asOf time.Time) ([]models.Holding, error)
+	GetValuation(ctx context.Context, userID, accountID int) (*models.PortfolioValuation, error)
+	GetGains(ctx context.Context, userID, accountID int, start, end time.Time) (*models.GainsReport, error)
+	GetAllocation(ctx context.Context, userID, accountID int) ([]models.AllocationItem, error)
+	GetSecurities(ctx context.Context) ([]models.Security, error)
+	GetPrices(ctx context.Context, ticker string, start, end time.Time) ([]models.SecurityPrice, error)
+	ImportPrices(ctx context.Context, r io.Reader) (*models.PriceImportResult, error)
+	GetHoldingsValueAt(ctx context.Context, accountID int, asOf time.Time) (money.Decimal, error)
+}
+
+type investmentService struct {
+	repo  repository.Repository
+	audit AuditService
+}
+
+func NewInvestmentService(repo repository.Repository, audit AuditService) InvestmentService {
+	return &investmentService{repo: repo, audit: audit}
+}
+
+// position — открытые лоты одной бумаги при проигрывании истории операций
+type position struct {
+	security  models.Security
+	lots      []models.TaxLot
+	lastPrice money.Decimal
+	lastDate  time.Time
+}
+
+// portfolioState — результат проигрывания операций по FIFO
+type portfolioState struct {
+	positions map[int]*position
+	realized  []models.RealizedGain
+	dividends []models.InvestmentTransaction
+}
+
+func (s *investmentService) CreateTransaction(ctx context.Context, transaction *models.InvestmentTransaction) error {
+	account, err := s.getInvestmentAccount(ctx, transaction.UserID, transaction.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if transaction.Security == nil {
+		return errors.New("security is required")
+	}
+	ticker := normalizeTicker(transaction.Security.Ticker)
+	if ticker == "" {
+		return errors.New("ticker is required")
+	}
+
+	// Денежный эффект операции на остаток счета
+	switch transaction.Type {
+	case "buy", "sell":
+		if !transaction.Quantity.IsPositive() || !transaction.Price.IsPositive() {
+			return errors.New("quantity and price must be greater than zero")
+		}
+		gross := transaction.Quantity.Mul(transaction.Price)
+		if transaction.Type == "buy" {
+			transaction.Amount = gross.Add(transaction.Fee).Neg()
+		} else {
+			transaction.Amount = gross.Sub(transaction.Fee)
+		}
+	case "dividend":
+		if !transaction.Amount.IsPositive() {
+			return errors.New("dividend amount must be greater than zero")
+		}
+		transaction.Quantity = money.Zero
+		transaction.Price = money.Zero
+		transaction.Amount = transaction.Amount.Sub(transaction.Fee)
+	default:
+		return errors.New("invalid investment transaction type")
+	}
+	transaction.Amount = roundToCurrency(transaction.Amount, account.Currency, money.RoundHalfUp)
+
+	security, err := s.repo.GetSecurityByTicker(ctx, ticker)
+	if err != nil {
+		return err
+	}
+	if security == nil {
+		security = &models.Security{
+			Ticker:     ticker,
+			Name:       transaction.Security.Name,
+			AssetClass: transaction.Security.AssetClass,
+		}
+		if security.AssetClass == "" {
+			security.AssetClass = "stock"
+		}
+		if err := s.repo.CreateSecurity(ctx, security); err != nil {
+			return err
+		}
+	}
+	transaction.SecurityID = security.ID
+	transaction.Security = security
+
+	// Сделка, баланс счета и запись журнала сохраняются вместе
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		// Строка счета блокируется до конца транзакции: параллельные сделки по счету выполняются
+		// по очереди, и проверка позиции и новый баланс учитывают предыдущие
+		locked, err := tx.GetAccountByIDForUpdate(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+		if locked == nil {
+			return errors.New("account not found")
+		}
+
+		// Продажа не должна превышать позицию ни в один момент истории
+		if transaction.Type == "sell" {
+			history, err := tx.GetInvestmentTransactionsByAccountID(ctx, locked.ID)
+			if err != nil {
+				return err
+			}
+			candidate := *transaction
+			candidate.ID = math.MaxInt32
+			history = append(history, candidate)
+			sortInvestmentTransactions(history)
+
+			if _, err := replayTransactions(history, time.Time{}); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.CreateInvestmentTransaction(ctx, transaction); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, tx, transaction.UserID, models.AuditCreate, AuditEntityInvestmentTransaction, transaction.ID, nil, transaction); err != nil {
+			return err
+		}
+		return tx.UpdateAccountBalance(locked.ID, locked.Balance.Add(transaction.Amount))
+	})
+}
+
+func (s *investmentService) GetTransactions(ctx context.Context, userID, accountID int) ([]models.InvestmentTransaction, error) {
+	if _, err := s.getInvestmentAccount(ctx, userID, accountID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetInvestmentTransactionsByAccountID(ctx, accountID)
+}
+
+func (s *investmentService) GetHoldings(ctx context.Context, userID, accountID int, asOf time.Time) ([]models.Holding, error) {
+	if _, err := s.getInvestmentAccount(ctx, userID, accountID); err != nil {
+		return nil, err
+	}
+
+	state, err := s.loadState(ctx, accountID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.valueHoldings(ctx, state, asOf)
+}
+
+func (s *investmentService) GetValuation(ctx context.Context, userID, accountID int) (*models.PortfolioValuation, error) {
+	account, err := s.getInvestmentAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	state, err := s.loadState(ctx, accountID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	holdings, err := s.valueHoldings(ctx, state, now)
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := &models.PortfolioValuation{
+		AccountID: account.ID,
+		AsOf:      now.Format("2006-01-02"),
+		Cash:      account.Balance,
+		Holdings:  holdings,
+	}
+	for _, h := range holdings {
+		valuation.HoldingsValue = valuation.HoldingsValue.Add(h.MarketValue)
+		valuation.CostBasis = valuation.CostBasis.Add(h.CostBasis)
+		valuation.UnrealizedGain = valuation.UnrealizedGain.Add(h.UnrealizedGain)
+	}
+	valuation.MarketValue = valuation.Cash.Add(valuation.HoldingsValue)
+
+	return valuation, nil
+}
+
+func (s *investmentService) GetGains(ctx context.Context, userID, accountID int, start, end time.Time) (*models.GainsReport, error) {
+	if _, err := s.getInvestmentAccount(ctx, userID, accountID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	state, err := s.loadState(ctx, accountID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.GainsReport{
+		AccountID: accountID,
+		Realized:  []models.RealizedGain{},
+	}
+	if !start.IsZero() {
+		report.PeriodStart = start.Format("2006-01-02")
+	}
+	if !end.IsZero() {
+		report.PeriodEnd = end.Format("2006-01-02")
+	}
+
+	inPeriod := func(date time.Time) bool {
+		return (start.IsZero() || !date.Before(start)) && (end.IsZero() || !date.After(end))
+	}
+
+	for _, gain := range state.realized {
+		date, _ := time.Parse("2006-01-02", gain.SellDate)
+		if inPeriod(date) {
+			report.Realized = append(report.Realized, gain)
+			report.TotalRealizedGain = report.TotalRealizedGain.Add(gain.Gain)
+		}
+	}
+
+	for _, dividend := range state.dividends {
+		if inPeriod(dividend.Date) {
+			report.TotalDividends = report.TotalDividends.Add(dividend.Amount)
+		}
+	}
+
+	// Нереализованная прибыль — всегда по текущим котировкам
+	holdings, err := s.valueHoldings(ctx, state, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range holdings {
+		report.TotalUnrealizedGain = report.TotalUnrealizedGain.Add(h.UnrealizedGain)
+	}
+
+	return report, nil
+}
+
+func (s *investmentService) GetAllocation(ctx context.Context, userID, accountID int) ([]models.AllocationItem, error) {
+	valuation, err := s.GetValuation(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	byClass := make(map[string]money.Decimal)
+	for _, h := range valuation.Holdings {
+		byClass[h.AssetClass] = byClass[h.AssetClass].Add(h.MarketValue)
+	}
+	if valuation.Cash.IsPositive() {
+		byClass["cash"] = byClass["cash"].Add(valuation.Cash)
+	}
+
+	total := money.Zero
+	for _, value := range byClass {
+		total = total.Add(value)
+	}
+
+	result := make([]models.AllocationItem, 0, len(byClass))
+	for class, value := range byClass {
+		item := models.AllocationItem{AssetClass: class, MarketValue: value}
+		if total.IsPositive() {
+			item.Percentage = value.Float64() / total.Float64() * 100
+		}
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].MarketValue.GreaterThan(result[j].MarketValue)
+	})
+
+	return result, nil
+}
+
+// GetHoldingsValueAt возвращает рыночную стоимость позиций счета на дату (без денежного остатка)
+func (s *investmentService) GetHoldingsValueAt(ctx context.Context, accountID int, asOf time.Time) (money.Decimal, error) {
+	state, err := s.loadState(ctx, accountID, asOf)
+	if err != nil {
+		return money.Zero, err
+	}
+
+	holdings, err := s.valueHoldings(ctx, state, asOf)
+	if err != nil {
+		return money.Zero, err
+	}
+
+	total := money.Zero
+	for _, h := range holdings {
+		total = total.Add(h.MarketValue)
+	}
+
+	return total, nil
+}
+
+func (s *investmentService) GetSecurities(ctx context.Context) ([]models.Security, error) {
+	return s.repo.GetAllSecurities(ctx)
+}
+
+func (s *investmentService) GetPrices(ctx context.Context, ticker string, start, end time.Time) ([]models.SecurityPrice, error) {
+	security, err := s.repo.GetSecurityByTicker(ctx, normalizeTicker(ticker))
+	if err != nil {
+		return nil, err
+	}
+	if security == nil {
+		return nil, errors.New("security not found")
+	}
+
+	return s.repo.GetSecurityPrices(ctx, security.ID, start, end)
+}
+
+// ImportPrices загружает котировки из CSV: date,ticker,price[,asset_class]
+func (s *investmentService) ImportPrices(ctx context.Context, r io.Reader) (*models.PriceImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	result := &models.PriceImportResult{Errors: []string{}}
+	securities := make(map[string]*models.Security)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %v", err)
+		}
+
+		// Пропускаем заголовок и пустые строки
+		if len(record) == 0 || (line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date")) {
+			continue
+		}
+		if len(record) < 3 {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: expected date,ticker,price", line))
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: invalid date %q", line, record[0]))
+			continue
+		}
+
+		ticker := normalizeTicker(record[1])
+		if ticker == "" {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: empty ticker", line))
+			continue
+		}
+
+		price, err := money.Parse(record[2])
+		if err != nil || !price.IsPositive() {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: invalid price %q", line, record[2]))
+			continue
+		}
+
+		security, ok := securities[ticker]
+		if !ok {
+			security, err = s.repo.GetSecurityByTicker(ctx, ticker)
+			if err != nil {
+				return nil, err
+			}
+			if security == nil {
+				security = &models.Security{Ticker: ticker, AssetClass: "other"}
+				if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+					security.AssetClass = strings.ToLower(strings.TrimSpace(record[3]))
+				}
+				if err := s.repo.CreateSecurity(ctx, security); err != nil {
+					result.Skipped++
+					result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+					continue
+				}
+			}
+			securities[ticker] = security
+		}
+
+		err = s.repo.UpsertSecurityPrice(ctx, &models.SecurityPrice{
+			SecurityID: security.ID,
+			Date:       date,
+			Price:      price,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Imported++
+	}
+
+	return result, nil
+}
+
+// getInvestmentAccount проверяет, что счет существует, принадлежит пользователю и является инвестиционным
+func (s *investmentService) getInvestmentAccount(ctx context.Context, userID, accountID int) (*models.Account, error) {
+	account, err := s.repo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("account not found")
+	}
+	if account.UserID != userID {
+		return nil, errors.New("account does not belong to user")
+	}
+	if account.Kind != "investment" {
+		return nil, errors.New("account is not an investment account")
+	}
+
+	return account, nil
+}
+
+func (s *investmentService) loadState(ctx context.Context, accountID int, asOf time.Time) (*portfolioState, error) {
+	transactions, err := s.repo.GetInvestmentTransactionsByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return replayTransactions(transactions, asOf)
+}
+
+// valueHoldings оценивает открытые позиции по котировке на дату (или по цене последней сделки)
+func (s *investmentService) valueHoldings(ctx context.Context, state *portfolioState, asOf time.Time) ([]models.Holding, error) {
+	holdings := make([]models.Holding, 0, len(state.positions))
+
+	for securityID, pos := range state.positions {
+		holding := models.Holding{
+			SecurityID: securityID,
+			Ticker:     pos.security.Ticker,
+			Name:       pos.security.Name,
+			AssetClass: pos.security.AssetClass,
+			Lots:       pos.lots,
+		}
+		for _, lot := range pos.lots {
+			holding.Quantity = holding.Quantity.Add(lot.Quantity)
+			holding.CostBasis = holding.CostBasis.Add(lot.CostBasis)
+		}
+		if !holding.Quantity.IsPositive() {
+			continue
+		}
+
+		price, err := s.repo.GetSecurityPriceAt(ctx, securityID, asOf)
+		if err != nil {
+			return nil, err
+		}
+		if price != nil && !price.Date.Before(pos.lastDate) {
+			holding.Price = price.Price
+			holding.PriceDate = price.Date.Format("2006-01-02")
+			holding.PriceSource = "price_table"
+		} else {
+			holding.Price = pos.lastPrice
+			holding.PriceDate = pos.lastDate.Format("2006-01-02")
+			holding.PriceSource = "last_trade"
+		}
+
+		holding.MarketValue = holding.Quantity.Mul(holding.Price)
+		holding.UnrealizedGain = holding.MarketValue.Sub(holding.CostBasis)
+		if holding.CostBasis.IsPositive() {
+			holding.UnrealizedGainPercent = holding.UnrealizedGain.Float64() / holding.CostBasis.Float64() * 100
+		}
+
+		holdings = append(holdings, holding)
+	}
+
+	sort.Slice(holdings, func(i, j int) bool {
+		return holdings[i].Ticker < holdings[j].Ticker
+	})
+
+	return holdings, nil
+}
+
+// replayTransactions проигрывает операции в хронологическом порядке, закрывая лоты по FIFO.
+// Нулевая asOf означает «все операции».
+func replayTransactions(transactions []models.InvestmentTransaction, asOf time.Time) (*portfolioState, error) {
+	state := &portfolioState{positions: make(map[int]*position)}
+
+	for _, t := range transactions {
+		if !asOf.IsZero() && t.Date.After(asOf) {
+			break
+		}
+
+		pos, ok := state.positions[t.SecurityID]
+		if !ok {
+			pos = &position{}
+			if t.Security != nil {
+				pos.security = *t.Security
+			}
+			state.positions[t.SecurityID] = pos
+		}
+
+		switch t.Type {
+		case "buy":
+			cost := t.Quantity.Mul(t.Price).Add(t.Fee)
+			pos.lots = append(pos.lots, models.TaxLot{
+				TransactionID: t.ID,
+				AcquiredDate:  t.Date,
+				Quantity:      t.Quantity,
+				CostPerUnit:   cost.Div(t.Quantity, money.RateScale, money.RoundHalfEven).Normalize(),
+				CostBasis:     cost,
+			})
+			pos.lastPrice = t.Price
+			pos.lastDate = t.Date
+		case "sell":
+			remaining := t.Quantity
+			costBasis := money.Zero
+			for remaining.IsPositive() && len(pos.lots) > 0 {
+				lot := &pos.lots[0]
+				used := money.Min(lot.Quantity, remaining)
+				// Стоимость списываем пропорционально, чтобы сумма частей совпала со стоимостью лота
+				usedCost := lot.CostBasis
+				if used.LessThan(lot.Quantity) {
+					usedCost = lot.CostBasis.Mul(used).Div(lot.Quantity, money.RateScale, money.RoundHalfEven).Normalize()
+				}
+				costBasis = costBasis.Add(usedCost)
+				lot.Quantity = lot.Quantity.Sub(used)
+				lot.CostBasis = lot.CostBasis.Sub(usedCost)
+				remaining = remaining.Sub(used)
+				if lot.Quantity.IsZero() {
+					pos.lots = pos.lots[1:]
+				}
+			}
+			if remaining.IsPositive() {
+				return nil, fmt.Errorf("cannot sell %s %s on %s: insufficient quantity",
+					t.Quantity, pos.security.Ticker, t.Date.Format("2006-01-02"))
+			}
+
+			proceeds := t.Quantity.Mul(t.Price).Sub(t.Fee)
+			state.realized = append(state.realized, models.RealizedGain{
+				TransactionID: t.ID,
+				Ticker:        pos.security.Ticker,
+				SellDate:      t.Date.Format("2006-01-02"),
+				Quantity:      t.Quantity,
+				Proceeds:      proceeds,
+				CostBasis:     costBasis,
+				Gain:          proceeds.Sub(costBasis),
+			})
+			pos.lastPrice = t.Price
+			pos.lastDate = t.Date
+		case "dividend":
+			state.dividends = append(state.dividends, t)
+		}
+	}
+
+	return state, nil
+}
+
+func sortInvestmentTransactions(transactions []models.InvestmentTransaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if !transactions[i].Date.Equal(transactions[j].Date) {
+			return transactions[i].Date.Before(transactions[j].Date)
+		}
+		return transactions[i].ID < transactions[j].ID
+	})
+}
+
+func normalizeTicker(ticker string) string {
+	return strings.ToUpper(strings.TrimSpace(ticker))
+}
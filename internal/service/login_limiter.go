@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"personal-finance-tracker/internal/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Хранилища счетчиков для конфигурации LOGIN_LIMITER_STORE
+const (
+	LimiterStoreMemory   = "memory"
+	LimiterStorePostgres = "postgres"
+)
+
+// Виды ключей ограничителя
+const (
+	LimitLoginIP      = "login-ip"      // неудачные входы с одного IP
+	LimitLoginAccount = "login-account" // неудачные входы в одну учетную запись
+	LimitRegisterIP   = "register-ip"   // регистрации с одного IP
+	LimitOIDCStartIP  = "oidc-start-ip" // начатые входы через OIDC с одного IP
+	LimitResetIP      = "reset-ip"      // запросы сброса пароля с одного IP
+	LimitResetEmail   = "reset-email"   // запросы сброса пароля для одного email
+)
+
+// Первая задержка после бесплатных попыток; дальше она удваивается
+const limiterBackoffBase = time.Second
+
+// Через сколько после последней неудачи счетчик обнуляется
+const limiterFailureWindow = time.Hour
+
+// LimiterStore хранит счетчики неудачных попыток. Реализация в памяти подходит для
+// одного экземпляра, Postgres — для нескольких экземпляров за балансировщиком.
+type LimiterStore interface {
+	// RecordLoginAttempt атомарно учитывает попытку и блокирует ключ на delay(счетчик);
+	// если ключ уже заблокирован, попытка не учитывается и возвращается срок блокировки
+	RecordLoginAttempt(ctx context.Context, key string, at, resetBefore time.Time, delay func(attempts int) time.Duration) (*time.Time, error)
+	// ReleaseLoginAttempt отменяет учтенную попытку и снятую ею блокировку
+	ReleaseLoginAttempt(ctx context.Context, key string) error
+	ResetLoginThrottles(ctx context.Context, keys []string) error
+	PurgeLoginThrottles(ctx context.Context, failedBefore, now time.Time) (int64, error)
+}
+
+// NewLimiterStore создает хранилище счетчиков по имени
+func NewLimiterStore(name string, repo repository.Repository) (LimiterStore, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case LimiterStoreMemory:
+		return &memoryLimiterStore{throttles: make(map[string]*models.LoginThrottle)}, nil
+	case LimiterStorePostgres:
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown limiter store: %s", name)
+	}
+}
+
+// LimitKey — ключ ограничителя: вид (LimitLoginIP и т.д.) и значение (IP или email)
+type LimitKey struct {
+	Kind  string
+	Value string
+}
+
+// String возвращает ключ хранилища; значение хешируется, чтобы длина ключа не зависела
+// от длины email и в таблице не хранились адреса
+func (k LimitKey) String() string {
+	return k.Kind + ":" + utils.HashToken(strings.ToLower(strings.TrimSpace(k.Value)))
+}
+
+// LimitPolicy — сколько неудач допускается без задержки и после скольких ключ блокируется
+type LimitPolicy struct {
+	FreeAttempts int
+	// LockoutThreshold — число неудач до блокировки на LockoutDuration; 0 — без блокировки
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// LoginLimiterConfig — политики для видов ключей и предельная задержка
+type LoginLimiterConfig struct {
+	Policies   map[string]LimitPolicy
+	BackoffMax time.Duration
+}
+
+// RateLimitError — попытки по ключу временно отклоняются
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return "too many attempts, try again later"
+}
+
+// LoginLimiter защищает вход и регистрацию от перебора: после FreeAttempts неудач каждая
+// следующая откладывает новые попытки вдвое дольше, а после LockoutThreshold ключ блокируется.
+// Попытка учитывается до проверки пароля, поэтому одновременные запросы не обходят лимит;
+// успешная попытка затем отменяется через Release или Reset.
+type LoginLimiter interface {
+	// Attempt учитывает попытку по каждому ключу и возвращает *RateLimitError,
+	// если хотя бы один из ключей заблокирован (тогда попытка не учитывается)
+	Attempt(ctx context.Context, keys ...LimitKey) error
+	// Release отменяет учтенную попытку, оказавшуюся успешной
+	Release(ctx context.Context, keys ...LimitKey) error
+	// Reset обнуляет счетчики ключей
+	Reset(ctx context.Context, keys ...LimitKey) error
+	// Purge удаляет устаревшие счетчики; вызывается периодически
+	Purge(ctx context.Context) (int64, error)
+}
+
+type loginLimiter struct {
+	store LimiterStore
+	cfg   LoginLimiterConfig
+}
+
+func NewLoginLimiter(store LimiterStore, cfg LoginLimiterConfig) LoginLimiter {
+	return &loginLimiter{store: store, cfg: cfg}
+}
+
+func (l *loginLimiter) Attempt(ctx context.Context, keys ...LimitKey) error {
+	now := time.Now()
+	for i, key := range keys {
+		policy := l.cfg.Policies[key.Kind]
+		blockedUntil, err := l.store.RecordLoginAttempt(ctx, key.String(), now, now.Add(-limiterFailureWindow), func(attempts int) time.Duration {
+			return l.delay(policy, attempts)
+		})
+		if err != nil {
+			return err
+		}
+
+		if blockedUntil != nil {
+			// Отклоненная попытка не должна учитываться и по уже пройденным ключам
+			if err := l.Release(ctx, keys[:i]...); err != nil {
+				return err
+			}
+			return &RateLimitError{RetryAfter: blockedUntil.Sub(now)}
+		}
+	}
+	return nil
+}
+
+func (l *loginLimiter) Release(ctx context.Context, keys ...LimitKey) error {
+	for _, key := range keys {
+		if err := l.store.ReleaseLoginAttempt(ctx, key.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *loginLimiter) Reset(ctx context.Context, keys ...LimitKey) error {
+	return l.store.ResetLoginThrottles(ctx, limitKeyStrings(keys))
+}
+
+func (l *loginLimiter) Purge(ctx context.Context) (int64, error) {
+	now := time.Now()
+	return l.store.PurgeLoginThrottles(ctx, now.Add(-limiterFailureWindow), now)
+}
+
+// delay возвращает, на сколько отложить попытки после failures неудач подряд
+func (l *loginLimiter) delay(policy LimitPolicy, failures int) time.Duration {
+	var delay time.Duration
+	if excess := failures - policy.FreeAttempts; excess > 0 {
+		delay = l.cfg.BackoffMax
+		// Сдвиг ограничен, чтобы не переполнить Duration
+		if excess <= 30 {
+			delay = min(limiterBackoffBase<<(excess-1), l.cfg.BackoffMax)
+		}
+	}
+	if policy.LockoutThreshold > 0 && failures >= policy.LockoutThreshold {
+		delay = max(delay, policy.LockoutDuration)
+	}
+	return delay
+}
+
+func limitKeyStrings(keys []LimitKey) []string {
+	result := make([]string, len(keys))
+	for i, key := range keys {
+		result[i] = key.String()
+	}
+	return result
+}
+
+// memoryLimiterStore хранит счетчики в памяти процесса
+type memoryLimiterStore struct {
+	mu        sync.Mutex
+	throttles map[string]*models.LoginThrottle
+}
+
+func (s *memoryLimiterStore) RecordLoginAttempt(ctx context.Context, key string, at, resetBefore time.Time, delay func(attempts int) time.Duration) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	throttle, ok := s.throttles[key]
+	if !ok {
+		throttle = &models.LoginThrottle{Key: key}
+		s.throttles[key] = throttle
+	}
+	if throttle.BlockedUntil != nil && throttle.BlockedUntil.After(at) {
+		blockedUntil := *throttle.BlockedUntil
+		return &blockedUntil, nil
+	}
+
+	if throttle.LastFailureAt.Before(resetBefore) {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = at
+	if d := delay(throttle.Failures); d > 0 {
+		blockedUntil := at.Add(d)
+		throttle.BlockedUntil = &blockedUntil
+	}
+	return nil, nil
+}
+
+func (s *memoryLimiterStore) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if throttle, ok := s.throttles[key]; ok {
+		throttle.Failures = max(throttle.Failures-1, 0)
+		throttle.BlockedUntil = nil
+	}
+	return nil
+}
+
+func (s *memoryLimiterStore) ResetLoginThrottles(ctx context.Context, keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		delete(s.throttles, key)
+	}
+	return nil
+}
+
+func (s *memoryLimiterStore) PurgeLoginThrottles(ctx context.Context, failedBefore, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for key, throttle := range s.throttles {
+		if throttle.LastFailureAt.Before(failedBefore) && (throttle.BlockedUntil == nil || throttle.BlockedUntil.Before(now)) {
+			delete(s.throttles, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(policies map[string]LimitPolicy) (*loginLimiter, *memoryLimiterStore) {
+	store := &memoryLimiterStore{throttles: make(map[string]*models.LoginThrottle)}
+	limiter := NewLoginLimiter(store, LoginLimiterConfig{Policies: policies, BackoffMax: 15 * time.Minute})
+	return limiter.(*loginLimiter), store
+}
+
+// failures возвращает счетчик ключа в хранилище
+func (s *memoryLimiterStore) failures(key LimitKey) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if throttle, ok := s.throttles[key.String()]; ok {
+		return throttle.Failures
+	}
+	return 0
+}
+
+func TestLimiterDelay(t *testing.T) {
+	limiter, _ := newTestLimiter(nil)
+	backoff := LimitPolicy{FreeAttempts: 3}
+	lockout := LimitPolicy{FreeAttempts: 3, LockoutThreshold: 6, LockoutDuration: time.Hour}
+
+	tests := []struct {
+		policy   LimitPolicy
+		failures int
+		want     time.Duration
+	}{
+		{backoff, 1, 0},
+		{backoff, 3, 0},
+		{backoff, 4, time.Second},
+		{backoff, 5, 2 * time.Second},
+		{backoff, 6, 4 * time.Second},
+		{backoff, 13, 512 * time.Second},
+		{backoff, 14, 15 * time.Minute},
+		{backoff, 33, 15 * time.Minute},
+		{backoff, 1000, 15 * time.Minute},
+		{lockout, 5, 2 * time.Second},
+		{lockout, 6, time.Hour},
+		{lockout, 100, time.Hour},
+		{LimitPolicy{FreeAttempts: 0}, 1, time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := limiter.delay(tt.policy, tt.failures); got != tt.want {
+			t.Errorf("delay(%+v, %d) = %s, want %s", tt.policy, tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterFreeAttempts(t *testing.T) {
+	tests := []struct {
+		freeAttempts int
+		// allowed — сколько попыток подряд проходит до первого отказа
+		allowed int
+	}{
+		{0, 1},
+		{1, 2},
+		{5, 6},
+	}
+
+	for _, tt := range tests {
+		limiter, store := newTestLimiter(map[string]LimitPolicy{LimitLoginIP: {FreeAttempts: tt.freeAttempts}})
+		key := LimitKey{Kind: LimitLoginIP, Value: "203.0.113.7"}
+
+		for i := 1; i <= tt.allowed; i++ {
+			if err := limiter.Attempt(context.Background(), key); err != nil {
+				t.Fatalf("free attempts %d: attempt %d error: %v", tt.freeAttempts, i, err)
+			}
+		}
+
+		// Попытка сверх бесплатных откладывает следующие на 1 с
+		err := limiter.Attempt(context.Background(), key)
+		var limitErr *RateLimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("free attempts %d: attempt %d error = %v, want *RateLimitError", tt.freeAttempts, tt.allowed+1, err)
+		}
+		if limitErr.RetryAfter <= 0 || limitErr.RetryAfter > time.Second {
+			t.Errorf("free attempts %d: RetryAfter = %s, want (0, 1s]", tt.freeAttempts, limitErr.RetryAfter)
+		}
+		// Отклоненная попытка не учитывается
+		if got := store.failures(key); got != tt.allowed {
+			t.Errorf("free attempts %d: failures = %d, want %d", tt.freeAttempts, got, tt.allowed)
+		}
+	}
+}
+
+func TestLimiterLockoutThreshold(t *testing.T) {
+	limiter, _ := newTestLimiter(map[string]LimitPolicy{
+		LimitLoginAccount: {FreeAttempts: 100, LockoutThreshold: 3, LockoutDuration: 30 * time.Minute},
+	})
+	key := LimitKey{Kind: LimitLoginAccount, Value: "user@example.com"}
+
+	for i := 1; i <= 3; i++ {
+		if err := limiter.Attempt(context.Background(), key); err != nil {
+			t.Fatalf("attempt %d error: %v", i, err)
+		}
+	}
+
+	var limitErr *RateLimitError
+	if err := limiter.Attempt(context.Background(), key); !errors.As(err, &limitErr) {
+		t.Fatalf("attempt after lockout error = %v, want *RateLimitError", err)
+	}
+	if limitErr.RetryAfter < 29*time.Minute || limitErr.RetryAfter > 30*time.Minute {
+		t.Errorf("RetryAfter = %s, want about 30m", limitErr.RetryAfter)
+	}
+
+	// Блокировка по учетной записи не затрагивает другие ключи
+	other := LimitKey{Kind: LimitLoginAccount, Value: "other@example.com"}
+	if err := limiter.Attempt(context.Background(), other); err != nil {
+		t.Errorf("attempt for another account error: %v", err)
+	}
+}
+
+func TestLimiterRejectedKeyReleasesOthers(t *testing.T) {
+	limiter, store := newTestLimiter(map[string]LimitPolicy{
+		LimitLoginIP:      {FreeAttempts: 100},
+		LimitLoginAccount: {FreeAttempts: 100, LockoutThreshold: 1, LockoutDuration: time.Hour},
+	})
+	ipKey := LimitKey{Kind: LimitLoginIP, Value: "203.0.113.7"}
+	accountKey := LimitKey{Kind: LimitLoginAccount, Value: "user@example.com"}
+
+	if err := limiter.Attempt(context.Background(), ipKey, accountKey); err != nil {
+		t.Fatalf("first attempt error: %v", err)
+	}
+	if err := limiter.Attempt(context.Background(), ipKey, accountKey); err == nil {
+		t.Fatal("attempt on a locked account did not fail")
+	}
+
+	// Попытка, отклоненная по учетной записи, не учитывается и по IP
+	if got := store.failures(ipKey); got != 1 {
+		t.Errorf("ip failures = %d, want 1", got)
+	}
+	if got := store.failures(accountKey); got != 1 {
+		t.Errorf("account failures = %d, want 1", got)
+	}
+}
+
+func TestLimiterReleaseAndReset(t *testing.T) {
+	limiter, store := newTestLimiter(map[string]LimitPolicy{LimitLoginIP: {FreeAttempts: 1}})
+	ctx := context.Background()
+	key := LimitKey{Kind: LimitLoginIP, Value: "203.0.113.7"}
+
+	tests := []struct {
+		name         string
+		attempts     int
+		undo         func() error
+		wantFailures int
+	}{
+		{"release after block", 2, func() error { return limiter.Release(ctx, key) }, 1},
+		{"release twice", 1, func() error { limiter.Release(ctx, key); return limiter.Release(ctx, key) }, 0},
+		{"reset", 2, func() error { return limiter.Reset(ctx, key) }, 0},
+	}
+
+	for _, tt := range tests {
+		limiter.Reset(ctx, key)
+		for i := 0; i < tt.attempts; i++ {
+			if err := limiter.Attempt(ctx, key); err != nil {
+				t.Fatalf("%s: attempt %d error: %v", tt.name, i+1, err)
+			}
+		}
+
+		if err := tt.undo(); err != nil {
+			t.Fatalf("%s: error: %v", tt.name, err)
+		}
+		if got := store.failures(key); got != tt.wantFailures {
+			t.Errorf("%s: failures = %d, want %d", tt.name, got, tt.wantFailures)
+		}
+		// Снятая блокировка позволяет сразу попробовать снова
+		if err := limiter.Attempt(ctx, key); err != nil {
+			t.Errorf("%s: attempt after undo error: %v", tt.name, err)
+		}
+	}
+}
+
+func TestLimiterFailureWindow(t *testing.T) {
+	limiter, store := newTestLimiter(map[string]LimitPolicy{LimitLoginIP: {FreeAttempts: 3}})
+	key := LimitKey{Kind: LimitLoginIP, Value: "203.0.113.7"}
+
+	// Старые неудачи без блокировки забываются после limiterFailureWindow
+	store.throttles[key.String()] = &models.LoginThrottle{
+		Key:           key.String(),
+		Failures:      50,
+		LastFailureAt: time.Now().Add(-limiterFailureWindow - time.Minute),
+	}
+
+	if err := limiter.Attempt(context.Background(), key); err != nil {
+		t.Fatalf("attempt error: %v", err)
+	}
+	if got := store.failures(key); got != 1 {
+		t.Errorf("failures = %d, want 1", got)
+	}
+}
+
+func TestLimiterPurge(t *testing.T) {
+	limiter, store := newTestLimiter(nil)
+	now := time.Now()
+	old := now.Add(-limiterFailureWindow - time.Minute)
+	expired := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		key          string
+		lastFailure  time.Time
+		blockedUntil *time.Time
+		purged       bool
+	}{
+		{"old", old, nil, true},
+		{"old-block-expired", old, &expired, true},
+		{"old-still-blocked", old, &future, false},
+		{"recent", now, nil, false},
+	}
+	for _, tt := range tests {
+		store.throttles[tt.key] = &models.LoginThrottle{Key: tt.key, Failures: 1, LastFailureAt: tt.lastFailure, BlockedUntil: tt.blockedUntil}
+	}
+
+	purged, err := limiter.Purge(context.Background())
+	if err != nil {
+		t.Fatalf("Purge error: %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("purged = %d, want 2", purged)
+	}
+	for _, tt := range tests {
+		if _, kept := store.throttles[tt.key]; kept == tt.purged {
+			t.Errorf("%s: kept = %v, want %v", tt.key, kept, !tt.purged)
+		}
+	}
+}
+
+func TestLimitKeyString(t *testing.T) {
+	key := LimitKey{Kind: LimitLoginAccount, Value: " User@Example.COM "}
+
+	// Значение хешируется после приведения к нижнему регистру, адрес в ключе не хранится
+	want := LimitLoginAccount + ":" + utils.HashToken("user@example.com")
+	if got := key.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	if strings.Contains(strings.ToLower(key.String()), "example") {
+		t.Errorf("String() = %s contains the raw value", key)
+	}
+	if (LimitKey{Kind: LimitLoginIP, Value: "user@example.com"}).String() == want {
+		t.Error("keys of different kinds collide")
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(ctx context.Context, message *MailMessage) error
+}
+
+// MailMessage — письмо в виде простого текста
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Способы доставки писем для конфигурации MAILER
+const (
+	MailerSMTP = "smtp"
+	MailerLog  = "log"
+)
+
+// MailerConfig — параметры доставки писем
+type MailerConfig struct {
+	From string
+	// SMTP-сервер; при наличии Username используется PLAIN-аутентификация (после STARTTLS)
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	// Файл для писем способа log; пустой — письма выводятся в лог сервера
+	LogFile string
+}
+
+// NewMailer создает способ доставки писем по имени
+func NewMailer(name string, cfg MailerConfig) (Mailer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case MailerSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("smtp mailer requires SMTP_HOST")
+		}
+		from, err := mail.ParseAddress(cfg.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+		}
+		return &smtpMailer{cfg: cfg, envelopeFrom: from.Address}, nil
+	case MailerLog:
+		return &logMailer{from: cfg.From, path: cfg.LogFile}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer: %s", name)
+	}
+}
+
+// Предельное время отправки письма, если у контекста нет своего срока
+const smtpTimeout = 30 * time.Second
+
+// smtpMailer отправляет письма через SMTP-сервер
+type smtpMailer struct {
+	cfg          MailerConfig
+	envelopeFrom string // адрес отправителя без имени — для команды MAIL FROM
+}
+
+func (m *smtpMailer) Send(ctx context.Context, message *MailMessage) (err error) {
+	addr := net.JoinHostPort(m.cfg.SMTPHost, m.cfg.SMTPPort)
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Весь диалог с сервером ограничен сроком контекста (или smtpTimeout), а отмена
+	// контекста закрывает соединение и прерывает ожидание ответа
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer func() {
+		if !stop() && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	}()
+
+	client, err := smtp.NewClient(conn, m.cfg.SMTPHost)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.SMTPHost}); err != nil {
+			return err
+		}
+	}
+	if m.cfg.SMTPUsername != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		auth := smtp.PlainAuth("", m.cfg.SMTPUsername, m.cfg.SMTPPassword, m.cfg.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.envelopeFrom); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(formatMail(m.cfg.From, message)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// logMailer записывает письма в файл или лог сервера — для локальной разработки
+type logMailer struct {
+	from string
+	path string
+	mu   sync.Mutex
+}
+
+func (m *logMailer) Send(ctx context.Context, message *MailMessage) error {
+	if m.path == "" {
+		log.Printf("Mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(formatMail(m.from, message), "\r\n\r\n"...))
+	return err
+}
+
+// formatMail собирает письмо в формате RFC 5322; тема кодируется для не-ASCII символов
+func formatMail(from string, message *MailMessage) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + message.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", message.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpStandIn — минимальный SMTP-сервер в процессе теста: принимает одно письмо
+// и запоминает команды конверта и данные
+type smtpStandIn struct {
+	listener   net.Listener
+	extensions []string
+	// hangAfter — команда, после которой сервер перестает отвечать; "greeting" — до приветствия
+	hangAfter string
+
+	mu       sync.Mutex
+	commands []string
+	data     []byte
+	done     chan struct{}
+}
+
+func startSMTPStandIn(t *testing.T, extensions []string, hangAfter string) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	server := &smtpStandIn{listener: listener, extensions: extensions, hangAfter: hangAfter, done: make(chan struct{})}
+	go server.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		<-server.done
+	})
+	return server
+}
+
+// mailer создает SMTP-мейлер, направленный на этот сервер
+func (s *smtpStandIn) mailer(t *testing.T, username string) Mailer {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	mailer, err := NewMailer(MailerSMTP, MailerConfig{
+		From:         "Finance Tracker <no-reply@example.com>",
+		SMTPHost:     host,
+		SMTPPort:     port,
+		SMTPUsername: username,
+		SMTPPassword: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewMailer error: %v", err)
+	}
+	return mailer
+}
+
+func (s *smtpStandIn) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	// Зависший сервер держит соединение, пока его не закроет клиент
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if s.hangAfter == "greeting" {
+		io.Copy(io.Discard, conn)
+		return
+	}
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " ")[0])
+
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		if verb == s.hangAfter {
+			io.Copy(io.Discard, conn)
+			return
+		}
+
+		switch verb {
+		case "EHLO":
+			lines := append([]string{"localhost"}, s.extensions...)
+			for i, ext := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, ext)
+			}
+		case "AUTH":
+			tp.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL", "RCPT":
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			// Данные читаются как есть, с переводами строк CRLF, только без точки в конце
+			var data []byte
+			for {
+				line, err := tp.R.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data = append(data, strings.TrimPrefix(line, ".")...)
+			}
+			s.mu.Lock()
+			s.data = data
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK: queued")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// received ждет завершения диалога и возвращает команды и данные письма
+func (s *smtpStandIn) received(t *testing.T) ([]string, []byte) {
+	t.Helper()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("smtp stand-in did not finish")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands, s.data
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := startSMTPStandIn(t, []string{"8BITMIME"}, "")
+
+	message := &MailMessage{
+		To:      "user@example.com",
+		Subject: "Сброс пароля",
+		Body:    "Здравствуйте!\nКод: 123456\n",
+	}
+	if err := server.mailer(t, "").Send(context.Background(), message); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+
+	commands, data := server.received(t)
+
+	// В конверте — адрес отправителя без имени, без AUTH: имя пользователя не задано
+	var envelope []string
+	for _, command := range commands {
+		if verb := strings.Fields(command)[0]; verb != "EHLO" {
+			envelope = append(envelope, verb)
+		}
+	}
+	if got := strings.Join(envelope, " "); got != "MAIL RCPT DATA QUIT" {
+		t.Errorf("commands = %s, want MAIL RCPT DATA QUIT", got)
+	}
+	if !strings.HasPrefix(commands[1], "MAIL FROM:<no-reply@example.com>") {
+		t.Errorf("MAIL command = %q", commands[1])
+	}
+	if commands[2] != "RCPT TO:<user@example.com>" {
+		t.Errorf("RCPT command = %q", commands[2])
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("received message is not RFC 5322: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	headers := map[string]string{
+		"From":         "Finance Tracker <no-reply@example.com>",
+		"To":           "user@example.com",
+		"Subject":      "Сброс пароля",
+		"Content-Type": "text/plain; charset=UTF-8",
+	}
+	for name, want := range headers {
+		got := parsed.Header.Get(name)
+		if name == "Subject" {
+			got = subject
+		}
+		if got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := parsed.Header.Date(); err != nil {
+		t.Errorf("invalid Date header: %v", err)
+	}
+
+	body, _ := io.ReadAll(parsed.Body)
+	if got, want := string(body), "Здравствуйте!\r\nКод: 123456\r\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestSMTPMailerAuth(t *testing.T) {
+	server := startSMTPStandIn(t, []string{"AUTH PLAIN"}, "")
+
+	if err := server.mailer(t, "mailer").Send(context.Background(), &MailMessage{To: "user@example.com", Subject: "s", Body: "b"}); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+
+	commands, _ := server.received(t)
+	want := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00mailer\x00secret"))
+	if len(commands) < 2 || commands[1] != want {
+		t.Errorf("commands = %q, want AUTH PLAIN after EHLO", commands)
+	}
+}
+
+func TestSMTPMailerRequiresAuthSupport(t *testing.T) {
+	server := startSMTPStandIn(t, nil, "")
+
+	if err := server.mailer(t, "mailer").Send(context.Background(), &MailMessage{To: "user@example.com", Subject: "s", Body: "b"}); err == nil {
+		t.Error("Send with credentials to a server without AUTH did not fail")
+	}
+}
+
+func TestSMTPMailerDeadline(t *testing.T) {
+	// Сервер принимает соединение, но не присылает приветствие
+	server := startSMTPStandIn(t, nil, "greeting")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := server.mailer(t, "").Send(ctx, &MailMessage{To: "user@example.com", Subject: "s", Body: "b"})
+	if err == nil {
+		t.Fatal("Send to a silent server did not fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send returned after %s, want about the context deadline", elapsed)
+	}
+}
+
+func TestSMTPMailerCancel(t *testing.T) {
+	// Сервер перестает отвечать после EHLO; у контекста нет срока, только отмена
+	server := startSMTPStandIn(t, nil, "EHLO")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := server.mailer(t, "").Send(ctx, &MailMessage{To: "user@example.com", Subject: "s", Body: "b"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Send error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send returned after %s, want right after cancel", elapsed)
+	}
+}
+
+func TestLogMailerFile(t *testing.T) {
+	path := t.TempDir() + "/mail.log"
+	mailer, err := NewMailer(MailerLog, MailerConfig{From: "no-reply@example.com", LogFile: path})
+	if err != nil {
+		t.Fatalf("NewMailer error: %v", err)
+	}
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := mailer.Send(context.Background(), &MailMessage{To: to, Subject: "s", Body: "b"}); err != nil {
+			t.Fatalf("Send error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read mail log: %v", err)
+	}
+	for _, header := range []string{"To: a@example.com\r\n", "To: b@example.com\r\n"} {
+		if !strings.Contains(string(data), header) {
+			t.Errorf("mail log does not contain %q", header)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+)
+
+// Виды уведомлений
+const NotificationRateAlert = "rate_alert"
+
+// Максимальное число уведомлений в ленте за один запрос
+const notificationFeedLimit = 100
+
+type NotificationService interface {
+	GetNotifications(ctx context.Context, userID int, unreadOnly bool) ([]models.Notification, error)
+	MarkRead(ctx context.Context, userID, id int) error
+	MarkAllRead(ctx context.Context, userID int) error
+	// Notify сохраняет уведомление в ленту и, если задан webhookURL, отправляет payload в фоне
+	Notify(ctx context.Context, notification *models.Notification, webhookURL string, payload any) error
+}
+
+type notificationService struct {
+	repo   repository.Repository
+	client *http.Client
+}
+
+func NewNotificationService(repo repository.Repository) NotificationService {
+	return &notificationService{
+		repo:   repo,
+		client: newWebhookClient(),
+	}
+}
+
+func (s *notificationService) GetNotifications(ctx context.Context, userID int, unreadOnly bool) ([]models.Notification, error) {
+	return s.repo.GetNotificationsByUserID(ctx, userID, unreadOnly, notificationFeedLimit)
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, userID, id int) error {
+	found, err := s.repo.MarkNotificationRead(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+func (s *notificationService) MarkAllRead(ctx context.Context, userID int) error {
+	return s.repo.MarkAllNotificationsRead(ctx, userID)
+}
+
+func (s *notificationService) Notify(ctx context.Context, notification *models.Notification, webhookURL string, payload any) error {
+	if err := s.repo.CreateNotification(ctx, notification); err != nil {
+		return err
+	}
+
+	if webhookURL != "" {
+		// Медленный получатель не должен задерживать обновление курсов
+		go func() {
+			if err := s.deliverWebhook(webhookURL, payload); err != nil {
+				log.Printf("Failed to deliver webhook for notification %d: %v", notification.ID, err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// deliverWebhook отправляет payload POST-запросом в JSON; успешным считается ответ 2xx
+func (s *notificationService) deliverWebhook(url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"personal-finance-tracker/internal/models"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Как долго кешируются настройки провайдера и его ключи подписи
+const oidcMetadataTTL = time.Hour
+
+// Ключи с неизвестным kid перезапрашиваются не чаще этого интервала
+const oidcKeysRefreshInterval = time.Minute
+
+// Допустимое расхождение часов с провайдером при проверке сроков ID-токена
+const oidcClockSkew = time.Minute
+
+// Алгоритмы подписи ID-токенов, которые принимает клиент
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// OIDCConfig — параметры клиента OpenID Connect
+type OIDCConfig struct {
+	// IssuerURL — идентификатор провайдера; настройки читаются из
+	// IssuerURL/.well-known/openid-configuration
+	IssuerURL string
+	ClientID  string
+	// ClientSecret — пусто для публичного клиента, который защищен только PKCE
+	ClientSecret string
+	// RedirectURL — страница, на которую провайдер возвращает пользователя с кодом
+	RedirectURL string
+	Scopes      []string
+}
+
+// OIDCProvider выполняет вход у провайдера OpenID Connect по коду авторизации с PKCE (S256)
+type OIDCProvider interface {
+	// AuthorizationURL возвращает адрес страницы входа провайдера
+	AuthorizationURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Exchange обменивает код на токены и возвращает сведения из проверенного ID-токена
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*models.OIDCClaims, error)
+}
+
+// NewOIDCProvider создает клиент провайдера. Настройки провайдера запрашиваются при
+// первом входе, поэтому сервер запускается, даже если провайдер временно недоступен.
+func NewOIDCProvider(cfg OIDCConfig) (OIDCProvider, error) {
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("oidc login requires OIDC_CLIENT_ID and OIDC_REDIRECT_URL")
+	}
+	if _, err := url.ParseRequestURI(cfg.IssuerURL); err != nil {
+		return nil, fmt.Errorf("invalid oidc issuer url %q: %w", cfg.IssuerURL, err)
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &oidcProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// oidcMetadata — нужная клиенту часть документа openid-configuration
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu         sync.Mutex
+	metadata   *oidcMetadata
+	metadataAt time.Time
+	keys       map[string]any
+	keysAt     time.Time
+}
+
+func (p *oidcProvider) AuthorizationURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// oidcTokenResponse — ответ token endpoint (успешный или с ошибкой)
+type oidcTokenResponse struct {
+	IDToken          string `json:"id_token"`
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*models.OIDCClaims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	// Конфиденциальный клиент аутентифицируется по client_secret_basic, публичный — только client_id
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(token.Error+" "+token.ErrorDescription))
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token endpoint returned no id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, metadata, token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// Часть провайдеров отдает email только через userinfo
+	if claims.Email == "" && metadata.UserinfoEndpoint != "" && token.AccessToken != "" {
+		if err := p.fillFromUserinfo(ctx, metadata.UserinfoEndpoint, token.AccessToken, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// oidcBool принимает email_verified и как логическое значение, и как строку "true"
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	*b = oidcBool(value == "true")
+	return nil
+}
+
+// oidcIDTokenClaims — утверждения ID-токена и ответа userinfo
+type oidcIDTokenClaims struct {
+	Nonce             string   `json:"nonce"`
+	AuthorizedParty   string   `json:"azp"`
+	Email             string   `json:"email"`
+	EmailVerified     oidcBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// verifyIDToken проверяет подпись ключом провайдера, issuer, audience, срок и nonce
+func (p *oidcProvider) verifyIDToken(ctx context.Context, metadata *oidcMetadata, raw, nonce string) (*models.OIDCClaims, error) {
+	claims := &oidcIDTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, metadata.JWKSURI, kid)
+	},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithLeeway(oidcClockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	if claims.ExpiresAt == nil || claims.Subject == "" {
+		return nil, errors.New("invalid id_token: exp and sub are required")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, errors.New("invalid id_token: unexpected azp")
+	}
+
+	return &models.OIDCClaims{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     bool(claims.EmailVerified),
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// fillFromUserinfo дополняет сведения ответом userinfo того же пользователя
+func (p *oidcProvider) fillFromUserinfo(ctx context.Context, endpoint, accessToken string, claims *models.OIDCClaims) error {
+	var userinfo oidcIDTokenClaims
+	if err := p.getJSON(ctx, endpoint, accessToken, &userinfo); err != nil {
+		return fmt.Errorf("userinfo request failed: %w", err)
+	}
+	if userinfo.Subject != claims.Subject {
+		return errors.New("userinfo subject does not match id_token")
+	}
+
+	claims.Email = userinfo.Email
+	claims.EmailVerified = bool(userinfo.EmailVerified)
+	if claims.Name == "" {
+		claims.Name = userinfo.Name
+	}
+	if claims.PreferredUsername == "" {
+		claims.PreferredUsername = userinfo.PreferredUsername
+	}
+	return nil
+}
+
+// discover возвращает настройки провайдера, обновляя их раз в oidcMetadataTTL
+func (p *oidcProvider) discover(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil && time.Since(p.metadataAt) < oidcMetadataTTL {
+		return p.metadata, nil
+	}
+
+	endpoint := strings.TrimSuffix(p.cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var metadata oidcMetadata
+	if err := p.getJSON(ctx, endpoint, "", &metadata); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+	// Документ должен принадлежать тому же провайдеру (OpenID Connect Discovery, 4.3)
+	if metadata.Issuer != p.cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery returned issuer %q, expected %q", metadata.Issuer, p.cfg.IssuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is missing required endpoints")
+	}
+
+	p.metadata = &metadata
+	p.metadataAt = time.Now()
+	return p.metadata, nil
+}
+
+// jsonWebKey — открытый ключ из JWKS (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// signingKey возвращает ключ подписи по kid. Неизвестный kid означает, что провайдер
+// сменил ключи, поэтому JWKS запрашивается заново (не чаще oidcKeysRefreshInterval).
+func (p *oidcProvider) signingKey(ctx context.Context, jwksURI, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fresh := time.Since(p.keysAt) < oidcMetadataTTL
+	if key, ok := p.lookupKey(kid); ok && fresh {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysAt) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, "", &jwks); err != nil {
+		return nil, fmt.Errorf("jwks request failed: %w", err)
+	}
+
+	keys := make(map[string]any, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Ключи неподдерживаемых типов пропускаются
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys = keys
+	p.keysAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey ищет ключ по kid; без kid подходит только единственный ключ провайдера
+func (p *oidcProvider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 2 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// pkceChallenge возвращает code_challenge метода S256 для code_verifier (RFC 7636)
+func pkceChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// getJSON выполняет GET (с Bearer-токеном, если он задан) и разбирает JSON-ответ
+func (p *oidcProvider) getJSON(ctx context.Context, endpoint, bearer string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"personal-finance-tracker/internal/utils"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testOIDCClientID    = "finance-tracker"
+	testOIDCRedirectURL = "http://localhost:8080/oidc/callback"
+	testOIDCKeyID       = "mock-key"
+)
+
+// Ключи подписи создаются один раз: генерация RSA заметно замедлила бы тесты
+var testOIDCKeys = sync.OnceValue(func() [2]*rsa.PrivateKey {
+	var keys [2]*rsa.PrivateKey
+	for i := range keys {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		keys[i] = key
+	}
+	return keys
+})
+
+// mockAuthorization — код авторизации, выданный mock IdP
+type mockAuthorization struct {
+	nonce     string
+	challenge string
+}
+
+// mockIdP — провайдер OpenID Connect в процессе теста: discovery, JWKS и token endpoint
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// issuer — значение issuer в discovery; по умолчанию адрес сервера
+	issuer string
+	// claims меняет утверждения ID-токена перед подписью
+	claims func(claims jwt.MapClaims)
+	// signingKey подменяет ключ подписи ID-токена
+	signingKey *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+func startMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	idp := &mockIdP{key: testOIDCKeys()[0], codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.issuer,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testOIDCKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	idp.issuer = idp.server.URL
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdP) provider(t *testing.T) OIDCProvider {
+	t.Helper()
+	provider, err := NewOIDCProvider(OIDCConfig{
+		IssuerURL:   idp.server.URL,
+		ClientID:    testOIDCClientID,
+		RedirectURL: testOIDCRedirectURL,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCProvider error: %v", err)
+	}
+	return provider
+}
+
+// authorize играет роль страницы входа провайдера: проверяет параметры адреса
+// и возвращает код и state, с которыми пользователь вернулся бы на redirect_uri
+func (idp *mockIdP) authorize(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid authorization url: %v", err)
+	}
+	if got, want := parsed.Scheme+"://"+parsed.Host+parsed.Path, idp.server.URL+"/authorize"; got != want {
+		t.Fatalf("authorization endpoint = %s, want %s", got, want)
+	}
+
+	query := parsed.Query()
+	params := map[string]string{
+		"response_type":         "code",
+		"client_id":             testOIDCClientID,
+		"redirect_uri":          testOIDCRedirectURL,
+		"scope":                 "openid email profile",
+		"code_challenge_method": "S256",
+	}
+	for name, want := range params {
+		if got := query.Get(name); got != want {
+			t.Errorf("authorization parameter %s = %q, want %q", name, got, want)
+		}
+	}
+	for _, name := range []string{"state", "nonce", "code_challenge"} {
+		if query.Get(name) == "" {
+			t.Fatalf("authorization parameter %s is missing", name)
+		}
+	}
+
+	code, _ = utils.GenerateOpaqueToken()
+	idp.mu.Lock()
+	idp.codes[code] = mockAuthorization{nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	idp.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	idp.mu.Lock()
+	authorization, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	// Код одноразовый и выдается только тому, кто знает code_verifier (PKCE)
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != testOIDCClientID ||
+		r.PostForm.Get("redirect_uri") != testOIDCRedirectURL ||
+		pkceChallenge(r.PostForm.Get("code_verifier")) != authorization.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "user-42",
+		"aud":            testOIDCClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          authorization.nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+		"name":           "Test User",
+	}
+	if idp.claims != nil {
+		idp.claims(claims)
+	}
+
+	key := idp.key
+	if idp.signingKey != nil {
+		key = idp.signingKey
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testOIDCKeyID
+	idToken, err := token.SignedString(key)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "access_token": "access", "token_type": "Bearer"})
+}
+
+// login проходит вход у mock IdP: от адреса авторизации до обмена кода
+func (idp *mockIdP) login(t *testing.T, provider OIDCProvider) (*models.OIDCClaims, error) {
+	t.Helper()
+	ctx := context.Background()
+	codeVerifier, _ := utils.GenerateOpaqueToken()
+	nonce, _ := utils.GenerateOpaqueToken()
+
+	authURL, err := provider.AuthorizationURL(ctx, "state", nonce, pkceChallenge(codeVerifier))
+	if err != nil {
+		t.Fatalf("AuthorizationURL error: %v", err)
+	}
+	code, _ := idp.authorize(t, authURL)
+
+	return provider.Exchange(ctx, code, codeVerifier, nonce)
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	idp := startMockIdP(t)
+
+	authURL, err := idp.provider(t).AuthorizationURL(context.Background(), "state-1", "nonce-1", "challenge-1")
+	if err != nil {
+		t.Fatalf("AuthorizationURL error: %v", err)
+	}
+
+	// Адрес берется из discovery и несет state, nonce и code_challenge без изменений
+	query, _ := url.ParseQuery(authURL[strings.Index(authURL, "?")+1:])
+	if !strings.HasPrefix(authURL, idp.server.URL+"/authorize?") {
+		t.Errorf("authorization url = %s", authURL)
+	}
+	for name, want := range map[string]string{"state": "state-1", "nonce": "nonce-1", "code_challenge": "challenge-1"} {
+		if got := query.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestOIDCDiscoveryRejectsForeignIssuer(t *testing.T) {
+	idp := startMockIdP(t)
+	idp.issuer = "https://evil.example.com"
+
+	if _, err := idp.provider(t).AuthorizationURL(context.Background(), "state", "nonce", "challenge"); err == nil {
+		t.Error("discovery with a different issuer did not fail")
+	}
+}
+
+func TestOIDCExchangeValidToken(t *testing.T) {
+	idp := startMockIdP(t)
+
+	claims, err := idp.login(t, idp.provider(t))
+	if err != nil {
+		t.Fatalf("Exchange error: %v", err)
+	}
+
+	want := models.OIDCClaims{
+		Issuer:        idp.server.URL,
+		Subject:       "user-42",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Name:          "Test User",
+	}
+	if *claims != want {
+		t.Errorf("claims = %+v, want %+v", *claims, want)
+	}
+}
+
+func TestOIDCExchangeRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     func(claims jwt.MapClaims)
+		signingKey *rsa.PrivateKey
+		want       string
+	}{
+		{name: "foreign audience", claims: func(c jwt.MapClaims) { c["aud"] = "another-client" }, want: "invalid audience"},
+		{name: "nonce mismatch", claims: func(c jwt.MapClaims) { c["nonce"] = "replayed-nonce" }, want: "nonce mismatch"},
+		{name: "missing nonce", claims: func(c jwt.MapClaims) { delete(c, "nonce") }, want: "nonce mismatch"},
+		{name: "expired", claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Minute).Unix() }, want: "token is expired"},
+		{name: "missing exp", claims: func(c jwt.MapClaims) { delete(c, "exp") }, want: "exp and sub are required"},
+		{name: "foreign issuer", claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, want: "invalid issuer"},
+		{name: "missing sub", claims: func(c jwt.MapClaims) { delete(c, "sub") }, want: "exp and sub are required"},
+		{name: "several audiences without azp", claims: func(c jwt.MapClaims) {
+			c["aud"] = []string{testOIDCClientID, "another-client"}
+		}, want: "unexpected azp"},
+		{name: "several audiences with foreign azp", claims: func(c jwt.MapClaims) {
+			c["aud"] = []string{testOIDCClientID, "another-client"}
+			c["azp"] = "another-client"
+		}, want: "unexpected azp"},
+		{name: "signed by an unknown key", signingKey: testOIDCKeys()[1], want: "signature is invalid"},
+	}
+
+	for _, tt := range tests {
+		idp := startMockIdP(t)
+		idp.claims = tt.claims
+		idp.signingKey = tt.signingKey
+
+		claims, err := idp.login(t, idp.provider(t))
+		if err == nil {
+			t.Errorf("%s: Exchange = %+v, want error", tt.name, claims)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestOIDCExchangeAcceptsSeveralAudiencesWithAZP(t *testing.T) {
+	idp := startMockIdP(t)
+	idp.claims = func(c jwt.MapClaims) {
+		c["aud"] = []string{testOIDCClientID, "another-client"}
+		c["azp"] = testOIDCClientID
+	}
+
+	if _, err := idp.login(t, idp.provider(t)); err != nil {
+		t.Errorf("Exchange error: %v", err)
+	}
+}
+
+func TestOIDCExchangeRequiresCodeVerifier(t *testing.T) {
+	idp := startMockIdP(t)
+	provider := idp.provider(t)
+	codeVerifier, _ := utils.GenerateOpaqueToken()
+
+	authURL, err := provider.AuthorizationURL(context.Background(), "state", "nonce", pkceChallenge(codeVerifier))
+	if err != nil {
+		t.Fatalf("AuthorizationURL error: %v", err)
+	}
+	code, _ := idp.authorize(t, authURL)
+
+	// Перехваченный код без code_verifier бесполезен
+	if _, err := provider.Exchange(context.Background(), code, "guessed-verifier", "nonce"); err == nil {
+		t.Error("Exchange with a wrong code_verifier did not fail")
+	}
+}
+
+func TestJSONWebKeyRSA(t *testing.T) {
+	key := &testOIDCKeys()[0].PublicKey
+	n := base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+
+	tests := []struct {
+		e     string
+		valid bool
+	}{
+		{"AQAB", true}, // 65537
+		{"Aw", true},   // 3
+		{"AQ", false},  // 1
+		{"", false},
+		{"AQAB!", false},
+		{base64.RawURLEncoding.EncodeToString([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1}), false},
+	}
+
+	for _, tt := range tests {
+		got, err := jsonWebKey{Kty: "RSA", N: n, E: tt.e}.publicKey()
+		if (err == nil) != tt.valid {
+			t.Errorf("e = %q: error = %v, want valid %v", tt.e, err, tt.valid)
+			continue
+		}
+		if tt.valid && got.(*rsa.PublicKey).N.Cmp(key.N) != 0 {
+			t.Errorf("e = %q: modulus does not match", tt.e)
+		}
+	}
+
+	if _, err := (jsonWebKey{Kty: "oct", Kid: "secret"}).publicKey(); err == nil {
+		t.Error("symmetric key was accepted")
+	}
+}
+
+// oidcTestRepo хранит начатые входы в памяти; остальные методы репозитория тесту не нужны
+type oidcTestRepo struct {
+	repository.Repository
+	user *models.User
+
+	mu           sync.Mutex
+	authRequests map[string]*models.OIDCAuthRequest
+}
+
+func (r *oidcTestRepo) CreateOIDCAuthRequest(ctx context.Context, req *models.OIDCAuthRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.authRequests[req.StateHash] = req
+	return nil
+}
+
+func (r *oidcTestRepo) ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (*models.OIDCAuthRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	req := r.authRequests[stateHash]
+	delete(r.authRequests, stateHash)
+	return req, nil
+}
+
+func (r *oidcTestRepo) GetUserIdentity(ctx context.Context, issuer, subject string) (*models.UserIdentity, error) {
+	return &models.UserIdentity{ID: 1, UserID: r.user.ID, Issuer: issuer, Subject: subject}, nil
+}
+
+func (r *oidcTestRepo) TouchUserIdentity(ctx context.Context, id int, email string, loginAt time.Time) error {
+	return nil
+}
+
+func (r *oidcTestRepo) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	return r.user, nil
+}
+
+func TestOIDCStateBoundToStartedLogin(t *testing.T) {
+	utils.SetJWTSecret("test-secret")
+	idp := startMockIdP(t)
+	// С включенной 2FA вход заканчивается токеном второго шага, без создания сессии
+	repo := &oidcTestRepo{
+		user:         &models.User{ID: 7, Email: "user@example.com", TwoFactorEnabled: true},
+		authRequests: make(map[string]*models.OIDCAuthRequest),
+	}
+	limiter, _ := newTestLimiter(map[string]LimitPolicy{LimitLoginIP: {FreeAttempts: 100}, LimitOIDCStartIP: {FreeAttempts: 100}})
+	users := NewUserService(repo, nil, limiter, idp.provider(t), nil, time.Minute, time.Hour)
+
+	ctx := context.Background()
+	client := models.SessionClient{IPAddress: "203.0.113.7"}
+
+	// Вход начат в двух браузерах: у пользователя и у злоумышленника
+	victim, err := users.StartOIDCLogin(ctx, client)
+	if err != nil {
+		t.Fatalf("StartOIDCLogin error: %v", err)
+	}
+	attacker, err := users.StartOIDCLogin(ctx, client)
+	if err != nil {
+		t.Fatalf("StartOIDCLogin error: %v", err)
+	}
+	if victim.State == attacker.State {
+		t.Fatal("two logins got the same state")
+	}
+
+	// Браузер получает тот же state, что уходит провайдеру, а сервер хранит только его хеш
+	code, state := idp.authorize(t, attacker.AuthorizationURL)
+	if state != attacker.State {
+		t.Fatalf("state in authorization url = %q, returned to the browser = %q", state, attacker.State)
+	}
+	if _, ok := repo.authRequests[utils.HashToken(attacker.State)]; !ok {
+		t.Error("auth request is not stored by state hash")
+	}
+
+	// Код, полученный чужим входом, не завершает вход, начатый в браузере пользователя:
+	// его code_verifier не подходит к этому коду
+	if _, err := users.LoginOIDC(ctx, &models.OIDCCallbackRequest{Code: code, State: victim.State}, client); err == nil {
+		t.Error("login with a code from another started login did not fail")
+	}
+
+	code, _ = idp.authorize(t, attacker.AuthorizationURL)
+	result, err := users.LoginOIDC(ctx, &models.OIDCCallbackRequest{Code: code, State: attacker.State}, client)
+	if err != nil {
+		t.Fatalf("LoginOIDC error: %v", err)
+	}
+	if result.ChallengeToken == "" {
+		t.Errorf("LoginOIDC result = %+v, want a two-factor challenge", result)
+	}
+
+	// state одноразовый: повторный возврат отклоняется
+	code, _ = idp.authorize(t, attacker.AuthorizationURL)
+	if _, err := users.LoginOIDC(ctx, &models.OIDCCallbackRequest{Code: code, State: attacker.State}, client); err == nil {
+		t.Error("reused state was accepted")
+	}
+	if _, err := users.LoginOIDC(ctx, &models.OIDCCallbackRequest{Code: code, State: "forged-state"}, client); err == nil {
+		t.Error("unknown state was accepted")
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"personal-finance-tracker/internal/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Время на доставку письма со ссылкой сброса
+const resetMailTimeout = 30 * time.Second
+
+type PasswordService interface {
+	// ChangePassword меняет пароль по текущему и завершает остальные сессии пользователя
+	ChangePassword(ctx context.Context, userID, sessionID int, req *models.ChangePasswordRequest) error
+	// RequestReset отправляет письмо с токеном сброса. Для неизвестного email ничего не
+	// делает и не сообщает об этом, чтобы не раскрывать зарегистрированные адреса.
+	RequestReset(ctx context.Context, email string, client models.SessionClient) error
+	// ResetPassword задает новый пароль по токену из письма и завершает все сессии
+	ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error
+}
+
+type passwordService struct {
+	repo         repository.Repository
+	mailer       Mailer
+	loginLimiter LoginLimiter
+	resetTTL     time.Duration
+	resetURL     string
+}
+
+// NewPasswordService создает сервис паролей. resetURL — адрес страницы сброса, в котором
+// {token} заменяется токеном; пустой — в письмо попадает только токен.
+func NewPasswordService(repo repository.Repository, mailer Mailer, loginLimiter LoginLimiter, resetTTL time.Duration, resetURL string) PasswordService {
+	return &passwordService{
+		repo:         repo,
+		mailer:       mailer,
+		loginLimiter: loginLimiter,
+		resetTTL:     resetTTL,
+		resetURL:     resetURL,
+	}
+}
+
+func (s *passwordService) ChangePassword(ctx context.Context, userID, sessionID int, req *models.ChangePasswordRequest) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return errors.New("current password is incorrect")
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return errors.New("new password must differ from current password")
+	}
+
+	// Новый пароль действует, только если остальные сессии и ссылки сброса уже недействительны
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.UpdateUserPassword(ctx, userID, req.NewPassword); err != nil {
+			return err
+		}
+		if err := tx.InvalidatePasswordResetTokens(ctx, userID); err != nil {
+			return err
+		}
+		return tx.RevokeOtherUserSessions(ctx, userID, sessionID)
+	})
+}
+
+func (s *passwordService) RequestReset(ctx context.Context, email string, client models.SessionClient) error {
+	// Каждый запрос учитывается в лимитах IP и адреса, даже для неизвестного email,
+	// иначе отказ по лимиту выдавал бы зарегистрированные адреса
+	if err := s.loginLimiter.Attempt(ctx,
+		LimitKey{Kind: LimitResetIP, Value: client.IPAddress},
+		LimitKey{Kind: LimitResetEmail, Value: email},
+	); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	// Действует только последняя ссылка
+	if err := s.repo.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	resetToken := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.resetTTL),
+	}
+	if err := s.repo.CreatePasswordResetToken(ctx, resetToken); err != nil {
+		return err
+	}
+
+	message := &MailMessage{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body:    s.resetMailBody(user, token),
+	}
+
+	// Письмо отправляется в фоне: время ответа не должно выдавать, существует ли адрес
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), resetMailTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, message); err != nil {
+			log.Printf("Failed to send password reset mail to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *passwordService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	// Токен погашается вместе со сменой пароля и завершением сессий: при ошибке им можно воспользоваться снова
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		resetToken, err := tx.ConsumePasswordResetToken(ctx, utils.HashToken(req.Token))
+		if err != nil {
+			return err
+		}
+		if resetToken == nil {
+			return errors.New("invalid or expired reset token")
+		}
+
+		if err := tx.UpdateUserPassword(ctx, resetToken.UserID, req.NewPassword); err != nil {
+			return err
+		}
+
+		// Пароль мог быть скомпрометирован — завершаем все сессии
+		return tx.RevokeOtherUserSessions(ctx, resetToken.UserID, 0)
+	})
+}
+
+func (s *passwordService) resetMailBody(user *models.User, token string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Здравствуйте, %s!\n\n", user.Username)
+	b.WriteString("Мы получили запрос на сброс пароля для вашего аккаунта.\n")
+	if s.resetURL != "" {
+		fmt.Fprintf(&b, "Чтобы задать новый пароль, перейдите по ссылке:\n%s\n", strings.ReplaceAll(s.resetURL, "{token}", token))
+	} else {
+		fmt.Fprintf(&b, "Код для сброса пароля:\n%s\n", token)
+	}
+	fmt.Fprintf(&b, "\nСрок действия — %s, воспользоваться можно один раз.\n", formatTTL(s.resetTTL))
+	b.WriteString("Если вы не запрашивали сброс, просто проигнорируйте это письмо.\n")
+	return b.String()
+}
+
+// formatTTL записывает срок действия для письма: «1 ч», «30 мин»
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d ч", int(ttl/time.Hour))
+	}
+	return fmt.Sprintf("%d мин", int(ttl/time.Minute))
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Время на доставку письма об удалении учетной записи
+const deletionMailTimeout = 30 * time.Second
+
+type PrivacyService interface {
+	// RequestDeletion проверяет пароль (и код 2FA, если она включена), назначает удаление
+	// через grace period и завершает все сессии. Вход до этого срока отменяет удаление.
+	RequestDeletion(ctx context.Context, userID int, req *models.DeleteAccountRequest) (time.Time, error)
+	// PurgeScheduledDeletions удаляет учетные записи, срок удаления которых наступил
+	PurgeScheduledDeletions(ctx context.Context) (int64, error)
+	// ExportData собирает все персональные данные пользователя
+	ExportData(ctx context.Context, userID int) (*models.UserDataExport, error)
+}
+
+type privacyService struct {
+	repo             repository.Repository
+	twoFactorService TwoFactorService
+	mailer           Mailer
+	deletionGrace    time.Duration
+}
+
+func NewPrivacyService(repo repository.Repository, twoFactorService TwoFactorService, mailer Mailer, deletionGrace time.Duration) PrivacyService {
+	return &privacyService{
+		repo:             repo,
+		twoFactorService: twoFactorService,
+		mailer:           mailer,
+		deletionGrace:    deletionGrace,
+	}
+}
+
+func (s *privacyService) RequestDeletion(ctx context.Context, userID int, req *models.DeleteAccountRequest) (time.Time, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if user == nil {
+		return time.Time{}, errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return time.Time{}, errors.New("password is incorrect")
+	}
+	if user.TwoFactorEnabled {
+		ok, err := s.twoFactorService.VerifyCode(ctx, user.ID, req.Code)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !ok {
+			return time.Time{}, errors.New("invalid two-factor code")
+		}
+	}
+
+	// Без администраторов управлять сервисом будет некому
+	if user.Role == models.RoleAdmin {
+		admins, err := s.repo.CountUsersByRole(ctx, models.RoleAdmin)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if admins <= 1 {
+			return time.Time{}, errors.New("cannot delete the last admin")
+		}
+	}
+
+	deleteAt := time.Now().Add(s.deletionGrace)
+	if err := s.repo.ScheduleUserDeletion(ctx, user.ID, deleteAt); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.repo.RevokeOtherUserSessions(ctx, user.ID, 0); err != nil {
+		return time.Time{}, err
+	}
+
+	message := &MailMessage{
+		To:      user.Email,
+		Subject: "Удаление учетной записи",
+		Body:    deletionMailBody(user, deleteAt),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deletionMailTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, message); err != nil {
+			log.Printf("Failed to send account deletion mail to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return deleteAt, nil
+}
+
+func (s *privacyService) PurgeScheduledDeletions(ctx context.Context) (int64, error) {
+	return s.repo.DeleteScheduledUsers(ctx, time.Now())
+}
+
+func (s *privacyService) ExportData(ctx context.Context, userID int) (*models.UserDataExport, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	export := &models.UserDataExport{ExportedAt: time.Now(), Profile: user}
+
+	if export.TwoFactor, err = s.twoFactorService.GetStatus(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Currencies, err = s.repo.GetUserCurrencies(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Sessions, err = s.repo.GetSessionHistoryByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.APIKeys, err = s.repo.GetAPIKeysByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Identities, err = s.repo.GetUserIdentitiesByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Accounts, err = s.repo.GetAccountsByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Transactions, err = s.repo.GetTransactionsByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Transfers, err = s.repo.GetTransfersByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.ExchangeRates, err = s.repo.GetUserExchangeRates(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.RateAlerts, err = s.repo.GetRateAlertsByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Notifications, err = s.repo.GetNotificationsByUserID(ctx, userID, false, 0); err != nil {
+		return nil, err
+	}
+	if export.AuditLog, err = s.repo.GetAuditEntries(ctx, &models.AuditFilter{UserID: &userID}); err != nil {
+		return nil, err
+	}
+
+	// Общие категории не относятся к данным пользователя
+	categories, err := s.repo.GetCategoriesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if category.UserID != nil {
+			export.Categories = append(export.Categories, category)
+		}
+	}
+
+	for _, account := range export.Accounts {
+		transactions, err := s.repo.GetInvestmentTransactionsByAccountID(ctx, account.ID)
+		if err != nil {
+			return nil, err
+		}
+		export.InvestmentTransactions = append(export.InvestmentTransactions, transactions...)
+	}
+
+	if export.SavingsGoals, err = s.repo.GetSavingsGoalsByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	for _, goal := range export.SavingsGoals {
+		contributions, err := s.repo.GetGoalContributions(ctx, goal.ID)
+		if err != nil {
+			return nil, err
+		}
+		export.GoalContributions = append(export.GoalContributions, contributions...)
+	}
+
+	return export, nil
+}
+
+func deletionMailBody(user *models.User, deleteAt time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Здравствуйте, %s!\n\n", user.Username)
+	fmt.Fprintf(&b, "Ваша учетная запись и все данные будут удалены %s (UTC).\n", deleteAt.UTC().Format("02.01.2006 15:04"))
+	b.WriteString("Все сессии завершены. Если вы передумали, просто войдите в аккаунт до этого срока — удаление будет отменено.\n")
+	b.WriteString("Если вы не запрашивали удаление, войдите в аккаунт и смените пароль.\n")
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"fmt"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Предел числа записей в кэше: при превышении кэш очищается целиком
+const rateCacheMaxEntries = 10000
+
+// rateCacheKey — пара валют и дата курса
+type rateCacheKey struct {
+	base   int
+	target int
+	date   string
+}
+
+// rateCache хранит в памяти курсы по датам и уже вычисленные курсы пар.
+// Одновременные промахи по одной дате приводят к одному запросу в базу.
+type rateCache struct {
+	repo repository.Repository
+
+	mu sync.RWMutex
+	// generation увеличивается при каждом сбросе; загрузки, начатые до сброса, не сохраняются
+	generation uint64
+	graphs     map[string]*rateGraph
+	latest     map[string][]models.ExchangeRate
+	pairs      map[rateCacheKey]*models.ExchangeRate
+
+	loads singleflight.Group
+}
+
+func newRateCache(repo repository.Repository) *rateCache {
+	c := &rateCache{repo: repo}
+	c.invalidate()
+	return c
+}
+
+// invalidate сбрасывает кэш после обновления или импорта курсов
+func (c *rateCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.graphs = make(map[string]*rateGraph)
+	c.latest = make(map[string][]models.ExchangeRate)
+	c.pairs = make(map[rateCacheKey]*models.ExchangeRate)
+}
+
+// rate возвращает курс пары на дату (nil, если его нельзя вывести из известных курсов)
+func (c *rateCache) rate(baseCurrencyID, targetCurrencyID int, asOf time.Time) (*models.ExchangeRate, error) {
+	key := rateCacheKey{base: baseCurrencyID, target: targetCurrencyID, date: asOf.Format("2006-01-02")}
+
+	c.mu.RLock()
+	rate, ok := c.pairs[key]
+	generation := c.generation
+	c.mu.RUnlock()
+	if !ok {
+		graph, err := c.graph(key.date, asOf)
+		if err != nil {
+			return nil, err
+		}
+		rate = graph.resolve(baseCurrencyID, targetCurrencyID, asOf)
+
+		c.mu.Lock()
+		if c.generation == generation {
+			if len(c.pairs) >= rateCacheMaxEntries {
+				c.pairs = make(map[rateCacheKey]*models.ExchangeRate)
+			}
+			c.pairs[key] = rate
+		}
+		c.mu.Unlock()
+	}
+
+	if rate == nil {
+		return nil, nil
+	}
+	// Отдаем копию, чтобы вызывающий код не менял закэшированное значение
+	result := *rate
+	return &result, nil
+}
+
+// graph возвращает граф курсов на дату, загружая его из базы при промахе
+func (c *rateCache) graph(date string, asOf time.Time) (*rateGraph, error) {
+	c.mu.RLock()
+	graph, ok := c.graphs[date]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok {
+		return graph, nil
+	}
+
+	// Поколение входит в ключ, чтобы запрос после сброса не присоединился к более ранней загрузке
+	value, err, _ := c.loads.Do(fmt.Sprintf("graph:%s:%d", date, generation), func() (any, error) {
+		rates, err := c.repo.GetAllExchangeRates(asOf)
+		if err != nil {
+			return nil, err
+		}
+		graph := newRateGraph(rates)
+
+		c.mu.Lock()
+		if c.generation == generation {
+			if len(c.graphs) >= rateCacheMaxEntries {
+				c.graphs = make(map[string]*rateGraph)
+			}
+			c.graphs[date] = graph
+		}
+		c.mu.Unlock()
+
+		return graph, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*rateGraph), nil
+}
+
+// all возвращает последние на дату курсы по всем парам
+func (c *rateCache) all(asOf time.Time) ([]models.ExchangeRate, error) {
+	date := asOf.Format("2006-01-02")
+
+	c.mu.RLock()
+	rates, ok := c.latest[date]
+	generation := c.generation
+	c.mu.RUnlock()
+	if !ok {
+		value, err, _ := c.loads.Do(fmt.Sprintf("latest:%s:%d", date, generation), func() (any, error) {
+			rates, err := c.repo.GetAllExchangeRates(asOf)
+			if err != nil {
+				return nil, err
+			}
+
+			c.mu.Lock()
+			if c.generation == generation {
+				if len(c.latest) >= rateCacheMaxEntries {
+					c.latest = make(map[string][]models.ExchangeRate)
+				}
+				c.latest[date] = rates
+			}
+			c.mu.Unlock()
+
+			return rates, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		rates = value.([]models.ExchangeRate)
+	}
+
+	result := make([]models.ExchangeRate, len(rates))
+	copy(result, rates)
+	return result, nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"math"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"sort"
+	"time"
+)
+
+// rateEdge — курс из одной валюты в другую: сохраненный или обращенный
+type rateEdge struct {
+	from     int
+	to       int
+	rate     money.Decimal
+	source   *models.ExchangeRate
+	inverted bool
+}
+
+// fresherThan сравнивает свежесть курсов: по дате курса, затем по времени обновления
+func (e rateEdge) fresherThan(other rateEdge) bool {
+	if !e.source.RateDate.Equal(other.source.RateDate) {
+		return e.source.RateDate.After(other.source.RateDate)
+	}
+	return e.source.LastUpdated.After(other.source.LastUpdated)
+}
+
+// rateGraph — граф валют, ребра которого — известные курсы в обе стороны
+type rateGraph struct {
+	edges      map[int][]rateEdge
+	currencies map[int]*models.Currency
+}
+
+func newRateGraph(rates []models.ExchangeRate) *rateGraph {
+	g := &rateGraph{
+		edges:      make(map[int][]rateEdge),
+		currencies: make(map[int]*models.Currency),
+	}
+
+	// Для каждой упорядоченной пары оставляем самый свежий курс (прямой или обращенный)
+	best := make(map[[2]int]rateEdge)
+	add := func(e rateEdge) {
+		key := [2]int{e.from, e.to}
+		if current, ok := best[key]; !ok || e.fresherThan(current) {
+			best[key] = e
+		}
+	}
+
+	for i := range rates {
+		rate := &rates[i]
+		if !rate.Rate.IsPositive() {
+			continue
+		}
+		if rate.BaseCurrency != nil {
+			g.currencies[rate.BaseCurrencyID] = rate.BaseCurrency
+		}
+		if rate.TargetCurrency != nil {
+			g.currencies[rate.TargetCurrencyID] = rate.TargetCurrency
+		}
+
+		add(rateEdge{from: rate.BaseCurrencyID, to: rate.TargetCurrencyID, rate: rate.Rate, source: rate})
+		add(rateEdge{from: rate.TargetCurrencyID, to: rate.BaseCurrencyID, rate: money.NewFromInt(1).Div(rate.Rate, money.RateScale, money.RoundHalfEven), source: rate, inverted: true})
+	}
+
+	for _, e := range best {
+		g.edges[e.from] = append(g.edges[e.from], e)
+	}
+	// Порядок обхода не должен зависеть от порядка ключей map
+	for from := range g.edges {
+		sort.Slice(g.edges[from], func(i, j int) bool { return g.edges[from][i].to < g.edges[from][j].to })
+	}
+
+	return g
+}
+
+// pathState — лучший найденный путь до валюты: последнее ребро и самое старое звено
+type pathState struct {
+	via    *rateEdge
+	oldest *rateEdge
+}
+
+// resolve ищет путь с наименьшим числом звеньев, а среди равных по длине —
+// путь, самое старое звено которого наиболее свежее
+func (g *rateGraph) resolve(baseCurrencyID, targetCurrencyID int, asOf time.Time) *models.ExchangeRate {
+	states := map[int]pathState{baseCurrencyID: {}}
+	frontier := []int{baseCurrencyID}
+
+	for len(frontier) > 0 {
+		if _, found := states[targetCurrencyID]; found {
+			break
+		}
+
+		next := make(map[int]pathState)
+		for _, from := range frontier {
+			for i := range g.edges[from] {
+				e := &g.edges[from][i]
+				if _, visited := states[e.to]; visited {
+					continue
+				}
+
+				candidate := pathState{via: e, oldest: e}
+				if prev := states[from].oldest; prev != nil && !prev.fresherThan(*e) {
+					candidate.oldest = prev
+				}
+
+				if current, ok := next[e.to]; !ok || candidate.oldest.fresherThan(*current.oldest) {
+					next[e.to] = candidate
+				}
+			}
+		}
+
+		frontier = frontier[:0]
+		for currencyID, state := range next {
+			states[currencyID] = state
+			frontier = append(frontier, currencyID)
+		}
+		sort.Ints(frontier)
+	}
+
+	state, found := states[targetCurrencyID]
+	if !found {
+		return nil
+	}
+
+	// Восстанавливаем путь от целевой валюты к базовой
+	var legs []*rateEdge
+	for currencyID := targetCurrencyID; currencyID != baseCurrencyID; {
+		e := states[currencyID].via
+		legs = append([]*rateEdge{e}, legs...)
+		currencyID = e.from
+	}
+
+	result := &models.ExchangeRate{
+		BaseCurrencyID:   baseCurrencyID,
+		TargetCurrencyID: targetCurrencyID,
+		Rate:             money.NewFromInt(1),
+		RateDate:         state.oldest.source.RateDate,
+		LastUpdated:      state.oldest.source.LastUpdated,
+		BaseCurrency:     g.currencies[baseCurrencyID],
+		TargetCurrency:   g.currencies[targetCurrencyID],
+		Path:             make([]models.ExchangeRateLeg, 0, len(legs)),
+	}
+	for _, e := range legs {
+		result.Rate = result.Rate.Mul(e.rate)
+		if e.source.LastUpdated.Before(result.LastUpdated) {
+			result.LastUpdated = e.source.LastUpdated
+		}
+		result.Path = append(result.Path, models.ExchangeRateLeg{
+			From:     g.currencyCode(e.from),
+			To:       g.currencyCode(e.to),
+			Rate:     e.rate,
+			RateDate: e.source.RateDate,
+			Inverted: e.inverted,
+		})
+	}
+
+	// Произведение курсов округляем до точности хранения курсов
+	result.Rate = result.Rate.Round(money.RateScale, money.RoundHalfEven).Normalize()
+
+	// Прямой сохраненный курс отдаем как есть, с его идентификатором
+	if len(legs) == 1 && !legs[0].inverted {
+		result.ID = legs[0].source.ID
+	}
+
+	staleness := stalenessDays(result.RateDate, asOf)
+	result.StalenessDays = &staleness
+
+	return result
+}
+
+func (g *rateGraph) currencyCode(currencyID int) string {
+	if currency := g.currencies[currencyID]; currency != nil {
+		return currency.Code
+	}
+	return ""
+}
+
+// stalenessDays — на сколько дней дата курса отстоит от даты, на которую он запрошен
+func stalenessDays(rateDate, asOf time.Time) int {
+	rateDay := time.Date(rateDate.Year(), rateDate.Month(), rateDate.Day(), 0, 0, 0, 0, time.UTC)
+	asOfDay := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	return int(math.Abs(asOfDay.Sub(rateDay).Hours() / 24))
+}
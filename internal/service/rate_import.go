@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"strings"
+	"time"
+)
+
+// Форматы файлов с курсами
+const (
+	RateImportCSV  = "csv"
+	RateImportJSON = "json"
+)
+
+// rateImportRecord — запись файла с курсом: rate единиц target за 1 единицу base
+type rateImportRecord struct {
+	Date    string     `json:"date"`
+	Base    string     `json:"base"`
+	Target  string     `json:"target"`
+	Rate    importRate `json:"rate"`
+	line    int
+	problem string
+}
+
+// importRate — курс в JSON может быть задан числом или строкой
+type importRate string
+
+func (r *importRate) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		value = string(data)
+	}
+	*r = importRate(value)
+	return nil
+}
+
+// ImportExchangeRates загружает курсы из CSV (date,base,target,rate) или JSON-массива
+// объектов с теми же полями. Некорректные строки пропускаются и попадают в отчет.
+func (s *exchangeService) ImportExchangeRates(ctx context.Context, r io.Reader, format string) (*models.RateImportResult, error) {
+	var records []rateImportRecord
+	var err error
+	switch strings.ToLower(format) {
+	case RateImportCSV, "":
+		records, err = readRateCSV(r)
+	case RateImportJSON:
+		records, err = readRateJSON(r)
+	default:
+		return nil, errors.New("unsupported format, use csv or json")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RateImportResult{Rows: []models.RateImportRow{}, Errors: []string{}}
+	// Загруженные курсы должны сразу использоваться при конвертации
+	defer s.cache.invalidate()
+
+	currencies := make(map[string]*models.Currency)
+	skip := func(line int, format string, args ...any) {
+		result.Skipped++
+		result.Errors = append(result.Errors, fmt.Sprintf("line %d: ", line)+fmt.Sprintf(format, args...))
+	}
+
+	// Все строки и запись журнала применяются в одной транзакции: при ошибке не сохраняется ни одна
+	err = s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		changes := newRateAuditChanges()
+		for _, record := range records {
+			if record.problem != "" {
+				skip(record.line, "%s", record.problem)
+				continue
+			}
+
+			date, err := time.Parse("2006-01-02", strings.TrimSpace(record.Date))
+			if err != nil {
+				skip(record.line, "invalid date %q", record.Date)
+				continue
+			}
+
+			rate, err := parseRateValue(string(record.Rate))
+			if err != nil || !rate.IsPositive() {
+				skip(record.line, "invalid rate %q", record.Rate)
+				continue
+			}
+
+			baseCode := strings.ToUpper(strings.TrimSpace(record.Base))
+			targetCode := strings.ToUpper(strings.TrimSpace(record.Target))
+			if baseCode == targetCode {
+				skip(record.line, "base and target currency must differ")
+				continue
+			}
+
+			base, err := lookupCurrency(tx, currencies, baseCode)
+			if err != nil {
+				return err
+			}
+			if base == nil {
+				skip(record.line, "unknown currency %q", record.Base)
+				continue
+			}
+			target, err := lookupCurrency(tx, currencies, targetCode)
+			if err != nil {
+				return err
+			}
+			if target == nil {
+				skip(record.line, "unknown currency %q", record.Target)
+				continue
+			}
+
+			previous, err := tx.CreateOrUpdateExchangeRate(&models.ExchangeRate{
+				BaseCurrencyID:   base.ID,
+				TargetCurrencyID: target.ID,
+				Rate:             rate,
+				RateDate:         date,
+			})
+			if err != nil {
+				return err
+			}
+
+			row := models.RateImportRow{
+				Line:         record.line,
+				Date:         date.Format("2006-01-02"),
+				Base:         base.Code,
+				Target:       target.Code,
+				Rate:         rate,
+				Status:       "inserted",
+				PreviousRate: previous,
+			}
+			if previous != nil {
+				row.Status = "replaced"
+				result.Replaced++
+			} else {
+				result.Inserted++
+			}
+			result.Rows = append(result.Rows, row)
+			changes.add(base.Code, target.Code, date, previous, rate)
+		}
+
+		return changes.record(ctx, tx, s.audit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// lookupCurrency ищет валюту по коду, запоминая результат на время импорта
+func lookupCurrency(repo repository.Repository, cache map[string]*models.Currency, code string) (*models.Currency, error) {
+	if currency, ok := cache[code]; ok {
+		return currency, nil
+	}
+
+	currency, err := repo.GetCurrencyByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	cache[code] = currency
+
+	return currency, nil
+}
+
+func readRateCSV(r io.Reader) ([]rateImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var records []rateImportRecord
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %v", err)
+		}
+
+		// Пропускаем заголовок и пустые строки
+		if len(record) == 0 || (line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date")) {
+			continue
+		}
+
+		if len(record) < 4 {
+			records = append(records, rateImportRecord{line: line, problem: "expected date,base,target,rate"})
+			continue
+		}
+		records = append(records, rateImportRecord{
+			Date:   record[0],
+			Base:   record[1],
+			Target: record[2],
+			Rate:   importRate(record[3]),
+			line:   line,
+		})
+	}
+
+	return records, nil
+}
+
+func readRateJSON(r io.Reader) ([]rateImportRecord, error) {
+	var records []rateImportRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+
+	// Номер строки в JSON — порядковый номер записи
+	for i := range records {
+		records[i].line = i + 1
+	}
+
+	return records, nil
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"personal-finance-tracker/internal/money"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// RateProvider — источник курсов валют
+type RateProvider interface {
+	Name() string
+	FetchRates(ctx context.Context) (*ProviderRates, error)
+}
+
+// ProviderRates — курсы от провайдера: сколько единиц валюты дают за 1 единицу Base
+type ProviderRates struct {
+	Provider string
+	Base     string
+	Date     time.Time
+	Rates    map[string]money.Decimal
+}
+
+// Названия провайдеров для конфигурации EXCHANGE_PROVIDERS
+const (
+	ProviderExchangeRateAPI = "exchangerate-api"
+	ProviderECB             = "ecb"
+	ProviderCBR             = "cbr"
+	ProviderNBT             = "nbt"
+)
+
+// Адреса провайдеров по умолчанию
+var defaultProviderEndpoints = map[string]string{
+	ProviderExchangeRateAPI: "https://api.exchangerate-api.com/v4/latest/USD",
+	ProviderECB:             "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml",
+	ProviderCBR:             "https://www.cbr.ru/scripts/XML_daily.asp",
+	ProviderNBT:             "https://nbt.tj/ru/kurs/export_xml.php?date={date}&export=xmlout",
+	ProviderCoinGecko:       "https://api.coingecko.com/api/v3/simple/price?ids={ids}&vs_currencies=usd",
+}
+
+// NewRateProvider создает провайдера по имени; пустой endpoint — адрес по умолчанию
+func NewRateProvider(name, endpoint string) (RateProvider, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if endpoint == "" {
+		endpoint = defaultProviderEndpoints[name]
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch name {
+	case ProviderExchangeRateAPI:
+		return &exchangeRateAPIProvider{client: client, endpoint: endpoint}, nil
+	case ProviderECB:
+		return &ecbProvider{client: client, endpoint: endpoint}, nil
+	case ProviderCBR:
+		return &valCursProvider{name: ProviderCBR, base: "RUB", client: client, endpoint: endpoint}, nil
+	case ProviderNBT:
+		return &valCursProvider{name: ProviderNBT, base: "TJS", client: client, endpoint: endpoint}, nil
+	case ProviderCoinGecko:
+		return &coinGeckoProvider{client: client, endpoint: endpoint}, nil
+	default:
+		return nil, fmt.Errorf("unknown exchange rate provider: %s", name)
+	}
+}
+
+// fallbackRateProvider опрашивает провайдеров по порядку и возвращает первый успешный ответ
+type fallbackRateProvider struct {
+	providers []RateProvider
+}
+
+func NewFallbackRateProvider(providers ...RateProvider) RateProvider {
+	return &fallbackRateProvider{providers: providers}
+}
+
+func (p *fallbackRateProvider) Name() string {
+	names := make([]string, 0, len(p.providers))
+	for _, provider := range p.providers {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func (p *fallbackRateProvider) FetchRates(ctx context.Context) (*ProviderRates, error) {
+	if len(p.providers) == 0 {
+		return nil, errors.New("no exchange rate providers configured")
+	}
+
+	var errs []error
+	for _, provider := range p.providers {
+		rates, err := provider.FetchRates(ctx)
+		if err == nil && len(rates.Rates) == 0 {
+			err = errors.New("empty response")
+		}
+		if err != nil {
+			log.Printf("Exchange rate provider %s failed: %v", provider.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+			continue
+		}
+		return rates, nil
+	}
+
+	return nil, fmt.Errorf("all exchange rate providers failed: %w", errors.Join(errs...))
+}
+
+// rebase пересчитывает курсы к другой базовой валюте через кросс-курс
+func (r *ProviderRates) rebase(base string) (map[string]money.Decimal, error) {
+	if r.Base == base {
+		return r.Rates, nil
+	}
+
+	baseRate, ok := r.Rates[base]
+	if !ok || !baseRate.IsPositive() {
+		return nil, fmt.Errorf("%s rate not found in %s response", base, r.Provider)
+	}
+
+	rates := make(map[string]money.Decimal, len(r.Rates)+1)
+	rates[r.Base] = money.NewFromInt(1).Div(baseRate, money.RateScale, money.RoundHalfEven)
+	for code, rate := range r.Rates {
+		if code == base {
+			continue
+		}
+		rates[code] = rate.Div(baseRate, money.RateScale, money.RoundHalfEven)
+	}
+
+	return rates, nil
+}
+
+// Предельный размер ответа провайдера; фид ЕЦБ за 90 дней занимает меньше 1 МБ
+const maxRateResponseSize = 4 << 20
+
+// fetch выполняет GET и возвращает тело ответа
+func fetch(ctx context.Context, client *http.Client, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exchange rates: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRateResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if len(body) > maxRateResponseSize {
+		return nil, fmt.Errorf("response exceeds %d bytes", maxRateResponseSize)
+	}
+
+	return body, nil
+}
+
+// ExchangeRateAPIResponse структура для ответа от ExchangeRate-API
+type ExchangeRateAPIResponse struct {
+	Base  string                   `json:"base"`
+	Date  string                   `json:"date"`
+	Rates map[string]money.Decimal `json:"rates"`
+}
+
+// exchangeRateAPIProvider — JSON API exchangerate-api.com
+type exchangeRateAPIProvider struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (p *exchangeRateAPIProvider) Name() string { return ProviderExchangeRateAPI }
+
+func (p *exchangeRateAPIProvider) FetchRates(ctx context.Context) (*ProviderRates, error) {
+	body, err := fetch(ctx, p.client, p.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResponse ExchangeRateAPIResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+
+	base := apiResponse.Base
+	if base == "" {
+		base = "USD"
+	}
+
+	// Дата курсов из ответа API (если указана), иначе — сегодня
+	date := time.Now()
+	if apiResponse.Date != "" {
+		if parsed, err := time.Parse("2006-01-02", apiResponse.Date); err == nil {
+			date = parsed
+		}
+	}
+
+	for code, rate := range apiResponse.Rates {
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("invalid %s rate for %s: %s", p.Name(), code, rate)
+		}
+	}
+
+	return &ProviderRates{Provider: p.Name(), Base: base, Date: date, Rates: apiResponse.Rates}, nil
+}
+
+// ecbEnvelope — ежедневный XML-фид ЕЦБ (курсы к EUR)
+type ecbEnvelope struct {
+	Cube struct {
+		Cube []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// ecbProvider — Европейский центральный банк
+type ecbProvider struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (p *ecbProvider) Name() string { return ProviderECB }
+
+func (p *ecbProvider) FetchRates(ctx context.Context) (*ProviderRates, error) {
+	body, err := fetch(ctx, p.client, p.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %v", err)
+	}
+	if len(envelope.Cube.Cube) == 0 {
+		return nil, errors.New("no rates in ECB response")
+	}
+
+	// Фид за 90 дней содержит несколько дат; берем первую (самую свежую)
+	day := envelope.Cube.Cube[0]
+	date, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ECB date: %s", day.Time)
+	}
+
+	rates := make(map[string]money.Decimal, len(day.Rates))
+	for _, r := range day.Rates {
+		rate, err := parseRateValue(r.Rate)
+		if err != nil || !rate.IsPositive() {
+			return nil, fmt.Errorf("invalid %s rate for %s: %s", p.Name(), r.Currency, r.Rate)
+		}
+		rates[r.Currency] = rate
+	}
+
+	return &ProviderRates{Provider: p.Name(), Base: "EUR", Date: date, Rates: rates}, nil
+}
+
+// valCurs — формат ValCurs, общий для ЦБ РФ и Нацбанка Таджикистана:
+// Value национальной валюты за Nominal единиц иностранной
+type valCurs struct {
+	Date    string `xml:"Date,attr"`
+	Valutes []struct {
+		CharCode string `xml:"CharCode"`
+		Nominal  string `xml:"Nominal"`
+		Value    string `xml:"Value"`
+	} `xml:"Valute"`
+}
+
+// valCursProvider — провайдер формата ValCurs с национальной валютой base
+type valCursProvider struct {
+	name     string
+	base     string
+	client   *http.Client
+	endpoint string
+}
+
+func (p *valCursProvider) Name() string { return p.name }
+
+func (p *valCursProvider) FetchRates(ctx context.Context) (*ProviderRates, error) {
+	// Нацбанк Таджикистана отдает курсы за дату из параметра запроса
+	endpoint := strings.ReplaceAll(p.endpoint, "{date}", time.Now().Format("2006-01-02"))
+
+	body, err := fetch(ctx, p.client, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var curs valCurs
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(&curs); err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %v", err)
+	}
+
+	date := time.Now()
+	for _, layout := range []string{"02.01.2006", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, curs.Date); err == nil {
+			date = parsed
+			break
+		}
+	}
+
+	rates := make(map[string]money.Decimal, len(curs.Valutes))
+	for _, v := range curs.Valutes {
+		value, err := parseRateValue(v.Value)
+		if err != nil || !value.IsPositive() {
+			return nil, fmt.Errorf("invalid %s rate for %s: %s", p.name, v.CharCode, v.Value)
+		}
+		nominal := money.NewFromInt(1)
+		if v.Nominal != "" {
+			nominal, err = parseRateValue(v.Nominal)
+			if err != nil || !nominal.IsPositive() {
+				return nil, fmt.Errorf("invalid %s nominal for %s: %s", p.name, v.CharCode, v.Nominal)
+			}
+		}
+		// Переводим в «единиц валюты за 1 единицу национальной»
+		rates[strings.TrimSpace(v.CharCode)] = nominal.Div(value, money.RateScale, money.RoundHalfEven)
+	}
+
+	return &ProviderRates{Provider: p.name, Base: p.base, Date: date, Rates: rates}, nil
+}
+
+// parseRateValue разбирает число с точкой или запятой в качестве десятичного разделителя
+func parseRateValue(value string) (money.Decimal, error) {
+	return money.Parse(strings.ReplaceAll(strings.TrimSpace(value), ",", "."))
+}
+
+// charsetReader поддерживает windows-1251, в которой ЦБ РФ отдает XML
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8":
+		return input, nil
+	case "windows-1251", "cp1251":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(decodeWindows1251(data)), nil
+	default:
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+}
+
+// decodeWindows1251 переводит windows-1251 в UTF-8: кириллица перекодируется,
+// прочие символы верхней половины таблицы заменяются на '?'
+func decodeWindows1251(data []byte) []byte {
+	result := make([]byte, 0, len(data)*2)
+	for _, b := range data {
+		var r rune
+		switch {
+		case b < 0x80:
+			r = rune(b)
+		case b >= 0xC0:
+			r = 0x0410 + rune(b-0xC0)
+		case b == 0xA8:
+			r = 'Ё'
+		case b == 0xB8:
+			r = 'ё'
+		default:
+			r = '?'
+		}
+		result = utf8.AppendRune(result, r)
+	}
+	return result
+}
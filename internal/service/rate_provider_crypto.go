@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"personal-finance-tracker/internal/money"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProviderCoinGecko — курсы криптовалют CoinGecko
+const ProviderCoinGecko = "coingecko"
+
+// Идентификаторы монет CoinGecko для кодов криптовалют из справочника
+var coinGeckoIDs = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"USDT": "tether",
+	"USDC": "usd-coin",
+	"BNB":  "binancecoin",
+	"SOL":  "solana",
+	"XRP":  "ripple",
+	"ADA":  "cardano",
+	"DOGE": "dogecoin",
+	"TON":  "the-open-network",
+	"LTC":  "litecoin",
+	"TRX":  "tron",
+}
+
+// coinGeckoProvider — цены монет в USD; {ids} в адресе заменяется списком монет
+type coinGeckoProvider struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (p *coinGeckoProvider) Name() string { return ProviderCoinGecko }
+
+func (p *coinGeckoProvider) FetchRates(ctx context.Context) (*ProviderRates, error) {
+	ids := make([]string, 0, len(coinGeckoIDs))
+	codes := make(map[string]string, len(coinGeckoIDs))
+	for code, id := range coinGeckoIDs {
+		ids = append(ids, id)
+		codes[id] = code
+	}
+	sort.Strings(ids)
+
+	body, err := fetch(ctx, p.client, strings.ReplaceAll(p.endpoint, "{ids}", strings.Join(ids, ",")))
+	if err != nil {
+		return nil, err
+	}
+
+	// Ответ: {"bitcoin": {"usd": 67012.5}, ...}
+	var prices map[string]map[string]money.Decimal
+	if err := json.Unmarshal(body, &prices); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+
+	rates := make(map[string]money.Decimal, len(prices))
+	for id, quote := range prices {
+		code, ok := codes[id]
+		if !ok {
+			continue
+		}
+		price, ok := quote["usd"]
+		if !ok || !price.IsPositive() {
+			return nil, fmt.Errorf("invalid %s price for %s", p.Name(), code)
+		}
+		// Цена монеты в долларах — переводим в «монет за 1 USD»
+		rates[code] = money.NewFromInt(1).Div(price, money.RateScale, money.RoundHalfEven)
+	}
+
+	return &ProviderRates{Provider: p.Name(), Base: "USD", Date: time.Now(), Rates: rates}, nil
+}
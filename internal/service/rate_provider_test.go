@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFixtureServer отдает body с заданным Content-Type и считает обращения
+func newFixtureServer(t *testing.T, contentType string, body []byte) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func newTestProvider(t *testing.T, name, endpoint string) RateProvider {
+	t.Helper()
+	provider, err := NewRateProvider(name, endpoint)
+	if err != nil {
+		t.Fatalf("NewRateProvider(%q) error: %v", name, err)
+	}
+	return provider
+}
+
+// checkRates сравнивает курсы ответа с ожидаемыми строковыми значениями
+func checkRates(t *testing.T, got *ProviderRates, base string, date time.Time, want map[string]string) {
+	t.Helper()
+	if got.Base != base {
+		t.Errorf("Base = %s, want %s", got.Base, base)
+	}
+	if !got.Date.Equal(date) {
+		t.Errorf("Date = %s, want %s", got.Date, date)
+	}
+	if len(got.Rates) != len(want) {
+		t.Errorf("got %d rates, want %d: %v", len(got.Rates), len(want), got.Rates)
+	}
+	for code, rate := range want {
+		if got.Rates[code].String() != rate {
+			t.Errorf("rate %s = %s, want %s", code, got.Rates[code], rate)
+		}
+	}
+}
+
+// encodeWindows1251 кодирует ASCII и кириллицу в windows-1251 для фикстур ЦБ РФ
+func encodeWindows1251(s string) []byte {
+	result := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			result = append(result, byte(r))
+		case r >= 'А' && r <= 'я':
+			result = append(result, byte(r-'А'+0xC0))
+		case r == 'Ё':
+			result = append(result, 0xA8)
+		case r == 'ё':
+			result = append(result, 0xB8)
+		default:
+			result = append(result, '?')
+		}
+	}
+	return result
+}
+
+func TestExchangeRateAPIProvider(t *testing.T) {
+	server, _ := newFixtureServer(t, "application/json",
+		[]byte(`{"base":"USD","date":"2024-03-01","rates":{"EUR":0.9234,"RUB":"91.5","USD":1}}`))
+
+	rates, err := newTestProvider(t, ProviderExchangeRateAPI, server.URL).FetchRates(context.Background())
+	if err != nil {
+		t.Fatalf("FetchRates error: %v", err)
+	}
+
+	checkRates(t, rates, "USD", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), map[string]string{
+		"EUR": "0.9234",
+		"RUB": "91.5",
+		"USD": "1",
+	})
+}
+
+func TestExchangeRateAPIProviderRejectsNonPositiveRates(t *testing.T) {
+	for _, rate := range []string{"0", "-1.5"} {
+		server, _ := newFixtureServer(t, "application/json", []byte(`{"base":"USD","rates":{"EUR":`+rate+`}}`))
+
+		if _, err := newTestProvider(t, ProviderExchangeRateAPI, server.URL).FetchRates(context.Background()); err == nil {
+			t.Errorf("rate %s: FetchRates did not fail", rate)
+		}
+	}
+}
+
+const ecbFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time='2024-03-01'>
+			<Cube currency='USD' rate='1.0830'/>
+			<Cube currency='JPY' rate='162.48'/>
+		</Cube>
+		<Cube time='2024-02-29'>
+			<Cube currency='USD' rate='1.0813'/>
+			<Cube currency='JPY' rate='162.25'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECBProvider(t *testing.T) {
+	server, _ := newFixtureServer(t, "text/xml", []byte(ecbFixture))
+
+	rates, err := newTestProvider(t, ProviderECB, server.URL).FetchRates(context.Background())
+	if err != nil {
+		t.Fatalf("FetchRates error: %v", err)
+	}
+
+	// Из фида за несколько дней берется самая свежая дата
+	checkRates(t, rates, "EUR", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), map[string]string{
+		"USD": "1.0830",
+		"JPY": "162.48",
+	})
+}
+
+func TestECBProviderRejectsInvalidRates(t *testing.T) {
+	for _, rate := range []string{"0", "-1.0830", "n/a"} {
+		body := strings.Replace(ecbFixture, "rate='1.0830'", "rate='"+rate+"'", 1)
+		server, _ := newFixtureServer(t, "text/xml", []byte(body))
+
+		if _, err := newTestProvider(t, ProviderECB, server.URL).FetchRates(context.Background()); err == nil {
+			t.Errorf("rate %s: FetchRates did not fail", rate)
+		}
+	}
+}
+
+func TestCBRProvider(t *testing.T) {
+	// ЦБ РФ отдает XML в windows-1251, курс — рублей за Nominal единиц валюты
+	body := encodeWindows1251(`<?xml version="1.0" encoding="windows-1251"?>
+<ValCurs Date="01.03.2024" name="Foreign Currency Market">
+	<Valute ID="R01235">
+		<NumCode>840</NumCode>
+		<CharCode>USD</CharCode>
+		<Nominal>1</Nominal>
+		<Name>Доллар США</Name>
+		<Value>80,0000</Value>
+	</Valute>
+	<Valute ID="R01060">
+		<NumCode>051</NumCode>
+		<CharCode>AMD</CharCode>
+		<Nominal>100</Nominal>
+		<Name>Армянских драмов</Name>
+		<Value>20,0000</Value>
+	</Valute>
+</ValCurs>`)
+	server, _ := newFixtureServer(t, "application/xml; charset=windows-1251", body)
+
+	rates, err := newTestProvider(t, ProviderCBR, server.URL).FetchRates(context.Background())
+	if err != nil {
+		t.Fatalf("FetchRates error: %v", err)
+	}
+
+	checkRates(t, rates, "RUB", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), map[string]string{
+		"USD": "0.012500000000000000",
+		"AMD": "5.000000000000000000",
+	})
+}
+
+func TestNBTProvider(t *testing.T) {
+	var requestedDate atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedDate.Store(r.URL.Query().Get("date"))
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ValCurs Date="2024-03-01" name="Official exchange rate">
+	<Valute ID="840">
+		<CharCode>USD</CharCode>
+		<Nominal>1</Nominal>
+		<Name>Доллар США</Name>
+		<Value>10.9375</Value>
+	</Valute>
+	<Valute ID="643">
+		<CharCode>RUB</CharCode>
+		<Nominal>10</Nominal>
+		<Name>Российский рубль</Name>
+		<Value>1.2500</Value>
+	</Valute>
+</ValCurs>`))
+	}))
+	defer server.Close()
+
+	rates, err := newTestProvider(t, ProviderNBT, server.URL+"/?date={date}").FetchRates(context.Background())
+	if err != nil {
+		t.Fatalf("FetchRates error: %v", err)
+	}
+
+	if got, want := requestedDate.Load(), time.Now().Format("2006-01-02"); got != want {
+		t.Errorf("requested date = %v, want %s", got, want)
+	}
+	checkRates(t, rates, "TJS", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), map[string]string{
+		"USD": "0.091428571428571429",
+		"RUB": "8.000000000000000000",
+	})
+}
+
+func TestValCursProviderRejectsInvalidValues(t *testing.T) {
+	tests := []struct{ nominal, value string }{
+		{"1", "0"},
+		{"1", "-80,0000"},
+		{"0", "80,0000"},
+		{"-10", "80,0000"},
+		{"1", "abc"},
+	}
+
+	for _, tt := range tests {
+		server, _ := newFixtureServer(t, "application/xml", []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ValCurs Date="01.03.2024"><Valute><CharCode>USD</CharCode><Nominal>`+tt.nominal+`</Nominal><Value>`+tt.value+`</Value></Valute></ValCurs>`))
+
+		if _, err := newTestProvider(t, ProviderCBR, server.URL).FetchRates(context.Background()); err == nil {
+			t.Errorf("nominal %s, value %s: FetchRates did not fail", tt.nominal, tt.value)
+		}
+	}
+}
+
+func TestDecodeWindows1251(t *testing.T) {
+	input := "Курс ЦБ: Ёлка, ёж, 42"
+	if got := string(decodeWindows1251(encodeWindows1251(input))); got != input {
+		t.Errorf("decodeWindows1251 = %q, want %q", got, input)
+	}
+}
+
+func TestFetchRejectsOversizedResponse(t *testing.T) {
+	server, _ := newFixtureServer(t, "application/json", make([]byte, maxRateResponseSize+1))
+
+	if _, err := fetch(context.Background(), http.DefaultClient, server.URL); err == nil {
+		t.Error("fetch of an oversized response did not fail")
+	}
+}
+
+func TestFetchHonoursContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := newTestProvider(t, ProviderExchangeRateAPI, server.URL).FetchRates(ctx); err == nil {
+		t.Error("FetchRates did not fail after the context deadline")
+	}
+}
+
+func TestFallbackRateProvider(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	empty, emptyHits := newFixtureServer(t, "application/json", []byte(`{"base":"USD","rates":{}}`))
+	ecb, ecbHits := newFixtureServer(t, "text/xml", []byte(ecbFixture))
+	unused, unusedHits := newFixtureServer(t, "application/json", []byte(`{"base":"USD","rates":{"EUR":0.92}}`))
+
+	provider := NewFallbackRateProvider(
+		newTestProvider(t, ProviderExchangeRateAPI, failing.URL),
+		newTestProvider(t, ProviderExchangeRateAPI, empty.URL),
+		newTestProvider(t, ProviderECB, ecb.URL),
+		newTestProvider(t, ProviderExchangeRateAPI, unused.URL),
+	)
+
+	rates, err := provider.FetchRates(context.Background())
+	if err != nil {
+		t.Fatalf("FetchRates error: %v", err)
+	}
+
+	// Ошибка и пустой ответ пропускаются, после первого успешного ответа опрос прекращается
+	if rates.Provider != ProviderECB {
+		t.Errorf("Provider = %s, want %s", rates.Provider, ProviderECB)
+	}
+	if emptyHits.Load() != 1 || ecbHits.Load() != 1 {
+		t.Errorf("providers before the successful one were not queried in order")
+	}
+	if unusedHits.Load() != 0 {
+		t.Errorf("provider after the successful one was queried %d times", unusedHits.Load())
+	}
+	if got := provider.Name(); got != "exchangerate-api,exchangerate-api,ecb,exchangerate-api" {
+		t.Errorf("Name() = %s", got)
+	}
+}
+
+func TestFallbackRateProviderAllFail(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	provider := NewFallbackRateProvider(
+		newTestProvider(t, ProviderExchangeRateAPI, failing.URL),
+		newTestProvider(t, ProviderECB, failing.URL),
+	)
+
+	_, err := provider.FetchRates(context.Background())
+	if err == nil {
+		t.Fatal("FetchRates did not fail")
+	}
+	for _, name := range []string{ProviderExchangeRateAPI, ProviderECB} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention %s", err, name)
+		}
+	}
+
+	if _, err := NewFallbackRateProvider().FetchRates(context.Background()); err == nil {
+		t.Error("empty chain did not fail")
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"personal-finance-tracker/internal/repository"
+	"sort"
+	"time"
+)
+
+// resolveReportCurrency выбирает валюту отчета: явно заданную, валюту пользователя или USD
+func resolveReportCurrency(ctx context.Context, repo repository.Repository, userID, currencyID int) (*models.Currency, error) {
+	if currencyID == 0 {
+		user, err := repo.GetUserByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("user not found")
+		}
+		if user.DefaultCurrencyID != nil {
+			currencyID = *user.DefaultCurrencyID
+		}
+	}
+
+	var currency *models.Currency
+	var err error
+	if currencyID != 0 {
+		currency, err = repo.GetCurrencyByID(ctx, currencyID)
+	} else {
+		currency, err = repo.GetCurrencyByCode("USD")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if currency == nil {
+		return nil, errors.New("currency not found")
+	}
+
+	return currency, nil
+}
+
+// currencyConverter пересчитывает суммы в валюту отчета по курсам пользователя (личные курсы
+// приоритетнее общих), запоминая курсы в пределах одного отчета
+type currencyConverter struct {
+	exchangeService ExchangeService
+	userID          int
+	target          *models.Currency
+	rates           map[converterKey]money.Decimal
+}
+
+// converterKey — исходная валюта и дата курса
+type converterKey struct {
+	currencyID int
+	date       string
+}
+
+func newCurrencyConverter(exchangeService ExchangeService, userID int, target *models.Currency) *currencyConverter {
+	return &currencyConverter{
+		exchangeService: exchangeService,
+		userID:          userID,
+		target:          target,
+		rates:           make(map[converterKey]money.Decimal),
+	}
+}
+
+// convert пересчитывает сумму из валюты fromCurrencyID по курсу, действовавшему на дату,
+// и округляет до минимальной единицы валюты отчета
+func (c *currencyConverter) convert(ctx context.Context, amount money.Decimal, fromCurrencyID int, date time.Time) (money.Decimal, error) {
+	if fromCurrencyID == c.target.ID {
+		return amount, nil
+	}
+
+	key := converterKey{currencyID: fromCurrencyID, date: date.Format("2006-01-02")}
+	rate, ok := c.rates[key]
+	if !ok {
+		exchangeRate, err := c.exchangeService.GetUserExchangeRate(ctx, c.userID, fromCurrencyID, c.target.ID, date)
+		if err != nil {
+			return money.Zero, err
+		}
+		rate = exchangeRate.Rate
+		c.rates[key] = rate
+	}
+
+	return convertMoney(amount, rate, c.target, money.RoundHalfEven), nil
+}
+
+// currencyTotals накапливает подытоги в исходных валютах
+type currencyTotals map[int]*models.CurrencySubtotal
+
+func (t currencyTotals) add(currency *models.Currency, amount money.Decimal, isIncome bool) {
+	subtotal, ok := t[currency.ID]
+	if !ok {
+		subtotal = &models.CurrencySubtotal{CurrencyID: currency.ID, CurrencyCode: currency.Code}
+		t[currency.ID] = subtotal
+	}
+
+	if isIncome {
+		subtotal.TotalIncome = subtotal.TotalIncome.Add(amount)
+	} else {
+		subtotal.TotalExpense = subtotal.TotalExpense.Add(amount)
+	}
+	subtotal.NetAmount = subtotal.TotalIncome.Sub(subtotal.TotalExpense)
+	subtotal.TransactionCount++
+}
+
+func (t currencyTotals) list() []models.CurrencySubtotal {
+	result := make([]models.CurrencySubtotal, 0, len(t))
+	for _, subtotal := range t {
+		result = append(result, *subtotal)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CurrencyCode < result[j].CurrencyCode
+	})
+
+	return result
+}
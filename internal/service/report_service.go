@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"personal-finance-tracker/internal/repository"
+	"time"
+)
+
+// Ограничение на число точек в отчете, чтобы не считать историю по дням за десятилетия
+const maxReportPoints = 1000
+
+// Пассивные виды счетов: их баланс — задолженность
+var liabilityAccountKinds = map[string]bool{
+	"credit_card": true,
+	"loan":        true,
+}
+
+type ReportService interface {
+	GetNetWorth(ctx context.Context, userID, currencyID int, start, end time.Time, interval string) (*models.NetWorthReport, error)
+}
+
+type reportService struct {
+	repo              repository.Repository
+	exchangeService   ExchangeService
+	investmentService InvestmentService
+}
+
+func NewReportService(repo repository.Repository, exchangeService ExchangeService, investmentService InvestmentService) ReportService {
+	return &reportService{
+		repo:              repo,
+		exchangeService:   exchangeService,
+		investmentService: investmentService,
+	}
+}
+
+// GetNetWorth считает активы минус пассивы на каждую дату периода в выбранной валюте.
+// currencyID = 0 означает валюту пользователя по умолчанию (или USD, если она не выбрана).
+func (s *reportService) GetNetWorth(ctx context.Context, userID, currencyID int, start, end time.Time, interval string) (*models.NetWorthReport, error) {
+	if end.Before(start) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	if interval == "" {
+		interval = "month"
+	}
+
+	currency, err := resolveReportCurrency(ctx, s.repo, userID, currencyID)
+	if err != nil {
+		return nil, err
+	}
+
+	dates, err := reportDates(start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.repo.GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.repo.GetTransactionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Движения по счетам для восстановления баланса на прошлые даты
+	flows := make(map[int][]balanceFlow)
+	for _, t := range transactions {
+		if t.AccountID == nil {
+			continue
+		}
+		amount := t.Amount
+		if t.Type != "income" {
+			amount = amount.Neg()
+		}
+		flows[*t.AccountID] = append(flows[*t.AccountID], balanceFlow{date: t.Date, amount: amount})
+	}
+	transfers, err := s.repo.GetTransfersByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range transfers {
+		flows[t.FromAccountID] = append(flows[t.FromAccountID], balanceFlow{date: t.Date, amount: t.FromAmount.Neg()})
+		flows[t.ToAccountID] = append(flows[t.ToAccountID], balanceFlow{date: t.Date, amount: t.ToAmount})
+	}
+	for _, account := range accounts {
+		if account.Kind != "investment" {
+			continue
+		}
+		trades, err := s.repo.GetInvestmentTransactionsByAccountID(ctx, account.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range trades {
+			flows[account.ID] = append(flows[account.ID], balanceFlow{date: t.Date, amount: t.Amount})
+		}
+	}
+
+	report := &models.NetWorthReport{
+		CurrencyID:   currency.ID,
+		CurrencyCode: currency.Code,
+		Interval:     interval,
+		PeriodStart:  start.Format("2006-01-02"),
+		PeriodEnd:    end.Format("2006-01-02"),
+		Points:       make([]models.NetWorthPoint, 0, len(dates)),
+	}
+
+	converter := newCurrencyConverter(s.exchangeService, userID, currency)
+	for _, date := range dates {
+		point := models.NetWorthPoint{
+			Date:          date.Format("2006-01-02"),
+			ByAccountType: make(map[string]money.Decimal),
+		}
+
+		for _, account := range accounts {
+			// Счет, открытый после даты, еще не существовал
+			if account.CreatedAt.After(endOfDay(date)) {
+				continue
+			}
+
+			value := balanceAt(account.Balance, flows[account.ID], date)
+			if account.Kind == "investment" {
+				holdings, err := s.investmentService.GetHoldingsValueAt(ctx, account.ID, date)
+				if err != nil {
+					return nil, err
+				}
+				value = value.Add(holdings)
+			}
+
+			value, err = converter.convert(ctx, value, account.CurrencyID, date)
+			if err != nil {
+				return nil, err
+			}
+
+			point.ByAccountType[account.Kind] = point.ByAccountType[account.Kind].Add(value)
+			if liabilityAccountKinds[account.Kind] {
+				point.Liabilities = point.Liabilities.Sub(value)
+			} else {
+				point.Assets = point.Assets.Add(value)
+			}
+		}
+
+		point.NetWorth = point.Assets.Sub(point.Liabilities)
+		report.Points = append(report.Points, point)
+	}
+
+	return report, nil
+}
+
+// balanceFlow — изменение баланса счета на дату
+type balanceFlow struct {
+	date   time.Time
+	amount money.Decimal
+}
+
+// balanceAt восстанавливает баланс на конец дня, откатывая движения после даты
+func balanceAt(current money.Decimal, flows []balanceFlow, date time.Time) money.Decimal {
+	balance := current
+	cutoff := endOfDay(date)
+	for _, f := range flows {
+		if f.date.After(cutoff) {
+			balance = balance.Sub(f.amount)
+		}
+	}
+	return balance
+}
+
+// reportDates строит даты отчета с шагом interval; конец периода всегда включается
+func reportDates(start, end time.Time, interval string) ([]time.Time, error) {
+	step := func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	switch interval {
+	case "day":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case "week":
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case "month":
+	default:
+		return nil, errors.New("invalid interval, use day, week or month")
+	}
+
+	var dates []time.Time
+	for d := start; !d.After(end); d = step(d) {
+		dates = append(dates, d)
+		if len(dates) > maxReportPoints {
+			return nil, errors.New("too many report points, use a larger interval")
+		}
+	}
+	if len(dates) == 0 || !dates[len(dates)-1].Equal(end) {
+		dates = append(dates, end)
+	}
+
+	return dates, nil
+}
+
+func endOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
 	"personal-finance-tracker/internal/repository"
 	"time"
 )
@@ -13,9 +14,9 @@ type TransactionService interface {
 	GetUserTransactions(ctx context.Context, userID int) ([]models.Transaction, error)
 	GetUserTransactionsByPeriod(ctx context.Context, userID int, start, end time.Time) ([]models.Transaction, error)
 	GetTransactionByID(ctx context.Context, id int) (*models.Transaction, error)
-	GetTransactionSummary(ctx context.Context, userID int, start, end time.Time) (*models.TransactionSummary, error)
-	GetTransactionsByCategory(ctx context.Context, userID int, start, end time.Time) ([]models.CategorySummary, error)
-	GetMonthlySummary(ctx context.Context, userID int, year int) ([]models.MonthlySummary, error)
+	GetTransactionSummary(ctx context.Context, userID, currencyID int, start, end time.Time) (*models.TransactionSummary, error)
+	GetTransactionsByCategory(ctx context.Context, userID, currencyID int, start, end time.Time) ([]models.CategorySummary, error)
+	GetMonthlySummary(ctx context.Context, userID, currencyID int, year int) ([]models.MonthlySummary, error)
 	GetAccountTransactions(ctx context.Context, accountID int) ([]models.Transaction, error)
 	GetDefaultAccountTransactions(ctx context.Context, userID int) ([]models.Transaction, error)
 	GetDefaultAccountTransactionsByPeriod(ctx context.Context, userID int, start, end time.Time) ([]models.Transaction, error)
@@ -23,15 +24,19 @@ type TransactionService interface {
 }
 
 type transactionService struct {
-	repo           repository.Repository
-	accountService AccountService
+	repo            repository.Repository
+	accountService  AccountService
+	exchangeService ExchangeService
+	audit           AuditService
 }
 
-func NewTransactionService(repo repository.Repository) TransactionService {
-	accountService := NewAccountService(repo)
+func NewTransactionService(repo repository.Repository, exchangeService ExchangeService, audit AuditService) TransactionService {
+	accountService := NewAccountService(repo, audit)
 	return &transactionService{
-		repo:           repo,
-		accountService: accountService,
+		repo:            repo,
+		accountService:  accountService,
+		exchangeService: exchangeService,
+		audit:           audit,
 	}
 }
 
@@ -50,18 +55,19 @@ func (s *transactionService) CreateTransaction(ctx context.Context, transaction
 	}
 
 	// Если account_id не указан, используем дефолтный счет пользователя
+	var account *models.Account
 	if transaction.AccountID == nil {
-		defaultAccount, err := s.accountService.GetDefaultAccount(context.Background(), transaction.UserID)
+		account, err = s.accountService.GetDefaultAccount(context.Background(), transaction.UserID)
 		if err != nil {
 			return err
 		}
-		if defaultAccount == nil {
+		if account == nil {
 			return errors.New("no default account found")
 		}
-		transaction.AccountID = &defaultAccount.ID
+		transaction.AccountID = &account.ID
 	} else {
 		// Проверяем, что счет принадлежит пользователю
-		account, err := s.accountService.GetAccountByID(context.Background(), *transaction.AccountID)
+		account, err = s.accountService.GetAccountByID(context.Background(), *transaction.AccountID)
 		if err != nil {
 			return err
 		}
@@ -73,19 +79,23 @@ func (s *transactionService) CreateTransaction(ctx context.Context, transaction
 		}
 	}
 
-	// Создаем транзакцию
-	err = s.repo.CreateTransaction(ctx, transaction)
-	if err != nil {
+	// Сумма не может быть точнее минимальной единицы валюты счета (0.01 USD, 1 сатоши)
+	if err := checkPrecision(transaction.Amount, account.Currency); err != nil {
 		return err
 	}
 
-	// Обновляем баланс счета
-	err = s.accountService.UpdateAccountBalance(*transaction.AccountID, transaction.Amount, transaction.Type == "income")
-	if err != nil {
-		return err
-	}
+	// Транзакция, баланс счета и запись журнала сохраняются вместе
+	return s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.CreateTransaction(ctx, transaction); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, tx, transaction.UserID, models.AuditCreate, AuditEntityTransaction, transaction.ID, nil, transaction); err != nil {
+			return err
+		}
 
-	return nil
+		// Обновляем баланс счета
+		return NewAccountService(tx, s.audit).UpdateAccountBalance(*transaction.AccountID, transaction.Amount, transaction.Type == "income")
+	})
 }
 
 func (s *transactionService) GetUserTransactions(ctx context.Context, userID int) ([]models.Transaction, error) {
@@ -104,53 +114,76 @@ func (s *transactionService) GetAccountTransactions(ctx context.Context, account
 	return s.repo.GetTransactionsByAccountID(ctx, accountID)
 }
 
-func (s *transactionService) GetTransactionSummary(ctx context.Context, userID int, start, end time.Time) (*models.TransactionSummary, error) {
-	transactions, err := s.GetUserTransactionsByPeriod(context.Background(), userID, start, end)
+// GetTransactionSummary считает итоги за период в валюте отчета (currencyID = 0 — валюта пользователя)
+func (s *transactionService) GetTransactionSummary(ctx context.Context, userID, currencyID int, start, end time.Time) (*models.TransactionSummary, error) {
+	report, err := s.newReportContext(ctx, userID, currencyID)
 	if err != nil {
 		return nil, err
 	}
 
-	var totalIncome, totalExpense float64
+	transactions, err := s.GetUserTransactionsByPeriod(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.TransactionSummary{
+		TransactionCount: len(transactions),
+		PeriodStart:      start.Format("2006-01-02"),
+		PeriodEnd:        end.Format("2006-01-02"),
+		CurrencyID:       report.converter.target.ID,
+		CurrencyCode:     report.converter.target.Code,
+	}
+	totals := make(currencyTotals)
 
 	for _, t := range transactions {
+		converted, currency, err := report.convert(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+
 		if t.Type == "income" {
-			totalIncome += t.Amount
+			summary.TotalIncome = summary.TotalIncome.Add(converted)
 		} else {
-			totalExpense += t.Amount
+			summary.TotalExpense = summary.TotalExpense.Add(converted)
 		}
+		totals.add(currency, t.Amount, t.Type == "income")
 	}
 
-	return &models.TransactionSummary{
-		TotalIncome:      totalIncome,
-		TotalExpense:     totalExpense,
-		NetAmount:        totalIncome - totalExpense,
-		TransactionCount: len(transactions),
-		PeriodStart:      start.Format("2006-01-02"),
-		PeriodEnd:        end.Format("2006-01-02"),
-	}, nil
+	summary.NetAmount = summary.TotalIncome.Sub(summary.TotalExpense)
+	summary.ByCurrency = totals.list()
+	return summary, nil
 }
 
-func (s *transactionService) GetTransactionsByCategory(ctx context.Context, userID int, start, end time.Time) ([]models.CategorySummary, error) {
-	transactions, err := s.GetUserTransactionsByPeriod(context.Background(), userID, start, end)
+func (s *transactionService) GetTransactionsByCategory(ctx context.Context, userID, currencyID int, start, end time.Time) ([]models.CategorySummary, error) {
+	report, err := s.newReportContext(ctx, userID, currencyID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.GetUserTransactionsByPeriod(ctx, userID, start, end)
 	if err != nil {
 		return nil, err
 	}
 
 	categoryMap := make(map[int]*models.CategorySummary)
-	var totalIncome, totalExpense float64
+	categoryTotals := make(map[int]currencyTotals)
+	totalIncome, totalExpense := money.Zero, money.Zero
+
+	newSummary := func(c *models.Category) *models.CategorySummary {
+		return &models.CategorySummary{
+			CategoryID:   c.ID,
+			CategoryName: c.Name,
+			Type:         c.Type,
+			CurrencyID:   report.converter.target.ID,
+			CurrencyCode: report.converter.target.Code,
+		}
+	}
 
 	// Предзагрузка категорий для устранения N+1
 	categories, err := s.repo.GetCategoriesByUserID(ctx, userID)
 	if err == nil {
 		for i := range categories {
-			c := categories[i]
-			categoryMap[c.ID] = &models.CategorySummary{
-				CategoryID:   c.ID,
-				CategoryName: c.Name,
-				Type:         c.Type,
-				TotalAmount:  0,
-				Count:        0,
-			}
+			categoryMap[categories[i].ID] = newSummary(&categories[i])
 		}
 	}
 
@@ -161,36 +194,43 @@ func (s *transactionService) GetTransactionsByCategory(ctx context.Context, user
 			if err != nil || category == nil {
 				continue
 			}
-			categoryMap[t.CategoryID] = &models.CategorySummary{
-				CategoryID:   t.CategoryID,
-				CategoryName: category.Name,
-				Type:         category.Type,
-				TotalAmount:  0,
-				Count:        0,
-			}
+			categoryMap[t.CategoryID] = newSummary(category)
 		}
 
-		categoryMap[t.CategoryID].TotalAmount += t.Amount
+		converted, currency, err := report.convert(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+
+		categoryMap[t.CategoryID].TotalAmount = categoryMap[t.CategoryID].TotalAmount.Add(converted)
 		categoryMap[t.CategoryID].Count++
 
+		if categoryTotals[t.CategoryID] == nil {
+			categoryTotals[t.CategoryID] = make(currencyTotals)
+		}
+		categoryTotals[t.CategoryID].add(currency, t.Amount, t.Type == "income")
+
 		if t.Type == "income" {
-			totalIncome += t.Amount
+			totalIncome = totalIncome.Add(converted)
 		} else {
-			totalExpense += t.Amount
+			totalExpense = totalExpense.Add(converted)
 		}
 	}
 
 	result := make([]models.CategorySummary, 0, len(categoryMap))
 	for _, summary := range categoryMap {
-		var total float64
+		var total money.Decimal
 		if summary.Type == "income" {
 			total = totalIncome
 		} else {
 			total = totalExpense
 		}
 
-		if total > 0 {
-			summary.Percentage = (summary.TotalAmount / total) * 100
+		if total.IsPositive() {
+			summary.Percentage = (summary.TotalAmount.Float64() / total.Float64()) * 100
+		}
+		if totals, ok := categoryTotals[summary.CategoryID]; ok {
+			summary.ByCurrency = totals.list()
 		}
 
 		result = append(result, *summary)
@@ -199,31 +239,105 @@ func (s *transactionService) GetTransactionsByCategory(ctx context.Context, user
 	return result, nil
 }
 
-func (s *transactionService) GetMonthlySummary(ctx context.Context, userID int, year int) ([]models.MonthlySummary, error) {
-	result := make([]models.MonthlySummary, 0, 12)
+func (s *transactionService) GetMonthlySummary(ctx context.Context, userID, currencyID int, year int) ([]models.MonthlySummary, error) {
+	report, err := s.newReportContext(ctx, userID, currencyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Загружаем год целиком и раскладываем по месяцам
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	transactions, err := s.GetUserTransactionsByPeriod(ctx, userID, yearStart, yearEnd)
+	if err != nil {
+		return nil, err
+	}
 
-	for month := 1; month <= 12; month++ {
-		start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-		end := start.AddDate(0, 1, -1)
+	result := make([]models.MonthlySummary, 12)
+	totals := make([]currencyTotals, 12)
+	for month := 0; month < 12; month++ {
+		result[month] = models.MonthlySummary{
+			Month:        time.Date(year, time.Month(month+1), 1, 0, 0, 0, 0, time.UTC).Format("2006-01"),
+			CurrencyID:   report.converter.target.ID,
+			CurrencyCode: report.converter.target.Code,
+		}
+		totals[month] = make(currencyTotals)
+	}
 
-		summary, err := s.GetTransactionSummary(ctx, userID, start, end)
+	for _, t := range transactions {
+		converted, currency, err := report.convert(ctx, t)
 		if err != nil {
-			continue
+			return nil, err
 		}
 
-		monthly := models.MonthlySummary{
-			Month:        start.Format("2006-01"),
-			TotalIncome:  summary.TotalIncome,
-			TotalExpense: summary.TotalExpense,
-			NetAmount:    summary.NetAmount,
+		month := int(t.Date.Month()) - 1
+		if t.Type == "income" {
+			result[month].TotalIncome = result[month].TotalIncome.Add(converted)
+		} else {
+			result[month].TotalExpense = result[month].TotalExpense.Add(converted)
 		}
+		totals[month].add(currency, t.Amount, t.Type == "income")
+	}
 
-		result = append(result, monthly)
+	for month := range result {
+		result[month].NetAmount = result[month].TotalIncome.Sub(result[month].TotalExpense)
+		result[month].ByCurrency = totals[month].list()
 	}
 
 	return result, nil
 }
 
+// transactionReport хранит валюты счетов пользователя для пересчета транзакций в валюту отчета
+type transactionReport struct {
+	converter       *currencyConverter
+	accountCurrency map[int]*models.Currency
+	defaultCurrency *models.Currency
+}
+
+func (s *transactionService) newReportContext(ctx context.Context, userID, currencyID int) (*transactionReport, error) {
+	target, err := resolveReportCurrency(ctx, s.repo, userID, currencyID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.repo.GetAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &transactionReport{
+		converter:       newCurrencyConverter(s.exchangeService, userID, target),
+		accountCurrency: make(map[int]*models.Currency, len(accounts)),
+		defaultCurrency: target,
+	}
+	for _, account := range accounts {
+		report.accountCurrency[account.ID] = account.Currency
+		if account.IsDefault {
+			report.defaultCurrency = account.Currency
+		}
+	}
+
+	return report, nil
+}
+
+// convert возвращает сумму транзакции в валюте отчета и исходную валюту.
+// Транзакции без счета считаются в валюте основного счета.
+func (r *transactionReport) convert(ctx context.Context, t models.Transaction) (money.Decimal, *models.Currency, error) {
+	currency := r.defaultCurrency
+	if t.AccountID != nil {
+		if c, ok := r.accountCurrency[*t.AccountID]; ok {
+			currency = c
+		}
+	}
+
+	converted, err := r.converter.convert(ctx, t.Amount, currency.ID, t.Date)
+	if err != nil {
+		return money.Zero, nil, err
+	}
+
+	return converted, currency, nil
+}
+
 func (s *transactionService) GetDefaultAccountTransactions(ctx context.Context, userID int) ([]models.Transaction, error) {
 	defaultAccount, err := s.accountService.GetDefaultAccount(ctx, userID)
 	if err != nil {
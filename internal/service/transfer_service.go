@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
+	"personal-finance-tracker/internal/repository"
+	"time"
+)
+
+type TransferService interface {
+	CreateTransfer(ctx context.Context, userID int, req *models.TransferRequest) (*models.Transfer, error)
+	GetUserTransfers(ctx context.Context, userID int) ([]models.Transfer, error)
+}
+
+type transferService struct {
+	repo            repository.Repository
+	accountService  AccountService
+	exchangeService ExchangeService
+	audit           AuditService
+}
+
+func NewTransferService(repo repository.Repository, exchangeService ExchangeService, audit AuditService) TransferService {
+	accountService := NewAccountService(repo, audit)
+	return &transferService{
+		repo:            repo,
+		accountService:  accountService,
+		exchangeService: exchangeService,
+		audit:           audit,
+	}
+}
+
+// CreateTransfer переводит сумму между счетами пользователя и сохраняет фактический курс.
+// Между валютами курс берется из запроса (rate или to_amount), иначе — личный или общий курс на дату.
+func (s *transferService) CreateTransfer(ctx context.Context, userID int, req *models.TransferRequest) (*models.Transfer, error) {
+	if req.FromAccountID == req.ToAccountID {
+		return nil, errors.New("source and target accounts must differ")
+	}
+	if req.Rate != nil && req.ToAmount != nil {
+		return nil, errors.New("specify either rate or to_amount, not both")
+	}
+
+	fromAccount, err := s.getOwnedAccount(ctx, userID, req.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	toAccount, err := s.getOwnedAccount(ctx, userID, req.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPrecision(req.Amount, fromAccount.Currency); err != nil {
+		return nil, err
+	}
+
+	date := time.Now()
+	if req.Date != "" {
+		date, err = time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return nil, errors.New("invalid date format, use YYYY-MM-DD")
+		}
+	}
+
+	transfer := &models.Transfer{
+		UserID:        userID,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		FromAmount:    req.Amount,
+		Date:          date,
+		Description:   req.Description,
+	}
+
+	switch {
+	case fromAccount.CurrencyID == toAccount.CurrencyID:
+		if req.Rate != nil || req.ToAmount != nil {
+			return nil, errors.New("rate can only be set for transfers between currencies")
+		}
+		transfer.Rate = money.NewFromInt(1)
+		transfer.ToAmount = req.Amount
+		transfer.RateSource = RateSourceSame
+	case req.ToAmount != nil:
+		if !req.ToAmount.IsPositive() {
+			return nil, errors.New("to_amount must be positive")
+		}
+		if err := checkPrecision(*req.ToAmount, toAccount.Currency); err != nil {
+			return nil, err
+		}
+		transfer.ToAmount = *req.ToAmount
+		transfer.Rate = req.ToAmount.Div(req.Amount, money.RateScale, money.RoundHalfEven).Normalize()
+		transfer.RateSource = RateSourceManual
+	case req.Rate != nil:
+		if !req.Rate.IsPositive() {
+			return nil, errors.New("rate must be positive")
+		}
+		transfer.Rate = *req.Rate
+		transfer.ToAmount = convertMoney(req.Amount, *req.Rate, toAccount.Currency, money.RoundHalfEven)
+		transfer.RateSource = RateSourceManual
+	default:
+		exchangeRate, err := s.exchangeService.GetUserExchangeRate(ctx, userID, fromAccount.CurrencyID, toAccount.CurrencyID, date)
+		if err != nil {
+			return nil, err
+		}
+		transfer.Rate = exchangeRate.Rate
+		transfer.ToAmount = convertMoney(req.Amount, exchangeRate.Rate, toAccount.Currency, money.RoundHalfEven)
+		transfer.RateSource = RateSourceGlobal
+		if exchangeRate.Source == RateSourceUser {
+			transfer.RateSource = RateSourceUser
+		}
+	}
+
+	if !transfer.ToAmount.IsPositive() {
+		return nil, errors.New("amount is too small to transfer at this rate")
+	}
+
+	// Перевод, балансы обоих счетов и запись журнала сохраняются в одной транзакции:
+	// списание без зачисления невозможно
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.CreateTransfer(ctx, transfer); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, tx, userID, models.AuditCreate, AuditEntityTransfer, transfer.ID, nil, transfer); err != nil {
+			return err
+		}
+
+		accounts := NewAccountService(tx, s.audit)
+		if err := accounts.UpdateAccountBalance(fromAccount.ID, transfer.FromAmount, false); err != nil {
+			return err
+		}
+		return accounts.UpdateAccountBalance(toAccount.ID, transfer.ToAmount, true)
+	}); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+func (s *transferService) GetUserTransfers(ctx context.Context, userID int) ([]models.Transfer, error) {
+	return s.repo.GetTransfersByUserID(ctx, userID)
+}
+
+func (s *transferService) getOwnedAccount(ctx context.Context, userID, accountID int) (*models.Account, error) {
+	account, err := s.accountService.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("account not found")
+	}
+	if account.UserID != userID {
+		return nil, errors.New("account does not belong to user")
+	}
+
+	return account, nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"personal-finance-tracker/internal/utils"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Число кодов восстановления и алфавит кода (base32 без похожих 0/1/8/9)
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+type TwoFactorService interface {
+	GetStatus(ctx context.Context, userID int) (*models.TwoFactorStatus, error)
+	// Setup выпускает новый секрет; 2FA включается только после подтверждения кодом (Enable)
+	Setup(ctx context.Context, user *models.User) (*models.TwoFactorSetup, error)
+	// Enable подтверждает секрет кодом из приложения и возвращает коды восстановления —
+	// они показываются один раз
+	Enable(ctx context.Context, userID int, code string) ([]string, error)
+	Disable(ctx context.Context, userID int, req *models.DisableTwoFactorRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+	// VerifyCode проверяет код из приложения или код восстановления; каждый код принимается один раз
+	VerifyCode(ctx context.Context, userID int, code string) (bool, error)
+}
+
+type twoFactorService struct {
+	repo          repository.Repository
+	encryptionKey string
+	issuer        string
+}
+
+// NewTwoFactorService создает сервис 2FA. Секреты TOTP хранятся зашифрованными ключом
+// encryptionKey; issuer — название сервиса в приложении-аутентификаторе.
+func NewTwoFactorService(repo repository.Repository, encryptionKey, issuer string) TwoFactorService {
+	return &twoFactorService{
+		repo:          repo,
+		encryptionKey: encryptionKey,
+		issuer:        issuer,
+	}
+}
+
+func (s *twoFactorService) GetStatus(ctx context.Context, userID int) (*models.TwoFactorStatus, error) {
+	state, err := s.repo.GetTOTPState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.TwoFactorStatus{}
+	if state != nil && state.EnabledAt != nil {
+		status.Enabled = true
+		status.EnabledAt = state.EnabledAt
+		if status.RecoveryCodesRemaining, err = s.repo.CountUnusedRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+func (s *twoFactorService) Setup(ctx context.Context, user *models.User) (*models.TwoFactorSetup, error) {
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptSecret(s.encryptionKey, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPendingTOTPSecret(ctx, user.ID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *twoFactorService) Enable(ctx context.Context, userID int, code string) ([]string, error) {
+	state, err := s.repo.GetTOTPState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, errors.New("two-factor setup not started")
+	}
+	if state.EnabledAt != nil {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	ok, err := s.verifyTOTP(ctx, userID, state, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	// 2FA включается только вместе с кодами восстановления
+	var codes []string
+	err = s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.EnableTOTP(ctx, userID, time.Now()); err != nil {
+			return err
+		}
+		codes, err = issueRecoveryCodes(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, userID int, req *models.DisableTwoFactorRequest) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if !user.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return errors.New("password is incorrect")
+	}
+	ok, err := s.VerifyCode(ctx, userID, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid two-factor code")
+	}
+
+	return s.repo.DisableTOTP(ctx, userID)
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	ok, err := s.VerifyCode(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	return issueRecoveryCodes(ctx, s.repo, userID)
+}
+
+func (s *twoFactorService) VerifyCode(ctx context.Context, userID int, code string) (bool, error) {
+	state, err := s.repo.GetTOTPState(ctx, userID)
+	if err != nil || state == nil || state.EnabledAt == nil {
+		return false, err
+	}
+
+	code = normalizeTwoFactorCode(code)
+	if len(code) == utils.TOTPDigits && isDigits(code) {
+		return s.verifyTOTP(ctx, userID, state, code)
+	}
+
+	return s.repo.UseRecoveryCode(ctx, userID, utils.HashToken(code))
+}
+
+// verifyTOTP проверяет код и запоминает его интервал, чтобы перехваченный код нельзя было повторить
+func (s *twoFactorService) verifyTOTP(ctx context.Context, userID int, state *models.TOTPState, code string) (bool, error) {
+	secret, err := utils.DecryptSecret(s.encryptionKey, state.Secret)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, normalizeTwoFactorCode(code), time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	return s.repo.ClaimTOTPStep(ctx, userID, step)
+}
+
+// issueRecoveryCodes создает новый набор кодов восстановления вместо прежнего
+func issueRecoveryCodes(ctx context.Context, repo repository.Repository, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, recoveryCodeLength)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := make([]byte, recoveryCodeLength)
+		for j, b := range buf {
+			code[j] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+		}
+		codes[i] = string(code[:recoveryCodeLength/2]) + "-" + string(code[recoveryCodeLength/2:])
+		hashes[i] = utils.HashToken(string(code))
+	}
+
+	if err := repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// normalizeTwoFactorCode убирает пробелы и дефисы и приводит код к нижнему регистру
+func normalizeTwoFactorCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
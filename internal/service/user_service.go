@@ -3,38 +3,90 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/money"
 	"personal-finance-tracker/internal/repository"
 	"personal-finance-tracker/internal/utils"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
-	Register(ctx context.Context, user *models.User) error
-	Login(ctx context.Context, loginReq *models.LoginRequest) (*models.User, string, error)
+	// Register создает пользователя; попытки регистрации с одного IP ограничиваются
+	Register(ctx context.Context, user *models.User, client models.SessionClient) error
+	// Login проверяет пароль; неудачные попытки ограничиваются по IP и учетной записи. Если у пользователя включена 2FA, вместо токенов сессии
+	// возвращается токен второго шага для LoginTwoFactor.
+	Login(ctx context.Context, loginReq *models.LoginRequest, client models.SessionClient) (*models.LoginResult, error)
+	// LoginTwoFactor завершает вход кодом 2FA и только тогда создает сессию
+	LoginTwoFactor(ctx context.Context, req *models.TwoFactorLoginRequest, client models.SessionClient) (*models.LoginResult, error)
+	// StartOIDCLogin начинает вход через провайдера OpenID Connect и возвращает адрес его страницы входа
+	StartOIDCLogin(ctx context.Context, client models.SessionClient) (*models.OIDCAuthorization, error)
+	// LoginOIDC завершает вход через провайдера по коду авторизации. При первом входе учетная
+	// запись провайдера привязывается к пользователю или создается новый пользователь.
+	LoginOIDC(ctx context.Context, req *models.OIDCCallbackRequest, client models.SessionClient) (*models.LoginResult, error)
+	// RefreshToken обменивает refresh-токен на новую пару; повторное предъявление
+	// уже обмененного токена отзывает всю сессию
+	RefreshToken(ctx context.Context, refreshToken string, client models.SessionClient) (*models.TokenPair, error)
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
-	ValidateToken(ctx context.Context, token string) (*models.User, error)
-	Logout(token string) error
+	ValidateSession(ctx context.Context, userID, sessionID int, client models.SessionClient) (*models.User, error)
+	Logout(ctx context.Context, sessionID int) error
+	GetSessions(ctx context.Context, userID, currentSessionID int) ([]models.SessionInfo, error)
+	RevokeSession(ctx context.Context, userID, sessionID int) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID int) error
+	PurgeExpiredSessions(ctx context.Context) (int64, error)
 	SetDefaultCurrency(ctx context.Context, userID, currencyID int) error
 	GetUserWithAccounts(ctx context.Context, userID int) (*models.User, []models.Account, error)
+	GetAllUsers(ctx context.Context) ([]models.User, error)
+	SetUserRole(ctx context.Context, actorID, userID int, role string) (*models.User, error)
+	EnsureAdmins(ctx context.Context, emails []string) error
 }
 
+// Как часто обновляется время последней активности сессии: не на каждый запрос
+const sessionTouchInterval = time.Minute
+
+// Время на ввод кода 2FA после проверки пароля
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// Время на вход у провайдера OpenID Connect
+const oidcAuthRequestTTL = 10 * time.Minute
+
 type userService struct {
-	repo           repository.Repository
-	accountService AccountService
+	repo             repository.Repository
+	accountService   AccountService
+	twoFactorService TwoFactorService
+	loginLimiter     LoginLimiter
+	oidcProvider     OIDCProvider
+	audit            AuditService
+	accessTokenTTL   time.Duration
+	refreshTokenTTL  time.Duration
 }
 
-func NewUserService(repo repository.Repository) UserService {
-	accountService := NewAccountService(repo)
+// NewUserService создает сервис пользователей; oidcProvider — nil, если вход через OpenID Connect не настроен
+func NewUserService(repo repository.Repository, twoFactorService TwoFactorService, loginLimiter LoginLimiter, oidcProvider OIDCProvider, audit AuditService, accessTokenTTL, refreshTokenTTL time.Duration) UserService {
+	accountService := NewAccountService(repo, audit)
 	return &userService{
-		repo:           repo,
-		accountService: accountService,
+		repo:             repo,
+		accountService:   accountService,
+		twoFactorService: twoFactorService,
+		loginLimiter:     loginLimiter,
+		oidcProvider:     oidcProvider,
+		audit:            audit,
+		accessTokenTTL:   accessTokenTTL,
+		refreshTokenTTL:  refreshTokenTTL,
 	}
 }
 
-func (s *userService) Register(ctx context.Context, user *models.User) error {
+func (s *userService) Register(ctx context.Context, user *models.User, client models.SessionClient) error {
+	// Каждая попытка регистрации, успешная или нет, учитывается в лимите IP
+	ipKey := LimitKey{Kind: LimitRegisterIP, Value: client.IPAddress}
+	if err := s.loginLimiter.Attempt(ctx, ipKey); err != nil {
+		return err
+	}
+
 	// Проверяем, существует ли пользователь с таким email
 	existingUser, err := s.repo.GetUserByEmail(ctx, user.Email)
 	if err != nil {
@@ -44,7 +96,8 @@ func (s *userService) Register(ctx context.Context, user *models.User) error {
 		return errors.New("user with this email already exists")
 	}
 
-	// Создаем пользователя
+	// Создаем пользователя; администраторы назначаются отдельно
+	user.Role = models.RoleUser
 	err = s.repo.CreateUser(ctx, user)
 	if err != nil {
 		return err
@@ -55,59 +108,425 @@ func (s *userService) Register(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-func (s *userService) Login(ctx context.Context, loginReq *models.LoginRequest) (*models.User, string, error) {
+func (s *userService) Login(ctx context.Context, loginReq *models.LoginRequest, client models.SessionClient) (*models.LoginResult, error) {
+	// Попытка учитывается до проверки пароля, а после успеха отменяется.
+	// Заблокированная учетная запись отклоняется даже с верным паролем.
+	ipKey := LimitKey{Kind: LimitLoginIP, Value: client.IPAddress}
+	accountKey := LimitKey{Kind: LimitLoginAccount, Value: loginReq.Email}
+	if err := s.loginLimiter.Attempt(ctx, ipKey, accountKey); err != nil {
+		return nil, err
+	}
+
 	// Находим пользователя по email
 	user, err := s.repo.GetUserByEmail(ctx, loginReq.Email)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	// Неизвестный email считается так же, как неверный пароль
+	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginReq.Password)) != nil {
+		return nil, errors.New("invalid email or password")
+	}
+
+	// С включенной 2FA сессия создается только после проверки кода, поэтому счетчик
+	// учетной записи не обнуляется — отменяется лишь эта попытка
+	if user.TwoFactorEnabled {
+		if err := s.loginLimiter.Release(ctx, ipKey, accountKey); err != nil {
+			return nil, err
+		}
+		return twoFactorChallenge(user)
+	}
+
+	if err := s.loginLimiter.Release(ctx, ipKey); err != nil {
+		return nil, err
+	}
+	if err := s.loginLimiter.Reset(ctx, accountKey); err != nil {
+		return nil, err
+	}
+
+	// Начинаем новую сессию
+	return s.signIn(ctx, user, client)
+}
+
+func (s *userService) LoginTwoFactor(ctx context.Context, req *models.TwoFactorLoginRequest, client models.SessionClient) (*models.LoginResult, error) {
+	ipKey := LimitKey{Kind: LimitLoginIP, Value: client.IPAddress}
+	if err := s.loginLimiter.Attempt(ctx, ipKey); err != nil {
+		return nil, err
+	}
+
+	claims, err := utils.ValidateTwoFactorToken(req.ChallengeToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
 	}
 	if user == nil {
-		return nil, "", errors.New("invalid email or password")
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	// Подбор кода 2FA ограничивается так же, как подбор пароля
+	accountKey := LimitKey{Kind: LimitLoginAccount, Value: user.Email}
+	if err := s.loginLimiter.Attempt(ctx, accountKey); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.twoFactorService.VerifyCode(ctx, user.ID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	if err := s.loginLimiter.Release(ctx, ipKey); err != nil {
+		return nil, err
+	}
+	if err := s.loginLimiter.Reset(ctx, accountKey); err != nil {
+		return nil, err
+	}
+
+	return s.signIn(ctx, user, client)
+}
+
+func (s *userService) StartOIDCLogin(ctx context.Context, client models.SessionClient) (*models.OIDCAuthorization, error) {
+	if s.oidcProvider == nil {
+		return nil, errors.New("oidc login is not configured")
+	}
+	// Каждое начало входа сохраняет запрос в базе, поэтому учитывается в лимите IP, как регистрация
+	if err := s.loginLimiter.Attempt(ctx, LimitKey{Kind: LimitOIDCStartIP, Value: client.IPAddress}); err != nil {
+		return nil, err
+	}
+
+	// state связывает возврат от провайдера с этим входом, nonce — ID-токен с ним,
+	// а code_verifier (PKCE) не дает воспользоваться перехваченным кодом
+	var state, nonce, codeVerifier string
+	for _, value := range []*string{&state, &nonce, &codeVerifier} {
+		token, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		*value = token
+	}
+
+	authURL, err := s.oidcProvider.AuthorizationURL(ctx, state, nonce, pkceChallenge(codeVerifier))
+	if err != nil {
+		log.Printf("Failed to start OIDC login: %v", err)
+		return nil, errors.New("oidc provider is unavailable")
+	}
+
+	authReq := &models.OIDCAuthRequest{
+		StateHash:    utils.HashToken(state),
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcAuthRequestTTL),
+	}
+	if err := s.repo.CreateOIDCAuthRequest(ctx, authReq); err != nil {
+		return nil, err
+	}
+
+	return &models.OIDCAuthorization{
+		AuthorizationURL: authURL,
+		State:            state,
+		ExpiresIn:        int(oidcAuthRequestTTL.Seconds()),
+	}, nil
+}
+
+func (s *userService) LoginOIDC(ctx context.Context, req *models.OIDCCallbackRequest, client models.SessionClient) (*models.LoginResult, error) {
+	if s.oidcProvider == nil {
+		return nil, errors.New("oidc login is not configured")
+	}
+	ipKey := LimitKey{Kind: LimitLoginIP, Value: client.IPAddress}
+	if err := s.loginLimiter.Attempt(ctx, ipKey); err != nil {
+		return nil, err
+	}
+
+	// state одноразовый: повторный возврат с тем же кодом отклоняется
+	authReq, err := s.repo.ConsumeOIDCAuthRequest(ctx, utils.HashToken(req.State))
+	if err != nil {
+		return nil, err
+	}
+	if authReq == nil {
+		return nil, errors.New("invalid or expired oidc state")
+	}
+
+	claims, err := s.oidcProvider.Exchange(ctx, req.Code, authReq.CodeVerifier, authReq.Nonce)
+	if err != nil {
+		log.Printf("OIDC login failed: %v", err)
+		return nil, errors.New("oidc login failed")
+	}
+	if err := s.loginLimiter.Release(ctx, ipKey); err != nil {
+		return nil, err
+	}
+
+	user, err := s.oidcUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// Провайдер заменяет пароль, но не второй фактор
+	if user.TwoFactorEnabled {
+		return twoFactorChallenge(user)
+	}
+
+	return s.signIn(ctx, user, client)
+}
+
+// oidcUser находит пользователя по учетной записи провайдера. При первом входе она
+// привязывается к пользователю с тем же email, если провайдер подтвердил этот email,
+// а если такого пользователя нет — создается новый.
+func (s *userService) oidcUser(ctx context.Context, claims *models.OIDCClaims) (*models.User, error) {
+	now := time.Now()
+
+	identity, err := s.repo.GetUserIdentity(ctx, claims.Issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		if err := s.repo.TouchUserIdentity(ctx, identity.ID, claims.Email, now); err != nil {
+			return nil, err
+		}
+		user, err := s.repo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("user not found")
+		}
+		return user, nil
+	}
+
+	if claims.Email == "" {
+		return nil, errors.New("oidc provider did not return an email")
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		// Неподтвержденный email позволил бы войти в чужую учетную запись
+		if !claims.EmailVerified {
+			return nil, errors.New("user with this email already exists")
+		}
+	} else {
+		if user, err = s.createOIDCUser(ctx, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	if claims.EmailVerified && user.VerifiedAt == nil {
+		if err := s.repo.SetUserVerified(ctx, user.ID, now); err != nil {
+			return nil, err
+		}
+		user.VerifiedAt = &now
+	}
+
+	identity = &models.UserIdentity{
+		UserID:      user.ID,
+		Issuer:      claims.Issuer,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+	if err := s.repo.CreateUserIdentity(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createOIDCUser создает пользователя при первом входе через провайдера. Пароль случайный
+// и никому не известен: задать свой можно через сброс пароля.
+func (s *userService) createOIDCUser(ctx context.Context, claims *models.OIDCClaims) (*models.User, error) {
+	password, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	username, err := s.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username: username,
+		Email:    claims.Email,
+		Password: password,
+		Role:     models.RoleUser,
+	}
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Сколько вариантов имени с числовым суффиксом перебирается при совпадении
+const maxUsernameAttempts = 100
+
+// availableUsername подбирает свободное имя пользователя из имени у провайдера или email
+func (s *userService) availableUsername(ctx context.Context, claims *models.OIDCClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.Map(func(r rune) rune {
+		if r == '.' || r == '_' || r == '-' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return -1
+	}, base)
+	if len(base) < 3 {
+		base = "user"
 	}
+	// Остается место для суффикса в пределах VARCHAR(50)
+	base = base[:min(len(base), 40)]
 
-	// Проверяем пароль
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginReq.Password))
+	for i := 1; i <= maxUsernameAttempts; i++ {
+		username := base
+		if i > 1 {
+			username = fmt.Sprintf("%s-%d", base, i)
+		}
+		exists, err := s.repo.UsernameExists(ctx, username)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return username, nil
+		}
+	}
+	return "", errors.New("failed to choose a username")
+}
+
+// twoFactorChallenge выдает токен второго шага вместо сессии
+func twoFactorChallenge(user *models.User) (*models.LoginResult, error) {
+	challenge, err := utils.GenerateTwoFactorToken(user.ID, twoFactorChallengeTTL)
 	if err != nil {
-		return nil, "", errors.New("invalid email or password")
+		return nil, err
+	}
+	return &models.LoginResult{ChallengeToken: challenge, ChallengeTTL: twoFactorChallengeTTL}, nil
+}
+
+// signIn начинает новую сессию; вход отменяет запрошенное удаление учетной записи
+func (s *userService) signIn(ctx context.Context, user *models.User, client models.SessionClient) (*models.LoginResult, error) {
+	result := &models.LoginResult{User: user}
+	if user.DeletionScheduledAt != nil {
+		if err := s.repo.CancelUserDeletion(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		user.DeletionScheduledAt = nil
+		result.DeletionCanceled = true
 	}
 
-	// Генерируем JWT токен
-	token, err := utils.GenerateJWT(user.ID, user.Email)
+	tokens, err := s.issueTokens(ctx, user, nil, client)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	// Создаем сессию в БД
+	result.Tokens = tokens
+	return result, nil
+}
+
+func (s *userService) RefreshToken(ctx context.Context, refreshToken string, client models.SessionClient) (*models.TokenPair, error) {
+	session, err := s.repo.GetSessionByTokenHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.RevokedAt != nil || !session.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("invalid or expired refresh token")
+	}
+
+	// Токен уже обменивали: им пользуется кто-то еще, поэтому отзываем все семейство.
+	// RotateSession атомарен, так что из двух параллельных обменов успешен только один.
+	rotated := false
+	if session.RotatedAt == nil {
+		if rotated, err = s.repo.RotateSession(ctx, session.ID); err != nil {
+			return nil, err
+		}
+	}
+	if !rotated {
+		if err := s.repo.RevokeSessionFamily(ctx, session.FamilyID); err != nil {
+			return nil, err
+		}
+		log.Printf("Refresh token reuse detected for user %d, session %d revoked", session.UserID, session.FamilyID)
+		return nil, errors.New("invalid or expired refresh token")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	return s.issueTokens(ctx, user, session, client)
+}
+
+// issueTokens выпускает refresh-токен и access-токен к нему. С parent токен продолжает
+// семейство обмененного токена, без него начинается новая сессия.
+func (s *userService) issueTokens(ctx context.Context, user *models.User, parent *models.Session, client models.SessionClient) (*models.TokenPair, error) {
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
 	session := &models.Session{
-		UserID:    user.ID,
-		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour * 7), // 7 дней
+		UserID:     user.ID,
+		TokenHash:  utils.HashToken(refreshToken),
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		SignedInAt: now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.refreshTokenTTL),
+	}
+	if parent != nil {
+		session.FamilyID = parent.FamilyID
+		session.SignedInAt = parent.SignedInAt
+		if session.UserAgent == "" {
+			session.UserAgent = parent.UserAgent
+		}
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
 	}
 
-	err = s.repo.CreateSession(ctx, session)
+	accessToken, err := utils.GenerateJWT(user.ID, user.Email, user.Role, session.FamilyID, s.accessTokenTTL)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	return user, token, nil
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+	}, nil
 }
 
 func (s *userService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	return s.repo.GetUserByID(ctx, id)
 }
 
-func (s *userService) ValidateToken(ctx context.Context, token string) (*models.User, error) {
-	// Проверяем сессию в БД
-	session, err := s.repo.GetSessionByToken(ctx, token)
+// ValidateSession проверяет, что сессия access-токена не отозвана, и возвращает пользователя
+func (s *userService) ValidateSession(ctx context.Context, userID, sessionID int, client models.SessionClient) (*models.User, error) {
+	active, err := s.repo.IsSessionFamilyActive(ctx, sessionID)
 	if err != nil {
 		return nil, err
 	}
-	if session == nil {
+	if !active {
 		return nil, errors.New("invalid or expired token")
 	}
 
+	now := time.Now()
+	if err := s.repo.TouchSession(ctx, sessionID, client.IPAddress, now, now.Add(-sessionTouchInterval)); err != nil {
+		return nil, err
+	}
+
 	// Получаем пользователя
-	user, err := s.repo.GetUserByID(ctx, session.UserID)
+	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -118,8 +537,48 @@ func (s *userService) ValidateToken(ctx context.Context, token string) (*models.
 	return user, nil
 }
 
-func (s *userService) Logout(token string) error {
-	return s.repo.DeleteSession(token)
+func (s *userService) Logout(ctx context.Context, sessionID int) error {
+	return s.repo.RevokeSessionFamily(ctx, sessionID)
+}
+
+// GetSessions возвращает действующие сессии пользователя, отмечая текущую
+func (s *userService) GetSessions(ctx context.Context, userID, currentSessionID int) ([]models.SessionInfo, error) {
+	sessions, err := s.repo.GetActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию пользователя на другом устройстве (или текущую)
+func (s *userService) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	revoked, err := s.repo.RevokeUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("session not found")
+	}
+	return nil
+}
+
+// RevokeOtherSessions завершает все сессии пользователя, кроме текущей
+func (s *userService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID int) error {
+	return s.repo.RevokeOtherUserSessions(ctx, userID, currentSessionID)
+}
+
+// PurgeExpiredSessions удаляет истекшие refresh-токены и незавершенные входы через
+// провайдера OpenID Connect; вызывается фоновой задачей
+func (s *userService) PurgeExpiredSessions(ctx context.Context) (int64, error) {
+	if _, err := s.repo.DeleteExpiredOIDCAuthRequests(ctx); err != nil {
+		return 0, err
+	}
+	return s.repo.DeleteExpiredSessions(ctx)
 }
 
 func (s *userService) SetDefaultCurrency(ctx context.Context, userID, currencyID int) error {
@@ -131,14 +590,32 @@ func (s *userService) SetDefaultCurrency(ctx context.Context, userID, currencyID
 	if currency == nil {
 		return errors.New("currency not found")
 	}
+	if !currency.IsActive {
+		return errors.New("currency is disabled")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
 
 	// Устанавливаем валюту по умолчанию пользователю
-	if err := s.repo.SetUserDefaultCurrency(userID, currencyID); err != nil {
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.SetUserDefaultCurrency(userID, currencyID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditUpdate, AuditEntityUserSettings, userID,
+			map[string]any{"default_currency_id": user.DefaultCurrencyID},
+			map[string]any{"default_currency_id": currencyID})
+	}); err != nil {
 		return err
 	}
 
 	// Если у пользователя нет счетов, создаем дефолтный счет в выбранной валюте
-	accounts, err := s.accountService.GetUserAccounts(context.Background(), userID)
+	accounts, err := s.accountService.GetUserAccounts(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -146,10 +623,11 @@ func (s *userService) SetDefaultCurrency(ctx context.Context, userID, currencyID
 		defaultAccount := &models.Account{
 			UserID:     userID,
 			CurrencyID: currencyID,
-			Balance:    0,
+			Balance:    money.Zero,
+			Kind:       "cash",
 			IsDefault:  true,
 		}
-		if err = s.accountService.CreateAccount(context.Background(), defaultAccount); err != nil {
+		if err = s.accountService.CreateAccount(ctx, defaultAccount); err != nil {
 			return err
 		}
 	}
@@ -173,3 +651,60 @@ func (s *userService) GetUserWithAccounts(ctx context.Context, userID int) (*mod
 
 	return user, accounts, nil
 }
+
+func (s *userService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	return s.repo.GetAllUsers(ctx)
+}
+
+// SetUserRole меняет роль пользователя. Свою роль администратор изменить не может,
+// чтобы не остаться без доступа к администрированию.
+func (s *userService) SetUserRole(ctx context.Context, actorID, userID int, role string) (*models.User, error) {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return nil, errors.New("invalid role")
+	}
+	if actorID == userID {
+		return nil, errors.New("cannot change own role")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := s.repo.WithTx(ctx, func(tx repository.Repository) error {
+		if err := tx.SetUserRole(ctx, userID, role); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, tx, userID, models.AuditUpdate, AuditEntityUserRole, userID,
+			map[string]any{"role": user.Role}, map[string]any{"role": role})
+	}); err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	return user, nil
+}
+
+// EnsureAdmins назначает роль admin зарегистрированным пользователям из списка email
+// (ADMIN_EMAILS) — так назначается первый администратор
+func (s *userService) EnsureAdmins(ctx context.Context, emails []string) error {
+	for _, email := range emails {
+		user, err := s.repo.GetUserByEmail(ctx, strings.TrimSpace(email))
+		if err != nil {
+			return err
+		}
+		if user == nil || user.Role == models.RoleAdmin {
+			continue
+		}
+
+		if err := s.repo.SetUserRole(ctx, user.ID, models.RoleAdmin); err != nil {
+			return err
+		}
+		log.Printf("User %s promoted to admin", user.Email)
+	}
+
+	return nil
+}
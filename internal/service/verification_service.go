@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"personal-finance-tracker/internal/models"
+	"personal-finance-tracker/internal/repository"
+	"personal-finance-tracker/internal/utils"
+	"strings"
+	"time"
+)
+
+// Время на доставку письма с подтверждением
+const verificationMailTimeout = 30 * time.Second
+
+type VerificationService interface {
+	// SendVerification отправляет в фоне письмо со ссылкой подтверждения email —
+	// не чаще одного раза за интервал повторной отправки
+	SendVerification(ctx context.Context, user *models.User) error
+	// VerifyEmail подтверждает email по токену из письма
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+}
+
+type verificationService struct {
+	repo           repository.Repository
+	mailer         Mailer
+	tokenTTL       time.Duration
+	resendInterval time.Duration
+	verifyURL      string
+}
+
+// NewVerificationService создает сервис подтверждения email. verifyURL — адрес из письма,
+// в котором {token} заменяется подписанным токеном.
+func NewVerificationService(repo repository.Repository, mailer Mailer, tokenTTL, resendInterval time.Duration, verifyURL string) VerificationService {
+	return &verificationService{
+		repo:           repo,
+		mailer:         mailer,
+		tokenTTL:       tokenTTL,
+		resendInterval: resendInterval,
+		verifyURL:      verifyURL,
+	}
+}
+
+func (s *verificationService) SendVerification(ctx context.Context, user *models.User) error {
+	if user.VerifiedAt != nil {
+		return errors.New("email already verified")
+	}
+
+	now := time.Now()
+	claimed, err := s.repo.ClaimVerificationSend(ctx, user.ID, now, now.Add(-s.resendInterval))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errors.New("verification email was sent recently, try again later")
+	}
+
+	token, err := utils.GenerateEmailVerificationToken(user.ID, user.Email, s.tokenTTL)
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Здравствуйте, %s!\n\n", user.Username)
+	body.WriteString("Чтобы подтвердить адрес электронной почты, перейдите по ссылке:\n")
+	fmt.Fprintf(&body, "%s\n\n", strings.ReplaceAll(s.verifyURL, "{token}", token))
+	fmt.Fprintf(&body, "Ссылка действует %s.\n", formatTTL(s.tokenTTL))
+	body.WriteString("Если вы не регистрировались, просто проигнорируйте это письмо.\n")
+
+	message := &MailMessage{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body:    body.String(),
+	}
+
+	// Письмо отправляется в фоне со своим сроком: медленный SMTP-сервер не задерживает
+	// регистрацию, а завершение запроса не прерывает отправку
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), verificationMailTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, message); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *verificationService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	claims, err := utils.ValidateEmailVerificationToken(token)
+	if err != nil {
+		return nil, errors.New("invalid or expired verification token")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// Ссылка, выданная на прежний адрес, не подтверждает текущий
+	if user == nil || user.Email != claims.Email {
+		return nil, errors.New("invalid or expired verification token")
+	}
+	if user.VerifiedAt != nil {
+		return user, nil
+	}
+
+	now := time.Now()
+	if err := s.repo.SetUserVerified(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+	user.VerifiedAt = &now
+
+	return user, nil
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -8,9 +12,20 @@ import (
 
 var jwtSecret []byte
 
+// Назначение токена (aud): токен одного назначения не принимается вместо другого
+const (
+	accessTokenAudience       = "access"
+	emailVerificationAudience = "email-verification"
+	twoFactorAudience         = "two-factor"
+)
+
+// Claims — содержимое access-токена. SessionID связывает токен с сессией в БД,
+// чтобы выход из системы отзывал и еще не истекшие access-токены.
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID int    `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -18,13 +33,17 @@ func SetJWTSecret(secret string) {
 	jwtSecret = []byte(secret)
 }
 
-func GenerateJWT(userID int, email string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour * 7) // 7 дней
+// GenerateJWT выпускает access-токен сессии со сроком действия ttl
+func GenerateJWT(userID int, email, role string, sessionID int, ttl time.Duration) (string, error) {
+	expirationTime := time.Now().Add(ttl)
 
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{accessTokenAudience},
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -36,18 +55,101 @@ func GenerateJWT(userID int, email string) (string, error) {
 
 func ValidateJWT(tokenString string) (*Claims, error) {
 	claims := &Claims{}
+	if err := parseSigned(tokenString, claims, accessTokenAudience); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// EmailVerificationClaims — содержимое токена подтверждения email. Токен привязан к адресу:
+// после смены email старые ссылки перестают действовать.
+type EmailVerificationClaims struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// GenerateEmailVerificationToken выпускает подписанный токен подтверждения email
+func GenerateEmailVerificationToken(userID int, email string, ttl time.Duration) (string, error) {
+	claims := &EmailVerificationClaims{
+		UserID: userID,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{emailVerificationAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+func ValidateEmailVerificationToken(tokenString string) (*EmailVerificationClaims, error) {
+	claims := &EmailVerificationClaims{}
+	if err := parseSigned(tokenString, claims, emailVerificationAudience); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// TwoFactorClaims — содержимое токена второго шага входа: пароль проверен, ждем код 2FA
+type TwoFactorClaims struct {
+	UserID int `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateTwoFactorToken выпускает токен второго шага входа
+func GenerateTwoFactorToken(userID int, ttl time.Duration) (string, error) {
+	claims := &TwoFactorClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{twoFactorAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+func ValidateTwoFactorToken(tokenString string) (*TwoFactorClaims, error) {
+	claims := &TwoFactorClaims{}
+	if err := parseSigned(tokenString, claims, twoFactorAudience); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
 
+// parseSigned проверяет подпись HS256, срок действия и назначение токена
+func parseSigned(tokenString string, claims jwt.Claims, audience string) error {
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return jwtSecret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(audience))
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if !token.Valid {
-		return nil, jwt.ErrSignatureInvalid
+		return jwt.ErrSignatureInvalid
 	}
 
-	return claims, nil
+	return nil
+}
+
+// GenerateOpaqueToken возвращает случайный токен (refresh-токен и т.п.) из 32 байт в base64url
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken возвращает SHA-256 токена в hex — в БД хранятся только хеши
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,10 @@
+-- Откат миграции для целей накопления
+
+-- Удаление индексов
+DROP INDEX IF EXISTS idx_goal_contributions_goal_id_date;
+DROP INDEX IF EXISTS idx_savings_goals_account_id;
+DROP INDEX IF EXISTS idx_savings_goals_user_id;
+
+-- Удаление таблиц
+DROP TABLE IF EXISTS goal_contributions;
+DROP TABLE IF EXISTS savings_goals;
@@ -0,0 +1,29 @@
+-- Миграция для целей накопления
+
+-- Создание таблицы целей накопления
+CREATE TABLE IF NOT EXISTS savings_goals (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    target_amount DECIMAL(15,2) NOT NULL CHECK (target_amount > 0),
+    currency_id INTEGER REFERENCES currencies(id),
+    deadline DATE,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание таблицы ручных взносов в цель
+CREATE TABLE IF NOT EXISTS goal_contributions (
+    id SERIAL PRIMARY KEY,
+    goal_id INTEGER REFERENCES savings_goals(id) ON DELETE CASCADE,
+    amount DECIMAL(15,2) NOT NULL,
+    date DATE NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы для улучшения производительности
+CREATE INDEX IF NOT EXISTS idx_savings_goals_user_id ON savings_goals(user_id);
+CREATE INDEX IF NOT EXISTS idx_savings_goals_account_id ON savings_goals(account_id);
+CREATE INDEX IF NOT EXISTS idx_goal_contributions_goal_id_date ON goal_contributions(goal_id, date);
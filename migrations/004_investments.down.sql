@@ -0,0 +1,14 @@
+-- Откат миграции для инвестиционных счетов
+
+-- Удаление индексов
+DROP INDEX IF EXISTS idx_investment_transactions_security_id;
+DROP INDEX IF EXISTS idx_investment_transactions_account_id_date;
+
+-- Удаление таблиц
+DROP TABLE IF EXISTS security_prices;
+DROP TABLE IF EXISTS investment_transactions;
+DROP TABLE IF EXISTS securities;
+
+-- Удаление вида счета
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_kind_check;
+ALTER TABLE accounts DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,43 @@
+-- Миграция для инвестиционных счетов
+
+-- Вид счета: обычный денежный или брокерский
+ALTER TABLE accounts ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'cash';
+ALTER TABLE accounts ADD CONSTRAINT accounts_kind_check CHECK (kind IN ('cash', 'investment'));
+
+-- Создание таблицы ценных бумаг
+CREATE TABLE IF NOT EXISTS securities (
+    id SERIAL PRIMARY KEY,
+    ticker VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    asset_class VARCHAR(20) NOT NULL DEFAULT 'stock'
+        CHECK (asset_class IN ('stock', 'bond', 'etf', 'fund', 'commodity', 'real_estate', 'crypto', 'other')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание таблицы инвестиционных операций (покупка, продажа, дивиденд)
+CREATE TABLE IF NOT EXISTS investment_transactions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    security_id INTEGER REFERENCES securities(id),
+    type VARCHAR(10) CHECK (type IN ('buy', 'sell', 'dividend')) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL DEFAULT 0,
+    price DECIMAL(15,6) NOT NULL DEFAULT 0,
+    fee DECIMAL(15,2) NOT NULL DEFAULT 0,
+    amount DECIMAL(15,2) NOT NULL,
+    date DATE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Создание таблицы котировок (импортируется из CSV)
+CREATE TABLE IF NOT EXISTS security_prices (
+    security_id INTEGER REFERENCES securities(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    price DECIMAL(15,6) NOT NULL,
+    PRIMARY KEY (security_id, date)
+);
+
+-- Индексы для улучшения производительности
+CREATE INDEX IF NOT EXISTS idx_investment_transactions_account_id_date ON investment_transactions(account_id, date);
+CREATE INDEX IF NOT EXISTS idx_investment_transactions_security_id ON investment_transactions(security_id);
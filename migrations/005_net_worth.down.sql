@@ -0,0 +1,6 @@
+-- Откат миграции для отчета о чистом капитале
+
+-- Пассивные счета переводятся в обычные денежные
+UPDATE accounts SET kind = 'cash' WHERE kind IN ('credit_card', 'loan');
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_kind_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_kind_check CHECK (kind IN ('cash', 'investment'));
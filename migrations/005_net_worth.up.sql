@@ -0,0 +1,6 @@
+-- Миграция для отчета о чистом капитале
+
+-- Добавляем пассивные виды счетов (кредитная карта, кредит).
+-- Их отрицательный баланс — задолженность, которая вычитается из капитала.
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_kind_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_kind_check CHECK (kind IN ('cash', 'investment', 'credit_card', 'loan'));
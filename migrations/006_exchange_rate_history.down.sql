@@ -0,0 +1,24 @@
+-- Откат миграции для истории курсов валют
+
+-- Откат выполняется, только если миграция была применена (есть колонка rate_date)
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'exchange_rates' AND column_name = 'rate_date'
+    ) THEN
+        -- Оставляем только последний курс по каждой паре
+        DELETE FROM exchange_rates er
+        USING exchange_rates newer
+        WHERE er.base_currency_id = newer.base_currency_id
+          AND er.target_currency_id = newer.target_currency_id
+          AND er.rate_date < newer.rate_date;
+
+        DROP INDEX IF EXISTS idx_exchange_rates_base_target_date;
+        CREATE INDEX IF NOT EXISTS idx_exchange_rates_base_target ON exchange_rates(base_currency_id, target_currency_id);
+
+        ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS exchange_rates_pair_date_key;
+        ALTER TABLE exchange_rates ADD CONSTRAINT exchange_rates_base_currency_id_target_currency_id_key UNIQUE (base_currency_id, target_currency_id);
+        ALTER TABLE exchange_rates DROP COLUMN rate_date;
+    END IF;
+END $$;
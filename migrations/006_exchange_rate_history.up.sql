@@ -0,0 +1,15 @@
+-- Миграция для истории курсов валют
+
+-- Дата, на которую действует курс. Вместо одной строки на пару храним временной ряд.
+ALTER TABLE exchange_rates ADD COLUMN rate_date DATE;
+UPDATE exchange_rates SET rate_date = last_updated::date;
+ALTER TABLE exchange_rates ALTER COLUMN rate_date SET DEFAULT CURRENT_DATE;
+ALTER TABLE exchange_rates ALTER COLUMN rate_date SET NOT NULL;
+
+-- Одна строка на пару и дату
+ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS exchange_rates_base_currency_id_target_currency_id_key;
+ALTER TABLE exchange_rates ADD CONSTRAINT exchange_rates_pair_date_key UNIQUE (base_currency_id, target_currency_id, rate_date);
+
+-- Индекс для поиска последнего курса на дату
+DROP INDEX IF EXISTS idx_exchange_rates_base_target;
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_base_target_date ON exchange_rates(base_currency_id, target_currency_id, rate_date DESC);
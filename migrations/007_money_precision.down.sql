@@ -0,0 +1,6 @@
+-- Откат миграции точных денежных сумм
+
+ALTER TABLE exchange_rates ALTER COLUMN rate TYPE DECIMAL(15,6);
+
+ALTER TABLE currencies DROP CONSTRAINT IF EXISTS currencies_minor_units_check;
+ALTER TABLE currencies DROP COLUMN IF EXISTS minor_units;
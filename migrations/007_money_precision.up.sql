@@ -0,0 +1,9 @@
+-- Миграция для точных денежных сумм
+
+-- Число знаков после запятой в минимальной единице валюты (2 для USD, 0 для JPY, 3 для KWD).
+-- Суммы при конвертации округляются до этой точности.
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS minor_units SMALLINT NOT NULL DEFAULT 2;
+ALTER TABLE currencies ADD CONSTRAINT currencies_minor_units_check CHECK (minor_units BETWEEN 0 AND 18);
+
+-- Кросс-курсы вида RUB->USD (0.0109…) при 6 знаках теряли точность
+ALTER TABLE exchange_rates ALTER COLUMN rate TYPE DECIMAL(24,12);
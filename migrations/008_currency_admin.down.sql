@@ -0,0 +1,10 @@
+-- Откат миграции для администрирования валют
+
+-- Валюты из справочника ISO 4217 не удаляются: на них могут ссылаться счета и курсы
+DROP TABLE IF EXISTS user_currencies;
+
+DROP INDEX IF EXISTS idx_currencies_is_active;
+ALTER TABLE currencies DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE currencies DROP COLUMN IF EXISTS is_active;
+ALTER TABLE currencies DROP CONSTRAINT IF EXISTS currencies_symbol_position_check;
+ALTER TABLE currencies DROP COLUMN IF EXISTS symbol_position;
@@ -0,0 +1,183 @@
+-- Миграция для администрирования валют
+
+-- Положение символа относительно суммы ("$10.00" или "10,00 ₽")
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS symbol_position VARCHAR(6) NOT NULL DEFAULT 'before';
+ALTER TABLE currencies ADD CONSTRAINT currencies_symbol_position_check CHECK (symbol_position IN ('before', 'after'));
+
+-- Отключенная валюта остается у существующих счетов и курсов,
+-- но недоступна для новых счетов, целей и выбора пользователями
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Справочник ISO 4217: число знаков минимальной единицы и положение символа.
+-- У уже существующих валют обновляются только метаданные, название и символ не меняются.
+INSERT INTO currencies (code, name, symbol, minor_units, symbol_position) VALUES
+('AED', 'UAE Dirham', 'د.إ', 2, 'before'),
+('AFN', 'Afghani', '؋', 2, 'before'),
+('ALL', 'Lek', 'L', 2, 'after'),
+('AMD', 'Armenian Dram', '֏', 2, 'after'),
+('ANG', 'Netherlands Antillean Guilder', 'ƒ', 2, 'before'),
+('AOA', 'Kwanza', 'Kz', 2, 'before'),
+('ARS', 'Argentine Peso', '$', 2, 'before'),
+('AUD', 'Australian Dollar', 'A$', 2, 'before'),
+('AWG', 'Aruban Florin', 'ƒ', 2, 'before'),
+('AZN', 'Azerbaijan Manat', '₼', 2, 'before'),
+('BAM', 'Convertible Mark', 'KM', 2, 'after'),
+('BBD', 'Barbados Dollar', '$', 2, 'before'),
+('BDT', 'Taka', '৳', 2, 'before'),
+('BGN', 'Bulgarian Lev', 'лв', 2, 'after'),
+('BHD', 'Bahraini Dinar', 'BD', 3, 'before'),
+('BIF', 'Burundi Franc', 'FBu', 0, 'after'),
+('BMD', 'Bermudian Dollar', '$', 2, 'before'),
+('BND', 'Brunei Dollar', '$', 2, 'before'),
+('BOB', 'Boliviano', 'Bs.', 2, 'before'),
+('BRL', 'Brazilian Real', 'R$', 2, 'before'),
+('BSD', 'Bahamian Dollar', '$', 2, 'before'),
+('BTN', 'Ngultrum', 'Nu.', 2, 'before'),
+('BWP', 'Pula', 'P', 2, 'before'),
+('BYN', 'Belarusian Ruble', 'Br', 2, 'after'),
+('BZD', 'Belize Dollar', 'BZ$', 2, 'before'),
+('CAD', 'Canadian Dollar', 'C$', 2, 'before'),
+('CDF', 'Congolese Franc', 'FC', 2, 'after'),
+('CHF', 'Swiss Franc', 'CHF', 2, 'before'),
+('CLF', 'Unidad de Fomento', 'UF', 4, 'before'),
+('CLP', 'Chilean Peso', '$', 0, 'before'),
+('CNY', 'Yuan Renminbi', '¥', 2, 'before'),
+('COP', 'Colombian Peso', '$', 2, 'before'),
+('CRC', 'Costa Rican Colon', '₡', 2, 'before'),
+('CUP', 'Cuban Peso', '$', 2, 'before'),
+('CVE', 'Cabo Verde Escudo', 'Esc', 2, 'after'),
+('CZK', 'Czech Koruna', 'Kč', 2, 'after'),
+('DJF', 'Djibouti Franc', 'Fdj', 0, 'after'),
+('DKK', 'Danish Krone', 'kr', 2, 'after'),
+('DOP', 'Dominican Peso', 'RD$', 2, 'before'),
+('DZD', 'Algerian Dinar', 'DA', 2, 'after'),
+('EGP', 'Egyptian Pound', 'E£', 2, 'before'),
+('ERN', 'Nakfa', 'Nfk', 2, 'before'),
+('ETB', 'Ethiopian Birr', 'Br', 2, 'before'),
+('EUR', 'Euro', '€', 2, 'before'),
+('FJD', 'Fiji Dollar', 'FJ$', 2, 'before'),
+('FKP', 'Falkland Islands Pound', '£', 2, 'before'),
+('GBP', 'Pound Sterling', '£', 2, 'before'),
+('GEL', 'Lari', '₾', 2, 'after'),
+('GHS', 'Ghana Cedi', '₵', 2, 'before'),
+('GIP', 'Gibraltar Pound', '£', 2, 'before'),
+('GMD', 'Dalasi', 'D', 2, 'before'),
+('GNF', 'Guinean Franc', 'FG', 0, 'after'),
+('GTQ', 'Quetzal', 'Q', 2, 'before'),
+('GYD', 'Guyana Dollar', '$', 2, 'before'),
+('HKD', 'Hong Kong Dollar', 'HK$', 2, 'before'),
+('HNL', 'Lempira', 'L', 2, 'before'),
+('HTG', 'Gourde', 'G', 2, 'before'),
+('HUF', 'Forint', 'Ft', 2, 'after'),
+('IDR', 'Rupiah', 'Rp', 2, 'before'),
+('ILS', 'New Israeli Sheqel', '₪', 2, 'before'),
+('INR', 'Indian Rupee', '₹', 2, 'before'),
+('IQD', 'Iraqi Dinar', 'ع.د', 3, 'before'),
+('IRR', 'Iranian Rial', '﷼', 2, 'before'),
+('ISK', 'Iceland Krona', 'kr', 0, 'after'),
+('JMD', 'Jamaican Dollar', 'J$', 2, 'before'),
+('JOD', 'Jordanian Dinar', 'JD', 3, 'before'),
+('JPY', 'Yen', '¥', 0, 'before'),
+('KES', 'Kenyan Shilling', 'KSh', 2, 'before'),
+('KGS', 'Som', 'сом', 2, 'after'),
+('KHR', 'Riel', '៛', 2, 'after'),
+('KMF', 'Comorian Franc', 'CF', 0, 'after'),
+('KPW', 'North Korean Won', '₩', 2, 'before'),
+('KRW', 'Won', '₩', 0, 'before'),
+('KWD', 'Kuwaiti Dinar', 'KD', 3, 'before'),
+('KYD', 'Cayman Islands Dollar', '$', 2, 'before'),
+('KZT', 'Tenge', '₸', 2, 'after'),
+('LAK', 'Lao Kip', '₭', 2, 'before'),
+('LBP', 'Lebanese Pound', 'L£', 2, 'before'),
+('LKR', 'Sri Lanka Rupee', 'Rs', 2, 'before'),
+('LRD', 'Liberian Dollar', '$', 2, 'before'),
+('LSL', 'Loti', 'L', 2, 'before'),
+('LYD', 'Libyan Dinar', 'LD', 3, 'before'),
+('MAD', 'Moroccan Dirham', 'DH', 2, 'after'),
+('MDL', 'Moldovan Leu', 'L', 2, 'after'),
+('MGA', 'Malagasy Ariary', 'Ar', 2, 'after'),
+('MKD', 'Denar', 'ден', 2, 'after'),
+('MMK', 'Kyat', 'K', 2, 'before'),
+('MNT', 'Tugrik', '₮', 2, 'before'),
+('MOP', 'Pataca', 'MOP$', 2, 'before'),
+('MRU', 'Ouguiya', 'UM', 2, 'after'),
+('MUR', 'Mauritius Rupee', 'Rs', 2, 'before'),
+('MVR', 'Rufiyaa', 'Rf', 2, 'before'),
+('MWK', 'Malawi Kwacha', 'MK', 2, 'before'),
+('MXN', 'Mexican Peso', '$', 2, 'before'),
+('MYR', 'Malaysian Ringgit', 'RM', 2, 'before'),
+('MZN', 'Mozambique Metical', 'MT', 2, 'after'),
+('NAD', 'Namibia Dollar', 'N$', 2, 'before'),
+('NGN', 'Naira', '₦', 2, 'before'),
+('NIO', 'Cordoba Oro', 'C$', 2, 'before'),
+('NOK', 'Norwegian Krone', 'kr', 2, 'after'),
+('NPR', 'Nepalese Rupee', 'Rs', 2, 'before'),
+('NZD', 'New Zealand Dollar', 'NZ$', 2, 'before'),
+('OMR', 'Rial Omani', 'OMR', 3, 'before'),
+('PAB', 'Balboa', 'B/.', 2, 'before'),
+('PEN', 'Sol', 'S/', 2, 'before'),
+('PGK', 'Kina', 'K', 2, 'before'),
+('PHP', 'Philippine Peso', '₱', 2, 'before'),
+('PKR', 'Pakistan Rupee', 'Rs', 2, 'before'),
+('PLN', 'Zloty', 'zł', 2, 'after'),
+('PYG', 'Guarani', '₲', 0, 'before'),
+('QAR', 'Qatari Rial', 'QR', 2, 'before'),
+('RON', 'Romanian Leu', 'lei', 2, 'after'),
+('RSD', 'Serbian Dinar', 'дин.', 2, 'after'),
+('RUB', 'Russian Ruble', '₽', 2, 'after'),
+('RWF', 'Rwanda Franc', 'FRw', 0, 'after'),
+('SAR', 'Saudi Riyal', 'SR', 2, 'before'),
+('SBD', 'Solomon Islands Dollar', 'SI$', 2, 'before'),
+('SCR', 'Seychelles Rupee', 'SR', 2, 'before'),
+('SDG', 'Sudanese Pound', 'SDG', 2, 'before'),
+('SEK', 'Swedish Krona', 'kr', 2, 'after'),
+('SGD', 'Singapore Dollar', 'S$', 2, 'before'),
+('SHP', 'Saint Helena Pound', '£', 2, 'before'),
+('SLE', 'Leone', 'Le', 2, 'before'),
+('SOS', 'Somali Shilling', 'Sh', 2, 'before'),
+('SRD', 'Surinam Dollar', '$', 2, 'before'),
+('SSP', 'South Sudanese Pound', 'SSP', 2, 'before'),
+('STN', 'Dobra', 'Db', 2, 'after'),
+('SVC', 'El Salvador Colon', '₡', 2, 'before'),
+('SYP', 'Syrian Pound', 'LS', 2, 'before'),
+('SZL', 'Lilangeni', 'E', 2, 'before'),
+('THB', 'Baht', '฿', 2, 'before'),
+('TJS', 'Tajikistani Somoni', 'SM', 2, 'after'),
+('TMT', 'Turkmenistan New Manat', 'm', 2, 'after'),
+('TND', 'Tunisian Dinar', 'DT', 3, 'after'),
+('TOP', 'Pa''anga', 'T$', 2, 'before'),
+('TRY', 'Turkish Lira', '₺', 2, 'before'),
+('TTD', 'Trinidad and Tobago Dollar', 'TT$', 2, 'before'),
+('TWD', 'New Taiwan Dollar', 'NT$', 2, 'before'),
+('TZS', 'Tanzanian Shilling', 'TSh', 2, 'before'),
+('UAH', 'Hryvnia', '₴', 2, 'after'),
+('UGX', 'Uganda Shilling', 'USh', 0, 'before'),
+('USD', 'US Dollar', '$', 2, 'before'),
+('UYU', 'Peso Uruguayo', '$U', 2, 'before'),
+('UZS', 'Uzbekistan Sum', 'сўм', 2, 'after'),
+('VES', 'Bolivar Soberano', 'Bs.S', 2, 'before'),
+('VND', 'Dong', '₫', 0, 'after'),
+('VUV', 'Vatu', 'VT', 0, 'after'),
+('WST', 'Tala', 'WS$', 2, 'before'),
+('XAF', 'CFA Franc BEAC', 'FCFA', 0, 'after'),
+('XCD', 'East Caribbean Dollar', 'EC$', 2, 'before'),
+('XOF', 'CFA Franc BCEAO', 'CFA', 0, 'after'),
+('XPF', 'CFP Franc', '₣', 0, 'after'),
+('YER', 'Yemeni Rial', '﷼', 2, 'before'),
+('ZAR', 'Rand', 'R', 2, 'before'),
+('ZMW', 'Zambian Kwacha', 'ZK', 2, 'before'),
+('ZWL', 'Zimbabwe Dollar', 'Z$', 2, 'before')
+ON CONFLICT (code) DO UPDATE SET
+    minor_units = EXCLUDED.minor_units,
+    symbol_position = EXCLUDED.symbol_position;
+
+-- Валюты, выбранные пользователем. Пустой список — доступны все активные валюты.
+CREATE TABLE IF NOT EXISTS user_currencies (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    currency_id INTEGER NOT NULL REFERENCES currencies(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, currency_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_currencies_is_active ON currencies(is_active);
@@ -0,0 +1,26 @@
+-- Откат миграции для криптовалют
+
+-- Коды длиннее трех символов не поместятся в VARCHAR(3): удаляем такие валюты и их курсы.
+-- Если на них ссылаются счета, откат прервется — сначала нужно закрыть эти счета.
+DELETE FROM user_currencies WHERE currency_id IN (SELECT id FROM currencies WHERE LENGTH(code) > 3);
+DELETE FROM exchange_rates
+WHERE base_currency_id IN (SELECT id FROM currencies WHERE LENGTH(code) > 3)
+   OR target_currency_id IN (SELECT id FROM currencies WHERE LENGTH(code) > 3);
+DELETE FROM currencies WHERE LENGTH(code) > 3;
+
+ALTER TABLE exchange_rates ALTER COLUMN rate TYPE DECIMAL(24,12);
+
+ALTER TABLE security_prices ALTER COLUMN price TYPE DECIMAL(15,6);
+ALTER TABLE investment_transactions
+    ALTER COLUMN quantity TYPE DECIMAL(20,8),
+    ALTER COLUMN price TYPE DECIMAL(15,6),
+    ALTER COLUMN fee TYPE DECIMAL(15,2),
+    ALTER COLUMN amount TYPE DECIMAL(15,2);
+ALTER TABLE goal_contributions ALTER COLUMN amount TYPE DECIMAL(15,2);
+ALTER TABLE savings_goals ALTER COLUMN target_amount TYPE DECIMAL(15,2);
+ALTER TABLE transactions ALTER COLUMN amount TYPE DECIMAL(15,2);
+ALTER TABLE accounts ALTER COLUMN balance TYPE DECIMAL(15,2);
+
+ALTER TABLE currencies DROP CONSTRAINT IF EXISTS currencies_kind_check;
+ALTER TABLE currencies DROP COLUMN IF EXISTS kind;
+ALTER TABLE currencies ALTER COLUMN code TYPE VARCHAR(3);
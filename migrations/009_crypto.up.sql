@@ -0,0 +1,39 @@
+-- Миграция для криптовалют
+
+-- Коды криптовалют бывают длиннее трех символов (USDT, DOGE)
+ALTER TABLE currencies ALTER COLUMN code TYPE VARCHAR(10);
+
+-- Вид валюты: фиатная (ISO 4217) или криптовалюта
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS kind VARCHAR(10) NOT NULL DEFAULT 'fiat';
+ALTER TABLE currencies ADD CONSTRAINT currencies_kind_check CHECK (kind IN ('fiat', 'crypto'));
+
+-- Суммы в криптовалютах требуют до 18 знаков после запятой (1 wei = 10^-18 ETH)
+ALTER TABLE accounts ALTER COLUMN balance TYPE DECIMAL(38,18);
+ALTER TABLE transactions ALTER COLUMN amount TYPE DECIMAL(38,18);
+ALTER TABLE savings_goals ALTER COLUMN target_amount TYPE DECIMAL(38,18);
+ALTER TABLE goal_contributions ALTER COLUMN amount TYPE DECIMAL(38,18);
+ALTER TABLE investment_transactions
+    ALTER COLUMN quantity TYPE DECIMAL(38,18),
+    ALTER COLUMN price TYPE DECIMAL(38,18),
+    ALTER COLUMN fee TYPE DECIMAL(38,18),
+    ALTER COLUMN amount TYPE DECIMAL(38,18);
+ALTER TABLE security_prices ALTER COLUMN price TYPE DECIMAL(38,18);
+
+-- Курс USD->BTC (0.0000149…) при 12 знаках сохранял бы лишь несколько значащих цифр
+ALTER TABLE exchange_rates ALTER COLUMN rate TYPE DECIMAL(38,18);
+
+-- Основные криптовалюты; minor_units — точность сети (сатоши, wei и т.д.)
+INSERT INTO currencies (code, name, symbol, minor_units, symbol_position, kind) VALUES
+('BTC', 'Bitcoin', '₿', 8, 'before', 'crypto'),
+('ETH', 'Ether', 'Ξ', 18, 'before', 'crypto'),
+('USDT', 'Tether', '₮', 6, 'before', 'crypto'),
+('USDC', 'USD Coin', 'USDC', 6, 'after', 'crypto'),
+('BNB', 'BNB', 'BNB', 18, 'after', 'crypto'),
+('SOL', 'Solana', 'SOL', 9, 'after', 'crypto'),
+('XRP', 'XRP', 'XRP', 6, 'after', 'crypto'),
+('ADA', 'Cardano', 'ADA', 6, 'after', 'crypto'),
+('DOGE', 'Dogecoin', 'Ð', 8, 'before', 'crypto'),
+('TON', 'Toncoin', 'TON', 9, 'after', 'crypto'),
+('LTC', 'Litecoin', 'Ł', 8, 'before', 'crypto'),
+('TRX', 'TRON', 'TRX', 6, 'after', 'crypto')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Откат миграции для уведомлений о курсах валют
+
+-- Удаление индексов
+DROP INDEX IF EXISTS idx_notifications_user_id_created_at;
+DROP INDEX IF EXISTS idx_rate_alerts_active;
+DROP INDEX IF EXISTS idx_rate_alerts_user_id;
+
+-- Удаление таблиц
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS rate_alerts;
@@ -0,0 +1,36 @@
+-- Миграция для уведомлений о курсах валют
+
+-- Правила оповещения: пересечение порога курса (above/below) или дневное изменение на N% (change)
+CREATE TABLE IF NOT EXISTS rate_alerts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    base_currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    target_currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    condition VARCHAR(10) NOT NULL CHECK (condition IN ('above', 'below', 'change')),
+    threshold DECIMAL(38,18) NOT NULL CHECK (threshold > 0),
+    webhook_url TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    -- Курс при последней проверке: по нему определяется пересечение порога
+    last_rate DECIMAL(38,18),
+    last_triggered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (base_currency_id <> target_currency_id)
+);
+
+-- Лента уведомлений пользователя
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL,
+    rate_alert_id INTEGER REFERENCES rate_alerts(id) ON DELETE SET NULL,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Индексы для улучшения производительности
+CREATE INDEX IF NOT EXISTS idx_rate_alerts_user_id ON rate_alerts(user_id);
+CREATE INDEX IF NOT EXISTS idx_rate_alerts_active ON rate_alerts(is_active) WHERE is_active;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_created_at ON notifications(user_id, created_at DESC);
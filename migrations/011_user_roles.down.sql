@@ -0,0 +1,4 @@
+-- Откат миграции для ролей пользователей
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,6 @@
+-- Миграция для ролей пользователей
+
+-- Роль определяет доступ к общим данным: обновлению курсов, справочнику валют и общим категориям
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(10) NOT NULL DEFAULT 'user';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin'));
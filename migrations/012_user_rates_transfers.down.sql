@@ -0,0 +1,9 @@
+-- Откат миграции для личных курсов валют и переводов между счетами
+
+-- Удаление индексов
+DROP INDEX IF EXISTS idx_transfers_user_id_date;
+DROP INDEX IF EXISTS idx_user_exchange_rates_lookup;
+
+-- Удаление таблиц
+DROP TABLE IF EXISTS transfers;
+DROP TABLE IF EXISTS user_exchange_rates;
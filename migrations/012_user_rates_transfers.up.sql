@@ -0,0 +1,38 @@
+-- Миграция для личных курсов валют и переводов между счетами
+
+-- Личные курсы пользователя (например, курс обменника) на дату.
+-- В пересчетах пользователя имеют приоритет над общими курсами той же или более ранней даты.
+CREATE TABLE IF NOT EXISTS user_exchange_rates (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    base_currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    target_currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    rate DECIMAL(38,18) NOT NULL CHECK (rate > 0),
+    rate_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (base_currency_id <> target_currency_id),
+    CONSTRAINT user_exchange_rates_pair_date_key UNIQUE (user_id, base_currency_id, target_currency_id, rate_date)
+);
+
+-- Переводы между счетами пользователя с фактически примененным курсом
+CREATE TABLE IF NOT EXISTS transfers (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    from_amount DECIMAL(38,18) NOT NULL CHECK (from_amount > 0),
+    to_amount DECIMAL(38,18) NOT NULL CHECK (to_amount > 0),
+    rate DECIMAL(38,18) NOT NULL CHECK (rate > 0),
+    -- Откуда взят курс: same (одна валюта), user (личный курс), global (общий курс), manual (указан при переводе)
+    rate_source VARCHAR(10) NOT NULL CHECK (rate_source IN ('same', 'user', 'global', 'manual')),
+    date DATE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (from_account_id <> to_account_id)
+);
+
+-- Индексы для улучшения производительности
+CREATE INDEX IF NOT EXISTS idx_user_exchange_rates_lookup ON user_exchange_rates(user_id, base_currency_id, target_currency_id, rate_date DESC);
+CREATE INDEX IF NOT EXISTS idx_transfers_user_id_date ON transfers(user_id, date);
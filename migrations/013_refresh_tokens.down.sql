@@ -0,0 +1,11 @@
+-- Откат миграции refresh-токенов
+
+DELETE FROM sessions;
+DROP INDEX IF EXISTS idx_sessions_family_id;
+DROP INDEX IF EXISTS idx_sessions_token_hash;
+ALTER TABLE sessions DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS rotated_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS family_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS token_hash;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS token TEXT UNIQUE NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token);
@@ -0,0 +1,16 @@
+-- Миграция для refresh-токенов
+
+-- Строка sessions — один refresh-токен; токены, полученные ротацией, образуют семейство
+-- (family_id — id первой строки, он же идентификатор сессии в access-токене).
+-- В БД хранится только SHA-256 хеш токена. Сессии со старыми 7-дневными JWT сбрасываются.
+DELETE FROM sessions;
+DROP INDEX IF EXISTS idx_sessions_token;
+ALTER TABLE sessions DROP COLUMN IF EXISTS token;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS token_hash VARCHAR(64) NOT NULL;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS family_id INTEGER NOT NULL;
+-- rotated_at — токен обменян на новый; повторное предъявление означает кражу и отзывает семейство
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_token_hash ON sessions(token_hash);
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
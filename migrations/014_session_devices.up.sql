@@ -0,0 +1,13 @@
+-- Миграция для управления сессиями
+
+-- Устройство сессии: копируется в каждый новый refresh-токен семейства при ротации
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45);
+-- signed_in_at — время входа, с которого началось семейство
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS signed_in_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE sessions SET signed_in_at = created_at WHERE signed_in_at IS NULL;
+UPDATE sessions SET last_seen_at = created_at WHERE last_seen_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
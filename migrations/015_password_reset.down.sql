@@ -0,0 +1,4 @@
+-- Откат миграции сброса пароля
+
+DROP INDEX IF EXISTS idx_password_reset_tokens_user_id;
+DROP TABLE IF EXISTS password_reset_tokens;
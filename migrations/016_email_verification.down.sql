@@ -0,0 +1,4 @@
+-- Откат миграции подтверждения email
+
+ALTER TABLE users DROP COLUMN IF EXISTS verification_sent_at;
+ALTER TABLE users DROP COLUMN IF EXISTS verified_at;
@@ -0,0 +1,8 @@
+-- Миграция для подтверждения email
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+-- Время последнего письма с подтверждением — для ограничения повторной отправки
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_sent_at TIMESTAMP WITH TIME ZONE;
+
+-- Пользователи, зарегистрированные до появления подтверждения, считаются подтвержденными
+UPDATE users SET verified_at = created_at WHERE verified_at IS NULL;
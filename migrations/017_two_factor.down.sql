@@ -0,0 +1,8 @@
+-- Откат миграции двухфакторной аутентификации
+
+DROP INDEX IF EXISTS idx_two_factor_recovery_codes_user_id;
+DROP TABLE IF EXISTS two_factor_recovery_codes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,4 @@
+-- Откат миграции защиты от перебора
+
+DROP INDEX IF EXISTS idx_login_throttles_last_failure_at;
+DROP TABLE IF EXISTS login_throttles;
@@ -0,0 +1,13 @@
+-- Миграция для защиты входа и регистрации от перебора
+
+-- Счетчики неудачных попыток по IP и по учетной записи (key — вид и значение, например
+-- "login-account:user@example.com"); blocked_until — до какого времени попытки отклоняются.
+-- Таблица используется при LOGIN_LIMITER_STORE=postgres, чтобы лимиты действовали на всех экземплярах.
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    blocked_until TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure_at ON login_throttles(last_failure_at);
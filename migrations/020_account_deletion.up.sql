@@ -0,0 +1,8 @@
+-- Миграция для удаления учетных записей
+
+-- deletion_scheduled_at — когда учетная запись будет удалена вместе со всеми данными
+-- (ON DELETE CASCADE); до этого момента вход отменяет удаление.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_scheduled_at ON users(deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Откат миграции журнала аудита
+
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
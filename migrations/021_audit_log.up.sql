@@ -0,0 +1,38 @@
+-- Миграция для журнала аудита изменений
+
+-- user_id — чьи данные изменены (NULL — общие справочники), actor_id — кто изменил
+-- (администратор может менять чужие данные), api_key_id — если запрос пришел с API-ключом.
+-- before_data / after_data — состояние объекта до и после изменения.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    actor_id INTEGER,
+    api_key_id INTEGER,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id INTEGER,
+    before_data JSONB,
+    after_data JSONB,
+    ip_address VARCHAR(45),
+    request_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_log(user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_request_id ON audit_log(request_id);
+
+-- Журнал только дополняется: записи нельзя изменить, а удалить — только каскадно
+-- вместе с пользователем (удаление учетной записи)
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
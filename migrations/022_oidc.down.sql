@@ -0,0 +1,4 @@
+-- Откат миграции входа через OpenID Connect
+
+DROP TABLE IF EXISTS oidc_auth_requests;
+DROP TABLE IF EXISTS user_identities;
@@ -2,6 +2,7 @@ const $ = (sel) => document.querySelector(sel);
 const $$ = (sel) => Array.from(document.querySelectorAll(sel));
 
 let token = localStorage.getItem('token') || '';
+let refreshToken = localStorage.getItem('refresh_token') || '';
 let currentUser = null;
 let charts = {};
 let currencies = [];
@@ -543,7 +544,12 @@ class ApiClient {
         LoadingManager.show();
 
         const doFetch = async () => {
-            const res = await fetch(`${window.API_BASE}${path}`, { ...opts, headers });
+            let res = await fetch(`${window.API_BASE}${path}`, { ...opts, headers });
+            // access-токен живет недолго: обновляем его по refresh-токену и повторяем запрос
+            if (res.status === 401 && token && await AuthManager.refresh()) {
+                headers['Authorization'] = `Bearer ${token}`;
+                res = await fetch(`${window.API_BASE}${path}`, { ...opts, headers });
+            }
             const isJson = (res.headers.get('content-type') || '').includes('application/json');
             const body = isJson ? await res.json() : await res.text();
             if (!res.ok) throw new Error(body?.error || body?.message || res.statusText);
@@ -782,18 +788,92 @@ class UIManager {
 }
 
 class AuthManager {
+    static setTokens(data) {
+        token = data.token || '';
+        refreshToken = data.refresh_token || '';
+        if (token) localStorage.setItem('token', token); else localStorage.removeItem('token');
+        if (refreshToken) localStorage.setItem('refresh_token', refreshToken); else localStorage.removeItem('refresh_token');
+    }
+
+    // Один обмен на все параллельные запросы: повторное использование
+    // refresh-токена сервер считает кражей и завершает сессию
+    static refresh() {
+        if (!refreshToken) return Promise.resolve(false);
+        if (!this._refreshing) {
+            this._refreshing = fetch(`${window.API_BASE}/token/refresh`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ refresh_token: refreshToken })
+            })
+                .then(async (res) => {
+                    if (!res.ok) {
+                        refreshToken = '';
+                        localStorage.removeItem('refresh_token');
+                        return false;
+                    }
+                    AuthManager.setTokens(await res.json());
+                    return true;
+                })
+                .catch(() => false)
+                .finally(() => { this._refreshing = null; });
+        }
+        return this._refreshing;
+    }
+
     static async login(email, password) {
+        const data = await ApiClient.request('/login', {
+            method: 'POST',
+            body: JSON.stringify({ email, password })
+        });
+        await this.completeLogin(data);
+    }
+    
+    // Вход через провайдера OpenID Connect: он вернет пользователя на эту страницу с ?code=&state=.
+    // state запоминается во вкладке, чтобы завершить только вход, начатый в этом браузере.
+    static async startOIDCLogin() {
+        const data = await ApiClient.request('/login/oidc', { method: 'POST' });
+        sessionStorage.setItem('oidc_state', data.state);
+        window.location.href = data.authorization_url;
+    }
+    
+    static async finishOIDCLogin(code, state) {
+        const expectedState = sessionStorage.getItem('oidc_state');
+        sessionStorage.removeItem('oidc_state');
+        if (!expectedState || expectedState !== state) {
+            throw new Error('Вход через провайдера начат не в этом браузере');
+        }
+        const data = await ApiClient.request('/login/oidc/callback', {
+            method: 'POST',
+            body: JSON.stringify({ code, state })
+        });
+        await this.completeLogin(data);
+        // Пользователь, созданный при первом входе, еще не выбрал валюту
+        await CurrencySelection.ensureOrPrompt();
+    }
+    
+    static async completeLogin(data) {
         try {
-            const data = await ApiClient.request('/login', {
-                method: 'POST',
-                body: JSON.stringify({ email, password })
-            });
+            if (data.two_factor_required) {
+                const code = window.prompt('Введите код из приложения-аутентификатора или код восстановления');
+                if (!code) {
+                    throw new Error('Вход отменен: требуется код двухфакторной аутентификации');
+                }
+                data = await ApiClient.request('/login/2fa', {
+                    method: 'POST',
+                    body: JSON.stringify({ challenge_token: data.challenge_token, code: code.trim() })
+                });
+            }
             
-            token = data.token;
-            localStorage.setItem('token', token);
+            AuthManager.setTokens(data);
             try { localStorage.setItem('user', JSON.stringify(data.user || {})); } catch(_) {}
             UIManager.setAuthenticated(data.user);
             NotificationSystem.show('Вход выполнен успешно!', 'success');
+            if (data.deletion_canceled) {
+                NotificationSystem.show('Удаление аккаунта отменено', 'info');
+            }
+            if (data.user && !data.user.verified_at) {
+                NotificationSystem.show('Подтвердите email по ссылке из письма — до этого часть функций недоступна', 'info');
+            }
             
             await DataManager.loadInitialData();
             try { UIManager.refreshCurrencyDisplay(); } catch(_) {}
@@ -827,8 +907,7 @@ class AuthManager {
         } catch (error) {
             console.warn('Logout error:', error);
         } finally {
-            token = '';
-            localStorage.removeItem('token');
+            AuthManager.setTokens({});
             try { localStorage.removeItem('user'); } catch(_) {}
             UIManager.setAnonymous();
             NotificationSystem.show('Вы успешно вышли из системы', 'info');
@@ -886,7 +965,8 @@ class DataManager {
     
     static async loadCurrencies() {
         try {
-            const response = await ApiClient.request('/currencies');
+            // Авторизованному пользователю показываем только выбранные им валюты
+            const response = await ApiClient.request(token ? '/user/currencies' : '/currencies');
             currencies = response;
             try { appState.setState({ currencies: response }); } catch(_) {}
             this.populateCurrencySelects();
@@ -1732,6 +1812,14 @@ class FormHandlers {
             }
         });
         
+        $('#login-oidc').addEventListener('click', async () => {
+            try {
+                await AuthManager.startOIDCLogin();
+            } catch (error) {
+                NotificationSystem.show(error.message, 'error');
+            }
+        });
+        
         $('#register-form').addEventListener('submit', async (e) => {
             e.preventDefault();
             const username = $('#reg-username').value;
@@ -2198,7 +2286,10 @@ window.switchTab = (tabName) => UIManager.switchTab(tabName);
 
 window.updateExchangeRates = async () => {
     try {
-        await ApiClient.request('/exchange/rates/update', { method: 'POST' });
+        // Запрос курсов у провайдеров доступен администратору; остальные перечитывают сохраненные курсы
+        if (currentUser && currentUser.role === 'admin') {
+            await ApiClient.request('/admin/exchange/rates/update', { method: 'POST' });
+        }
         NotificationSystem.show('Курсы валют обновлены!', 'success');
         await DataManager.loadExchangeRates();
         await DataManager.loadExchangeRatesOverview();
@@ -2367,6 +2458,18 @@ async function initApp() {
     } catch (_) {}
     FormHandlers.init();
     
+    // Возврат от провайдера OpenID Connect
+    const params = new URLSearchParams(location.search);
+    if (params.get('code') && params.get('state')) {
+        history.replaceState(null, '', location.pathname);
+        try {
+            await AuthManager.finishOIDCLogin(params.get('code'), params.get('state'));
+            return;
+        } catch (error) {
+            NotificationSystem.show(error.message, 'error');
+        }
+    }
+    
     if (token) {
         // 1) Оптимистично показать UI, чтобы не выбрасывало на форму при перезагрузке
         let cachedUser = null;